@@ -81,7 +81,7 @@ func fn(out io.Writer, s string) bool {
 func printFunction(fn *interpreter.TaFunction, examples bool) string {
 	argumentList := make([]string, len(fn.Arguments))
 	for j := 0; j < len(fn.Arguments); j++ {
-		argumentList[j] = color.WhiteString(fn.Arguments[j].String())
+		argumentList[j] = color.WhiteString(fn.ArgumentString(j))
 	}
 
 	arguments := strings.Join(argumentList, ", ")
@@ -160,12 +160,30 @@ func createCommands() {
 		description: "show the function details",
 	}
 
+	commandExpand := commandDef{
+		function: func(args []string) {
+			if len(args) > 0 {
+				expanded, err := interp.LexAndExpand(strings.Join(args, " "))
+				if err != nil {
+					printErr(err)
+				} else {
+					printResult(expanded.Stringify())
+				}
+			} else {
+				printOut(color.RedString("You need to specify an expression"))
+			}
+		},
+		description: "show the expression after expanding all macros",
+	}
+
 	// Help
 	commands[":?"] = &commandHelp
 	commands[":help"] = &commandHelp
 
 	commands[":fn"] = &commandFn
 
+	commands[":expand"] = &commandExpand
+
 	// Quit
 	commands[":q"] = &commandQuit
 	commands[":quit"] = &commandQuit
@@ -19,25 +19,29 @@ var Equal = interpreter.TaFunction{
 		Name:       "=",
 		IsVariadic: true,
 		Arguments: []token.Kind{
-			token.Atom,
-			token.Atom,
-			token.Atom,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
 		},
 		Returns:     token.Boolean,
-		Description: "Tests if the arguments are the same",
+		Description: "Tests if the arguments are the same, values of different kinds are never the same (see token.Compare). Null is only the same as null",
 		Example: `
 (= 1 1)                                                          ; compares decimals, returns true
 (= "Hello World" "Hello World")                                  ; compares strings, returns true
 (= true true)                                                    ; compares booleans, returns true
 (= 2006-01-02T15:04:05Z 2006-01-02T15:04:05Z)                    ; compares time, returns true
-(= 1 "1")                                                        ; returns true
+(= 1 "1")                                                        ; returns false, the kinds are different
+(= 1.0 1)                                                        ; returns true
+(= (list 1 2) (list 1 2))                                        ; compares lists, returns true
+(= (kv (A 1) (B 2)) (kv (B 2) (A 1)))                            ; compares maps, returns true
 (= "Hello" "Bye")                                                ; returns false
 (= "Hello" "Hello" "Bye")                                        ; returns false
+(= "" (noop))                                                    ; returns false
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		for i := 1; i < len(args); i++ {
-			if args[0].String != args[i].String {
+			if !equal(interp, args[0], args[i]) {
 				return token.NewBool(false), nil
 			}
 		}
@@ -50,25 +54,28 @@ var NotEqual = interpreter.TaFunction{
 		Name:       "!=",
 		IsVariadic: true,
 		Arguments: []token.Kind{
-			token.Atom,
-			token.Atom,
-			token.Atom,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
 		},
 		Returns:     token.Boolean,
-		Description: "Tests if the arguments are not the same",
+		Description: "Tests if the first argument is not the same as any of the following, values of different kinds are never the same (see token.Compare). Null is only the same as null",
 		Example: `
 (!= 1 1)                                                         ; compares decimals, returns false
 (!= "Hello World" "Hello World")                                 ; compares strings, returns false
 (!= true true)                                                   ; compares booleans, returns false
 (!= 2006-01-02T15:04:05Z 2006-01-02T15:04:05Z)                   ; compares time, returns false
-(!= 1 "1")                                                       ; returns false
+(!= 1 "1")                                                       ; returns true, the kinds are different
+(!= 1.0 1)                                                       ; returns false
+(!= (list 1 2) (list 2 1))                                       ; compares lists, returns true
 (!= "Hello" "Bye")                                               ; returns true
 (!= "Hello" "Hello" "Bye")                                       ; returns false
+(!= "" (noop))                                                   ; returns true
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		for i := 1; i < len(args); i++ {
-			if args[0].String == args[i].String {
+			if equal(interp, args[0], args[i]) {
 				return token.NewBool(false), nil
 			}
 		}
@@ -93,15 +100,7 @@ var GreaterThanDecimal = interpreter.TaFunction{
 (> 2 1)                                                          ; returns true
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Decimal
-		for i := 1; i < len(args); i++ {
-			if a.Cmp(args[i].Decimal) <= 0 {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
-	},
+	Func: order(func(c int) bool { return c > 0 }),
 }
 
 var GreaterThanTime = interpreter.TaFunction{
@@ -121,15 +120,47 @@ var GreaterThanTime = interpreter.TaFunction{
 (> 2008-01-02T15:04:05Z 2007-01-02T15:04:05Z)                    ; returns true
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Time
-		for i := 1; i < len(args); i++ {
-			if !a.After(args[i].Time) {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
+	Func: order(func(c int) bool { return c > 0 }),
+}
+
+var GreaterThanDuration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       ">",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is greather then the following",
+		Example: `
+(> 1h 2h)                                                        ; returns false
+(> 1h 60m)                                                       ; returns false
+(> P1D 2h)                                                       ; returns true
+`,
+	},
+	Func: order(func(c int) bool { return c > 0 }),
+}
+
+var GreaterThan = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       ">",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is greather then the following, all arguments must have the same kind (the kind order of token.Compare is only used for sorting)",
+		Example: `
+(> "b" "a")                                                      ; returns true
+(> (list 1 2) (list 1))                                          ; returns true
+(> 1 "a")                                                        ; returns an error, the kinds are different
+`,
 	},
+	Func: order(func(c int) bool { return c > 0 }),
 }
 
 var LessThanDecimal = interpreter.TaFunction{
@@ -149,15 +180,7 @@ var LessThanDecimal = interpreter.TaFunction{
 (< 2 1)                                                          ; returns false
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Decimal
-		for i := 1; i < len(args); i++ {
-			if a.Cmp(args[i].Decimal) >= 0 {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
-	},
+	Func: order(func(c int) bool { return c < 0 }),
 }
 
 var LessThanTime = interpreter.TaFunction{
@@ -177,15 +200,47 @@ var LessThanTime = interpreter.TaFunction{
 (< 2008-01-02T15:04:05Z 2007-01-02T15:04:05Z)                    ; returns false
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Time
-		for i := 1; i < len(args); i++ {
-			if !a.Before(args[i].Time) {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
+	Func: order(func(c int) bool { return c < 0 }),
+}
+
+var LessThanDuration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "<",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is less then the following",
+		Example: `
+(< 1h 2h)                                                        ; returns true
+(< 1h 60m)                                                       ; returns false
+(< P1D 2h)                                                       ; returns false
+`,
 	},
+	Func: order(func(c int) bool { return c < 0 }),
+}
+
+var LessThan = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "<",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is less then the following, all arguments must have the same kind (the kind order of token.Compare is only used for sorting)",
+		Example: `
+(< "a" "b")                                                      ; returns true
+(< (list 1 2) (list 1 3))                                        ; returns true
+(< 1 "a")                                                        ; returns an error, the kinds are different
+`,
+	},
+	Func: order(func(c int) bool { return c < 0 }),
 }
 
 var GreaterThanOrEqualDecimal = interpreter.TaFunction{
@@ -205,15 +260,7 @@ var GreaterThanOrEqualDecimal = interpreter.TaFunction{
 (>= 2 1)                                                         ; returns true
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Decimal
-		for i := 1; i < len(args); i++ {
-			if a.Cmp(args[i].Decimal) < 0 {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
-	},
+	Func: order(func(c int) bool { return c >= 0 }),
 }
 
 var GreaterThanOrEqualTime = interpreter.TaFunction{
@@ -233,15 +280,47 @@ var GreaterThanOrEqualTime = interpreter.TaFunction{
 (>= 2008-01-02T15:04:05Z 2007-01-02T15:04:05Z)                   ; returns true
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Time
-		for i := 0; i < len(args); i++ {
-			if !a.Equal(args[i].Time) && !a.After(args[i].Time) {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
+	Func: order(func(c int) bool { return c >= 0 }),
+}
+
+var GreaterThanOrEqualDuration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       ">=",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is greather or equal then the following",
+		Example: `
+(>= 1h 2h)                                                       ; returns false
+(>= 1h 60m)                                                      ; returns true
+(>= P1D 2h)                                                      ; returns true
+`,
 	},
+	Func: order(func(c int) bool { return c >= 0 }),
+}
+
+var GreaterThanOrEqual = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       ">=",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is greather or equal then the following, all arguments must have the same kind (the kind order of token.Compare is only used for sorting)",
+		Example: `
+(>= "b" "b")                                                     ; returns true
+(>= (list 1 2) (list 1))                                         ; returns true
+(>= 1 "a")                                                       ; returns an error, the kinds are different
+`,
+	},
+	Func: order(func(c int) bool { return c >= 0 }),
 }
 
 var LessThanOrEqualDecimal = interpreter.TaFunction{
@@ -261,15 +340,7 @@ var LessThanOrEqualDecimal = interpreter.TaFunction{
 (<= 2 1)                                                         ; returns false
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Decimal
-		for i := 1; i < len(args); i++ {
-			if a.Cmp(args[i].Decimal) > 0 {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
-	},
+	Func: order(func(c int) bool { return c <= 0 }),
 }
 
 var LessThanOrEqualTime = interpreter.TaFunction{
@@ -289,15 +360,47 @@ var LessThanOrEqualTime = interpreter.TaFunction{
 (<= 2008-01-02T15:04:05Z 2007-01-02T15:04:05Z)                   ; returns false
 `,
 	},
-	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		a := args[0].Time
-		for i := 0; i < len(args); i++ {
-			if !a.Equal(args[i].Time) && !a.Before(args[i].Time) {
-				return token.NewBool(false), nil
-			}
-		}
-		return token.NewBool(true), nil
+	Func: order(func(c int) bool { return c <= 0 }),
+}
+
+var LessThanOrEqualDuration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "<=",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is less or equal then the following",
+		Example: `
+(<= 1h 2h)                                                       ; returns true
+(<= 1h 60m)                                                      ; returns true
+(<= P1D 2h)                                                      ; returns false
+`,
+	},
+	Func: order(func(c int) bool { return c <= 0 }),
+}
+
+var LessThanOrEqual = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "<=",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first argument is less or equal then the following, all arguments must have the same kind (the kind order of token.Compare is only used for sorting)",
+		Example: `
+(<= "a" "b")                                                     ; returns true
+(<= (list 1) (list 1 2))                                         ; returns true
+(<= 1 "a")                                                       ; returns an error, the kinds are different
+`,
 	},
+	Func: order(func(c int) bool { return c <= 0 }),
 }
 
 var BetweenDecimal = interpreter.TaFunction{
@@ -374,42 +477,76 @@ var BetweenTime = interpreter.TaFunction{
 	},
 }
 
+var BetweenDuration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "between",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Duration,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the arguments are between the second last and the last argument",
+		Example: `
+(between 90m 1h 2h)                                              ; returns true, (90m is between 1h and 2h)
+(between 1h 2h 30m P1D)                                          ; returns true, (1h and 2h are between 30m and P1D)
+(between 1h 1h 2h)                                               ; returns false
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		argc := len(args)
+
+		min := args[argc-2]
+		max := args[argc-1]
+
+		argc -= 2
+
+		for i := 0; i < argc; i++ {
+			if args[i].Duration <= min.Duration || args[i].Duration >= max.Duration {
+				return token.NewBool(false), nil
+			}
+		}
+		return token.NewBool(true), nil
+	},
+}
+
 var Or = interpreter.TaFunction{
 	CommonSignature: interpreter.CommonSignature{
 		Name:       "or",
 		IsVariadic: true,
 		Arguments: []token.Kind{
-			token.Atom | token.Collection,
+			token.Atom | token.Collection | token.Token,
 		},
 		Returns:     token.Boolean,
-		Description: "Evaluates whether at least one predicate is true",
+		Description: "Evaluates whether at least one predicate is true, predicates are evaluated from left to right until one is true",
 		Example: `
 (or false false false true false)                                ; returns true
 (or false false)                                                 ; returns false
+(or true (. Profile Age))                                        ; returns true, (. Profile Age) is never evaluated
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		for i := 0; i < len(args); i++ {
-			// pre-eval checks.
-			isCollection := args[i].Kind == token.List || args[i].Kind == token.Map
-			if isCollection {
-				// early return and no evaluation.
-				return (token.NewBool(true)), nil
-			}
-			err := interp.Evaluate(args[i])
-			if err != nil {
-				errors.New("Error evaluating block")
+			if args[i].IsBlock() {
+				if err := interp.Evaluate(args[i]); err != nil {
+					return nil, err
+				}
 			}
 
-			// post-eval checks.
-			if args[i].Kind != token.Boolean {
+			// collections evaluate to true
+			if args[i].IsList() || args[i].IsMap() {
+				return token.NewBool(true), nil
+			}
+			if !args[i].IsBool() {
 				return nil, errors.Errorf("Unexpected return type, expected Bool, got %s", args[i].Kind.String())
 			}
-			if args[i].Bool == true || args[i].Kind == token.Collection {
-				return (token.NewBool(true)), nil
+			if args[i].Bool {
+				return token.NewBool(true), nil
 			}
 		}
-		return (token.NewBool(false)), nil
+		return token.NewBool(false), nil
 	},
 }
 
@@ -418,37 +555,84 @@ var And = interpreter.TaFunction{
 		Name:       "and",
 		IsVariadic: true,
 		Arguments: []token.Kind{
-			token.Atom | token.Collection,
+			token.Atom | token.Collection | token.Token,
 		},
 		Returns:     token.Boolean,
-		Description: "Evaluates whether a series of predicates are all true",
+		Description: "Evaluates whether a series of predicates are all true, predicates are evaluated from left to right until one is false",
 		Example: `
-(and false (> 2 1))                                              ; returns true
+(and true (> 2 1))                                               ; returns true
 (and false false)                                                ; returns false
+(and false (. Profile Age))                                      ; returns false, (. Profile Age) is never evaluated
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		for i := 0; i < len(args); i++ {
-			// pre-eval checks.
-			isCollection := args[i].Kind == token.List || args[i].Kind == token.Map
-			if isCollection {
-				// fallthrough
-				continue
+			if args[i].IsBlock() {
+				if err := interp.Evaluate(args[i]); err != nil {
+					return nil, err
+				}
 			}
 
-			err := interp.Evaluate(args[i])
-			if err != nil {
-				errors.New("Error evaluating block")
+			// collections evaluate to true
+			if args[i].IsList() || args[i].IsMap() {
+				continue
 			}
-
-			// post-eval checks
-			if args[i].Kind != token.Boolean {
+			if !args[i].IsBool() {
 				return nil, errors.Errorf("Unexpected return type, expected Bool, got %s", args[i].Kind.String())
 			}
-			if args[i].Bool == false {
-				return (token.NewBool(false)), nil
+			if !args[i].Bool {
+				return token.NewBool(false), nil
 			}
 		}
-		return (token.NewBool(true)), nil
+		return token.NewBool(true), nil
 	},
 }
+
+// equal compares two values using token.Compare, with loose comparison the string representations are compared.
+// Null is only the same as null
+func equal(interp *interpreter.Interpreter, a, b *token.TaToken) bool {
+	if a.IsNull() || b.IsNull() {
+		return a.IsNull() && b.IsNull()
+	}
+	if interp.UsesLooseComparison() {
+		return a.String == b.String
+	}
+	return token.Compare(a, b) == 0
+}
+
+// order returns a function that tests if check is true for the comparison of the first argument with each of the following
+func order(check func(c int) bool) interpreter.TaFunc {
+	return func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		for i := 1; i < len(args); i++ {
+			if !comparable(args[0], args[i]) {
+				return nil, errors.Errorf("Cannot compare %s with %s", args[0].Stringify(), args[i].Stringify())
+			}
+			if !check(compareOrder(args[0], args[i])) {
+				return token.NewBool(false), nil
+			}
+		}
+		return token.NewBool(true), nil
+	}
+}
+
+// compareOrder compares like token.Compare, but times only by their instant:
+// token.Compare orders a date before a time at the same instant so = and sort tell them apart,
+// but 2018-01-01 is not before 2018-01-01T00:00:00Z
+func compareOrder(a, b *token.TaToken) int {
+	if a.IsTime() && b.IsTime() {
+		if a.Time.Before(b.Time) {
+			return -1
+		} else if a.Time.After(b.Time) {
+			return 1
+		}
+		return 0
+	}
+	return token.Compare(a, b)
+}
+
+// comparable returns true if a and b can be ordered: they have the same kind (but not null) and money has the same currency.
+// token.Compare also orders different kinds, so sort and distinct have a total order, but that order means nothing
+// for a condition like (< 1 "2"), which usually compares an attribute of the wrong kind and should fail instead
+func comparable(a, b *token.TaToken) bool {
+	return a.Kind == b.Kind && !a.IsNull() && (!a.IsMoney() || a.Currency == b.Currency)
+}
@@ -8,14 +8,23 @@ func AllOperations() []interpreter.TaFunction {
 		NotEqual,
 		GreaterThanDecimal,
 		GreaterThanTime,
+		GreaterThanDuration,
+		GreaterThan,
 		LessThanDecimal,
 		LessThanTime,
+		LessThanDuration,
+		LessThan,
 		GreaterThanOrEqualDecimal,
 		GreaterThanOrEqualTime,
+		GreaterThanOrEqualDuration,
+		GreaterThanOrEqual,
 		LessThanOrEqualDecimal,
 		LessThanOrEqualTime,
+		LessThanOrEqualDuration,
+		LessThanOrEqual,
 		BetweenDecimal,
 		BetweenTime,
+		BetweenDuration,
 		Or,
 		And,
 	}
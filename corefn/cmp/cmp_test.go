@@ -39,6 +39,31 @@ func TestEqual(t *testing.T) {
 		helpers.Test{
 			`(= 1 "1")`,
 			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(= 1.0 1)`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(= (list 1 "a") (list 1.00 "a"))`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(= (kv (A 1) (B (list 2))) (kv (B (list 2)) (A 1.0)))`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(= (list 1 2) (list 1 2 3))`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(= 2006-01-02T15:04:05Z 2006-01-02T17:04:05+02:00)`,
+			nil,
 			token.NewBool(true),
 		},
 		helpers.Test{
@@ -51,6 +76,16 @@ func TestEqual(t *testing.T) {
 			nil,
 			token.NewBool(false),
 		},
+		helpers.Test{
+			`(= "" (noop))`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(= (noop) (noop))`,
+			nil,
+			token.NewBool(true),
+		},
 	)
 }
 
@@ -84,8 +119,18 @@ func TestNotEqual(t *testing.T) {
 		helpers.Test{
 			`(!= 1 "1")`,
 			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(!= 1.0 1)`,
+			nil,
 			token.NewBool(false),
 		},
+		helpers.Test{
+			`(!= (kv (A 1)) (kv (A 1) (B 2)))`,
+			nil,
+			token.NewBool(true),
+		},
 		helpers.Test{
 			`(!= "Hello" "Bye")`,
 			nil,
@@ -96,6 +141,16 @@ func TestNotEqual(t *testing.T) {
 			nil,
 			token.NewBool(false),
 		},
+		helpers.Test{
+			`(!= "" (noop))`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(!= (noop) (noop))`,
+			nil,
+			token.NewBool(false),
+		},
 	)
 }
 
@@ -196,6 +251,27 @@ func TestLessThanTime(t *testing.T) {
 			nil,
 			token.NewBool(false),
 		},
+		// dates and times are ordered by their instant
+		helpers.Test{
+			`(< 2018-01-01 2018-01-01T00:00:00Z)`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(> 2018-01-01T00:00:00Z 2018-01-01)`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(<= 2018-01-01T00:00:00Z 2018-01-01)`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(>= 2018-01-01 2018-01-01T00:00:00Z)`,
+			nil,
+			token.NewBool(true),
+		},
 	)
 }
 
@@ -409,6 +485,28 @@ func TestOr(t *testing.T) {
 			nil,
 			token.NewBool(true),
 		},
+		// short-circuit evaluation
+		helpers.Test{
+			`or true (panic)`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`or (= 1 1) (> (. Profile Age) 18)`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`or false (panic)`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`or false (> (. Profile Age) 18)`,
+			token.NewMap(map[string]*token.TaToken{
+				"Profile": token.NewMap(map[string]*token.TaToken{
+					"Age": token.NewDecimalFromInt(21),
+				}),
+			}),
+			token.NewBool(true),
+		},
 	)
 }
 
@@ -474,5 +572,129 @@ func TestAnd(t *testing.T) {
 			nil,
 			token.NewBool(true),
 		},
+		// short-circuit evaluation
+		helpers.Test{
+			`and false (panic)`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`and (= 1 2) (> (. Profile Age) 18)`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`and true (panic)`,
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			`and true (> (. Profile Age) 18)`,
+			token.NewMap(map[string]*token.TaToken{
+				"Profile": token.NewMap(map[string]*token.TaToken{
+					"Age": token.NewDecimalFromInt(17),
+				}),
+			}),
+			token.NewBool(false),
+		},
+	)
+}
+
+func TestCompareDuration(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`(> P1D 2h)`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(> 1h 60m)`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(< 1h 2h 3h)`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(>= 1h 60m)`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(<= P1D 2h)`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(= 1h 60m)`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(between 90m 1h 2h)`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(between 1h 1h 2h)`,
+			nil,
+			token.NewBool(false),
+		},
+	)
+}
+
+func TestLooseComparison(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	interp.LooseComparison = true
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			`(= 1 "1")`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(= 1.0 1)`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(!= 1 "1")`,
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			`(= "" (noop))`,
+			nil,
+			token.NewBool(false),
+		},
+		// words that are durations and dates compare by their text
+		helpers.Test{
+			`(= 1m "1m")`,
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			`(= 2018-01-01 "2018-01-01")`,
+			nil,
+			token.NewBool(true),
+		},
+	)
+}
+
+func TestOrderAnyKind(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{`(> "b" "a")`, nil, token.NewBool(true)},
+		helpers.Test{`(< "b" "a")`, nil, token.NewBool(false)},
+		helpers.Test{`(< "a" "b" "c")`, nil, token.NewBool(true)},
+		helpers.Test{`(>= "b" "b")`, nil, token.NewBool(true)},
+		helpers.Test{`(<= "b" "a")`, nil, token.NewBool(false)},
+		helpers.Test{`(< (list 1 2) (list 1 3))`, nil, token.NewBool(true)},
+		helpers.Test{`(> (list 1 2) (list 1))`, nil, token.NewBool(true)},
+		helpers.Test{`(< false true)`, nil, token.NewBool(true)},
+		helpers.Test{`(< 1 "a")`, nil, helpers.Error{}},
+		helpers.Test{`(< "a" (noop))`, nil, helpers.Error{}},
+		helpers.Test{`(< 10 9)`, nil, token.NewBool(false)},
 	)
 }
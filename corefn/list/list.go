@@ -9,6 +9,8 @@ import (
 	"github.com/talon-one/decimal"
 	"github.com/talon-one/talang/interpreter"
 	"github.com/talon-one/talang/token"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 )
 
 func init() {
@@ -50,6 +52,7 @@ var Head = interpreter.TaFunction{
 (head (list 1 true Hello))                                       ; returns 1
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		if len(args[0].Children) > 0 {
 			return args[0].Children[0], nil
@@ -72,10 +75,12 @@ var Tail = interpreter.TaFunction{
 (tail (list 1 true Hello))                                       ; returns a list containing true and Hello
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		if len(args[0].Children) <= 0 {
 			return token.NewList(), nil
 		}
+		// lists are immutable, so the result can share the children
 		return token.NewList(args[0].Children[1:]...), nil
 	},
 }
@@ -94,9 +99,10 @@ var Drop = interpreter.TaFunction{
 (drop (list 1 true Hello))                                       ; returns a list containing 1 and true
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		if l := len(args[0].Children); l > 0 {
-			return token.NewList(args[0].Children[:l-1]...), nil
+			return token.NewList(args[0].Children[: l-1 : l-1]...), nil
 		}
 		return token.NewList(), nil
 	},
@@ -118,6 +124,7 @@ var Item = interpreter.TaFunction{
 (item (list 1 true Hello) 3)                                     ; fails
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		i, err := args[1].Decimal.Int64()
 		if err != nil {
@@ -149,8 +156,11 @@ var Push = interpreter.TaFunction{
 (push (list 1 2) 3 4)                                            ; returns a list containing 1, 2, 3 and 4
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		return token.NewList(append(args[0].Children, args[1:]...)...), nil
+		// the children of the list can be shared with other lists, so never append to them in place
+		list := args[0].Children
+		return token.NewList(append(list[:len(list):len(list)], args[1:]...)...), nil
 	},
 }
 
@@ -221,25 +231,68 @@ var Sort = interpreter.TaFunction{
 			token.Boolean,
 		},
 		Returns:     token.List,
-		Description: "Sort a list ascending, set the second argument to true for descending order",
+		Description: "Sort a list ascending, set the second argument to true for descending order. Items are compared using token.Compare",
 		Example: `
 (sort  (list "World" "Universe"))                                ; returns a list containing "Universe" and "World"
 (sort  (list "World" "Universe") true)                           ; returns a list containing "World" and "Universe"
+(sort  (list 10 9 1))                                            ; returns a list containing 1, 9 and 10
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		list := token.NewList()
-		list.Children = make([]*token.TaToken, len(args[0].Children))
-		copy(list.Children, args[0].Children)
+		items := make([]*token.TaToken, len(args[0].Children))
+		copy(items, args[0].Children)
+
+		descending := len(args) > 1 && args[1].Bool
+		sort.SliceStable(items, func(i, j int) bool {
+			if descending {
+				return compare(interp, items[i], items[j]) > 0
+			}
+			return compare(interp, items[i], items[j]) < 0
+		})
+		return token.NewList(items...), nil
+	},
+}
 
-		if len(args) > 1 && args[1].Bool {
-			a := token.TokenArguments(list.Children)
-			sort.Sort(sort.Reverse(&a))
-		} else {
-			sort.Sort(token.TokenArguments(list.Children))
+var SortLocale = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "sort",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Boolean,
+			token.String,
+		},
+		ArgumentNames: []string{"list", "descending", "locale"},
+		Returns:       token.List,
+		Description:   "Sort a list ascending or descending, strings are sorted alphabetically using the rules of the locale (like \"de\" or \"sv-SE\"), other items are compared using token.Compare",
+		Example: `
+(sort  (list "Zebra" "Äpfel" "apple") false "de")                ; returns a list containing "Äpfel", "apple" and "Zebra"
+(sort  (list "Zebra" "Äpfel" "apple") false "sv")                ; returns a list containing "apple", "Zebra" and "Äpfel"
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		collator, err := newCollator(args[2].String)
+		if err != nil {
+			return nil, err
 		}
+		items := make([]*token.TaToken, len(args[0].Children))
+		copy(items, args[0].Children)
 
-		return list, nil
+		compareItems := func(a, b *token.TaToken) int {
+			if interp.UsesLooseComparison() || (a.IsString() && b.IsString()) {
+				return collator.CompareString(a.String, b.String)
+			}
+			return token.Compare(a, b)
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			if args[1].Bool {
+				return compareItems(items[i], items[j]) > 0
+			}
+			return compareItems(items[i], items[j]) < 0
+		})
+		return token.NewList(items...), nil
 	},
 }
 
@@ -250,28 +303,47 @@ var Min = interpreter.TaFunction{
 		Arguments: []token.Kind{
 			token.List,
 		},
-		Returns:     token.Decimal,
-		Description: "Find the lowest number in the list",
+		Returns:     token.Atom | token.Collection,
+		Description: "Find the lowest item in the list, all items must have the same kind and are compared using token.Compare",
 		Example: `
 (min  (list 3 4 1 3 7 1 17 15 2))                                ; returns 1
 (min  (list 3 4 -1 3 7 1 17 0 2))                                ; returns -1
+(min  (list "b" "a"))                                            ; returns "a"
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		var d *decimal.Decimal
-		for _, item := range args[0].Children {
-			if item.IsDecimal() {
-				if d == nil {
-					d = &item.Decimal
-				} else if item.Decimal.Cmp(*d) < 0 {
-					d = &item.Decimal
+		if interp.UsesLooseComparison() {
+			// legacy rules: only decimals are compared
+			var d *decimal.Decimal
+			for _, item := range args[0].Children {
+				if item.IsDecimal() {
+					if d == nil {
+						d = &item.Decimal
+					} else if item.Decimal.Cmp(*d) < 0 {
+						d = &item.Decimal
+					}
 				}
 			}
+			if d == nil {
+				return nil, errors.New("No decimal present in list")
+			}
+			return token.NewDecimal(*d), nil
+		}
+
+		var result *token.TaToken
+		for _, item := range args[0].Children {
+			if result != nil && (item.Kind != result.Kind || item.Currency != result.Currency) {
+				return nil, errors.Errorf("Cannot compare %s with %s", result.Stringify(), item.Stringify())
+			}
+			if result == nil || token.Compare(item, result) < 0 {
+				result = item
+			}
 		}
-		if d == nil {
-			return nil, errors.New("No decimal present in list")
+		if result == nil {
+			return nil, errors.New("The list is empty")
 		}
-		return token.NewDecimal(*d), nil
+		return result, nil
 	},
 }
 
@@ -282,28 +354,47 @@ var Max = interpreter.TaFunction{
 		Arguments: []token.Kind{
 			token.List,
 		},
-		Returns:     token.Decimal,
-		Description: "Find the largest number in the list",
+		Returns:     token.Atom | token.Collection,
+		Description: "Find the largest item in the list, all items must have the same kind and are compared using token.Compare",
 		Example: `
 (max  (list 3 4 1 3 7 1 17 15 2))                                ; returns 17
 (max  (list 4 2 9 2 27 1 2 422))                                 ; returns 422
+(max  (list 2018-01-02 2019-01-02))                              ; returns 2019-01-02
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		var d *decimal.Decimal
-		for _, item := range args[0].Children {
-			if item.IsDecimal() {
-				if d == nil {
-					d = &item.Decimal
-				} else if item.Decimal.Cmp(*d) > 0 {
-					d = &item.Decimal
+		if interp.UsesLooseComparison() {
+			// legacy rules: only decimals are compared
+			var d *decimal.Decimal
+			for _, item := range args[0].Children {
+				if item.IsDecimal() {
+					if d == nil {
+						d = &item.Decimal
+					} else if item.Decimal.Cmp(*d) > 0 {
+						d = &item.Decimal
+					}
 				}
 			}
+			if d == nil {
+				return nil, errors.New("No decimal present in list")
+			}
+			return token.NewDecimal(*d), nil
+		}
+
+		var result *token.TaToken
+		for _, item := range args[0].Children {
+			if result != nil && (item.Kind != result.Kind || item.Currency != result.Currency) {
+				return nil, errors.Errorf("Cannot compare %s with %s", result.Stringify(), item.Stringify())
+			}
+			if result == nil || token.Compare(item, result) > 0 {
+				result = item
+			}
 		}
-		if d == nil {
-			return nil, errors.New("No decimal present in list")
+		if result == nil {
+			return nil, errors.New("The list is empty")
 		}
-		return token.NewDecimal(*d), nil
+		return result, nil
 	},
 }
 
@@ -336,6 +427,7 @@ var Count = interpreter.TaFunction{
 (count (list 1))                                                 ; returns "1"
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		count := int64(len(args[0].Children))
 		return token.NewDecimalFromInt(count), nil
@@ -356,14 +448,14 @@ var Reverse = interpreter.TaFunction{
 (reverse (list 1))                                               ; returns "1"
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		list := token.NewList()
-		list.Children = make([]*token.TaToken, len(args[0].Children))
+		items := make([]*token.TaToken, len(args[0].Children))
 		childrenCount := len(args[0].Children) - 1
 		for i := childrenCount; i >= 0; i-- {
-			list.Children[childrenCount-i] = args[0].Children[i]
+			items[childrenCount-i] = args[0].Children[i]
 		}
-		return list, nil
+		return token.NewList(items...), nil
 	},
 }
 
@@ -372,7 +464,7 @@ var Join = interpreter.TaFunction{
 		Name:       "join",
 		IsVariadic: false,
 		Arguments: []token.Kind{
-			token.List,
+			token.ListOf(token.String),
 			token.String,
 		},
 		Returns:     token.String,
@@ -382,11 +474,8 @@ var Join = interpreter.TaFunction{
 (join (list hello world) ",")                                    ; returns "hello,world"
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		if args[0].Children[0].Kind != token.String {
-			return nil, errors.New("List must be of string type")
-		}
-
 		var final = make([]string, len(args[0].Children))
 		for i := 0; i < len(args[0].Children); i++ {
 			final[i] = args[0].Children[i].String
@@ -410,6 +499,7 @@ isEmpty (list hello world)                                       ; returns "fals
 isEmpty (list)                                                   ; returns "true"
 `,
 	},
+	SharesArguments: true,
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		if args[0].IsEmpty() {
 			return token.NewBool(true), nil
@@ -434,13 +524,12 @@ var Split = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		list := token.NewList()
 		srcs := strings.Split(args[0].String, args[1].String)
-		list.Children = make([]*token.TaToken, len(srcs))
-		for i := 0; i < len(list.Children); i++ {
-			list.Children[i] = token.NewString(srcs[i])
+		items := make([]*token.TaToken, len(srcs))
+		for i := 0; i < len(items); i++ {
+			items[i] = token.NewString(srcs[i])
 		}
-		return list, nil
+		return token.NewList(items...), nil
 	},
 }
 
@@ -551,6 +640,28 @@ sum (. List) Item (. Item Price)                                 ; returns 4 Wit
 	},
 }
 
+var SumDecimals = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "sum",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.ListOf(token.Decimal),
+		},
+		Returns:     token.Decimal,
+		Description: "Sum up a list of decimals",
+		Example: `
+sum (list 1 2 3)                                                 ; returns 6
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		accumulator := decimal.NewFromInt(0)
+		for _, item := range args[0].Children {
+			accumulator.Add(item.Decimal)
+		}
+		return token.NewDecimal(accumulator), nil
+	},
+}
+
 var Every = interpreter.TaFunction{
 	CommonSignature: interpreter.CommonSignature{
 		Name:       "every",
@@ -640,8 +751,6 @@ sortByNumber (list 2 4 3 1) ((Item) (. Item)) false              ; returns [1, 2
 		}
 
 		structlist := make([]*SortByItem, len(list))
-		sorted := token.NewList()
-		sorted.Children = make([]*token.TaToken, len(list))
 		scope := interp.NewScope()
 
 		for i := 0; i < len(list); i++ {
@@ -651,6 +760,9 @@ sortByNumber (list 2 4 3 1) ((Item) (. Item)) false              ; returns [1, 2
 			if err := scope.Evaluate(&result); err != nil {
 				return nil, err
 			}
+			if !result.IsDecimal() {
+				return nil, errors.Errorf("Invalid type in block evaluation, expected type: DecimalKind got %s", result.Kind.String())
+			}
 			structlist[i] = &SortByItem{result.Decimal, list[i]}
 		}
 
@@ -662,11 +774,12 @@ sortByNumber (list 2 4 3 1) ((Item) (. Item)) false              ; returns [1, 2
 			return structlist[i].Num.Cmp(structlist[j].Num) == expected
 		})
 
+		sorted := make([]*token.TaToken, len(structlist))
 		for i := 0; i < len(structlist); i++ {
-			sorted.Children[i] = structlist[i].Item
+			sorted[i] = structlist[i].Item
 		}
 
-		return sorted, nil
+		return token.NewList(sorted...), nil
 	},
 }
 
@@ -680,50 +793,40 @@ var SortByString = interpreter.TaFunction{
 			token.Boolean, // descending
 		},
 		Returns:     token.List,
-		Description: "Sort a list alphabetically",
+		Description: "Sort a list alphabetically by byte order, set the third argument to true for descending order",
 		Example: `
-sortByString (list "b" "a" "z" "t") ((Item) (. Item)) true       ; returns [a, b, t, z]
-sortByString (list "b" "a" "z" "t") ((Item) (. Item)) false      ; returns [z, t, b, a]
+sortByString (list "b" "a" "z" "t") ((Item) (. Item)) false      ; returns [a, b, t, z]
+sortByString (list "b" "a" "z" "t") ((Item) (. Item)) true       ; returns [z, t, b, a]
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		list := args[0].Children
-		block := args[1].Children[1]
-		bindingName := args[1].Children[0].String
-
-		type SortByItem struct {
-			Word string
-			Item *token.TaToken
-		}
-
-		structlist := make([]*SortByItem, len(list))
-		sorted := token.NewList()
-		sorted.Children = make([]*token.TaToken, len(list))
-		scope := interp.NewScope()
-
-		for i := 0; i < len(list); i++ {
-			var result token.TaToken
-			scope.Set(bindingName, list[i])
-			token.Copy(&result, block)
-			if err := scope.Evaluate(&result); err != nil {
-				return nil, err
-			}
-			structlist[i] = &SortByItem{result.String, list[i]}
-		}
-
-		sort.SliceStable(structlist, func(i, j int) bool {
-			comparedAscending := structlist[i].Word < structlist[j].Word
-			if args[2].Bool {
-				return !comparedAscending
-			}
-			return comparedAscending
-		})
+		return sortByString(interp, args[0], args[1], args[2].Bool, strings.Compare)
+	},
+}
 
-		for i := 0; i < len(structlist); i++ {
-			sorted.Children[i] = structlist[i].Item
+var SortByStringLocale = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "sortByString",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,    // target
+			token.Token,   // block
+			token.Boolean, // descending
+			token.String,  // locale
+		},
+		Returns:     token.List,
+		Description: "Sort a list alphabetically using the rules of the locale (like \"de\" or \"sv-SE\"), set the third argument to true for descending order",
+		Example: `
+sortByString (list "Zebra" "Äpfel") ((Item) (. Item)) false "de" ; returns [Äpfel, Zebra]
+sortByString (list "Zebra" "Äpfel") ((Item) (. Item)) false "sv" ; returns [Zebra, Äpfel]
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		collator, err := newCollator(args[3].String)
+		if err != nil {
+			return nil, err
 		}
-
-		return sorted, nil
+		return sortByString(interp, args[0], args[1], args[2].Bool, collator.CompareString)
 	},
 }
 
@@ -746,7 +849,7 @@ filter (list "Sasquatch" "Front squats" "Caramel" "Cart items") ((x) (contains (
 		list := args[0].Children
 		block := args[1].Children[1]
 		bindingName := args[1].Children[0].String
-		passed := token.NewList()
+		passed := []*token.TaToken{}
 		scope := interp.NewScope()
 
 		for i := 0; i < len(list); i++ {
@@ -760,10 +863,1189 @@ filter (list "Sasquatch" "Front squats" "Caramel" "Cart items") ((x) (contains (
 				return nil, errors.Errorf("Invalid type in block evaluation, expected type: Boolean got %s", result.Kind.String())
 			}
 			if result.Bool {
-				passed.Children = append(passed.Children, list[i])
+				passed = append(passed, list[i])
+			}
+		}
+
+		return token.NewList(passed...), nil
+	},
+}
+
+var Unique = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "unique",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+		},
+		Returns:     token.List,
+		Description: "Returns the list without duplicates, items are compared using token.Compare and the first occurrence is kept",
+		Example: `
+(unique (list 1 2 1 "1" 1.0))                                    ; returns a list containing 1, 2 and "1"
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewList(uniqueItems(args[0].Children)...), nil
+	},
+}
+
+var Union = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "union",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+			token.List,
+		},
+		Returns:     token.List,
+		Description: "Returns the items that are in any of the lists without duplicates",
+		Example: `
+(union (list 1 2) (list 2 3))                                    ; returns a list containing 1, 2 and 3
+(union (list 1) (list "1") (list 1))                             ; returns a list containing 1 and "1"
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		var items []*token.TaToken
+		for _, list := range args {
+			items = append(items, list.Children...)
+		}
+		return token.NewList(uniqueItems(items)...), nil
+	},
+}
+
+var Intersection = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "intersection",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+			token.List,
+		},
+		Returns:     token.List,
+		Description: "Returns the items of the first list that are in all other lists without duplicates",
+		Example: `
+(intersection (list 1 2 3) (list 2 3 4))                         ; returns a list containing 2 and 3
+(intersection (list 1 "1") (list "1"))                           ; returns a list containing "1"
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		items := uniqueItems(args[0].Children)
+		for _, list := range args[1:] {
+			items = filterItems(items, newItemSet(list.Children), true)
+		}
+		return token.NewList(items...), nil
+	},
+}
+
+var Difference = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "difference",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+			token.List,
+		},
+		Returns:     token.List,
+		Description: "Returns the items of the first list that are in none of the other lists without duplicates",
+		Example: `
+(difference (list 1 2 3) (list 2 3 4))                           ; returns a list containing 1
+(difference (list 1 "1") (list "1"))                             ; returns a list containing 1
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		items := uniqueItems(args[0].Children)
+		for _, list := range args[1:] {
+			items = filterItems(items, newItemSet(list.Children), false)
+		}
+		return token.NewList(items...), nil
+	},
+}
+
+var IsSubset = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "isSubset",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if all items of the first list are in the second list",
+		Example: `
+(isSubset (list 1 2) (list 3 2 1))                               ; returns true
+(isSubset (list 1 "2") (list 1 2))                               ; returns false
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		set := newItemSet(args[1].Children)
+		for _, item := range args[0].Children {
+			if !set.contains(item) {
+				return token.NewBool(false), nil
+			}
+		}
+		return token.NewBool(true), nil
+	},
+}
+
+var CountOf = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "countOf",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Atom,
+		},
+		Returns:     token.Decimal,
+		Description: "Counts how often the value is in the list",
+		Example: `
+(countOf (list 1 2 1 "1") 1)                                     ; returns 2
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		var count int64
+		for _, item := range args[0].Children {
+			if token.Compare(item, args[1]) == 0 {
+				count++
+			}
+		}
+		return token.NewDecimalFromInt(count), nil
+	},
+}
+
+var CountOfList = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "countOf",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+		},
+		Returns:     token.Decimal,
+		Description: "Counts the items of the first list that are in the second list",
+		Example: `
+(countOf (list SKU1 SKU2 SKU2 SKU3) (list SKU2 SKU3 SKU4))       ; returns 3
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDecimalFromInt(int64(len(filterItems(args[0].Children, newItemSet(args[1].Children), true)))), nil
+	},
+}
+
+var ContainsAny = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "containsAny",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first list contains any item of the second list",
+		Example: `
+(containsAny (list 1 2 3) (list 3 4))                            ; returns true
+(containsAny (list 1 2 3) (list "3"))                            ; returns false
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		set := newItemSet(args[0].Children)
+		for _, item := range args[1].Children {
+			if set.contains(item) {
+				return token.NewBool(true), nil
 			}
 		}
+		return token.NewBool(false), nil
+	},
+}
 
-		return passed, nil
+var ContainsAll = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "containsAll",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the first list contains all items of the second list",
+		Example: `
+(containsAll (list 1 2 3) (list 3 1))                            ; returns true
+(containsAll (list 1 2 3) (list 3 4))                            ; returns false
+`,
 	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return IsSubset.Func(interp, args[1], args[0])
+	},
+}
+
+// itemSet is a sorted copy of list items, so items can be looked up using a binary search
+type itemSet []*token.TaToken
+
+func newItemSet(items []*token.TaToken) itemSet {
+	set := make(itemSet, len(items))
+	copy(set, items)
+	sort.Slice(set, func(i, j int) bool {
+		return token.Compare(set[i], set[j]) < 0
+	})
+	return set
+}
+
+func (set itemSet) contains(item *token.TaToken) bool {
+	i := sort.Search(len(set), func(i int) bool {
+		return token.Compare(set[i], item) >= 0
+	})
+	return i < len(set) && token.Compare(set[i], item) == 0
+}
+
+// filterItems returns the items that are (keep = true) or are not (keep = false) in the set
+func filterItems(items []*token.TaToken, set itemSet, keep bool) []*token.TaToken {
+	result := make([]*token.TaToken, 0, len(items))
+	for _, item := range items {
+		if set.contains(item) == keep {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// uniqueItems returns the items without duplicates, the first occurrence of an item is kept
+func uniqueItems(items []*token.TaToken) []*token.TaToken {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	// equal items stay in their order, so the first one of every run is the first occurrence
+	sort.SliceStable(order, func(i, j int) bool {
+		return token.Compare(items[order[i]], items[order[j]]) < 0
+	})
+	duplicate := make([]bool, len(items))
+	for i := 1; i < len(order); i++ {
+		if token.Compare(items[order[i-1]], items[order[i]]) == 0 {
+			duplicate[order[i]] = true
+		}
+	}
+	result := make([]*token.TaToken, 0, len(items))
+	for i, item := range items {
+		if !duplicate[i] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+var Reduce = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "reduce",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.String,
+			token.Atom | token.Collection,
+			token.Token,
+		},
+		Returns:     token.Atom | token.Collection,
+		Description: "Combine the items of a list into a single value, the block is evaluated for each item with the accumulator and the item bound and its result becomes the new accumulator",
+		Example: `
+(reduce (list 1 2 3) Sum x 0 (+ (. Sum) (. x)))                  ; returns 6
+(reduce (. Items) Total Item 0 (+ (. Total) (* (. Item Price) (. Item Quantity)))) ; returns the total price of all items
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0]
+		accumulatorName := args[1].String
+		bindingName := args[2].String
+		accumulator := args[3]
+		blockToRun := args[4]
+
+		scope := interp.NewScope()
+		for _, item := range list.Children {
+			scope.Set(accumulatorName, accumulator)
+			scope.Set(bindingName, item)
+			result, err := evaluateBlock(scope, blockToRun)
+			if err != nil {
+				return nil, err
+			}
+			accumulator = result
+		}
+		return accumulator, nil
+	},
+}
+
+var Fold = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:        "fold",
+		IsVariadic:  Reduce.IsVariadic,
+		Arguments:   Reduce.Arguments,
+		Returns:     Reduce.Returns,
+		Description: Reduce.Description,
+		Example: `
+(fold (list 1 2 3) Sum x 0 (+ (. Sum) (. x)))                    ; returns 6
+`,
+	},
+	Func: Reduce.Func,
+}
+
+var GroupBy = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "groupBy",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.MapOf(token.List),
+		Description: "Group the items of a list by the key the block evaluates to, returns a map of lists in the order the keys were found",
+		Example: `
+(groupBy (list 1 2 3 4) x (= 0 (mod (. x) 2)))                   ; returns {false:[1, 3], true:[2, 4]}
+(groupBy (. Items) Item (. Item Category))                       ; returns the items grouped by their category
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		keys, groups, err := group(interp, args[0], args[1].String, args[2])
+		if err != nil {
+			return nil, err
+		}
+		values := make([]*token.TaToken, len(groups))
+		for i, items := range groups {
+			values[i] = token.NewList(items...)
+		}
+		return token.NewOrderedMap(keys, values), nil
+	},
+}
+
+var CountBy = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "countBy",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.MapOf(token.Decimal),
+		Description: "Group the items of a list by the key the block evaluates to, returns a map with the number of items for each key",
+		Example: `
+(countBy (list 1 2 3 4 5) x (= 0 (mod (. x) 2)))                 ; returns {false:3, true:2}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		keys, groups, err := group(interp, args[0], args[1].String, args[2])
+		if err != nil {
+			return nil, err
+		}
+		values := make([]*token.TaToken, len(groups))
+		for i, items := range groups {
+			values[i] = token.NewDecimalFromInt(int64(len(items)))
+		}
+		return token.NewOrderedMap(keys, values), nil
+	},
+}
+
+var SumBy = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "sumBy",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+			token.Token,
+		},
+		Returns:     token.MapOf(token.Decimal),
+		Description: "Group the items of a list by the key the first block evaluates to, returns a map with the sum of the second block for each key",
+		Example: `
+(sumBy (. Items) Item (. Item Category) (* (. Item Price) (. Item Quantity))) ; returns the total price per category
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		bindingName := args[1].String
+		keys, groups, err := group(interp, args[0], bindingName, args[2])
+		if err != nil {
+			return nil, err
+		}
+		values := make([]*token.TaToken, len(groups))
+		for i, items := range groups {
+			sum, err := sumItems(interp, items, bindingName, args[3])
+			if err != nil {
+				return nil, err
+			}
+			values[i] = token.NewDecimal(sum)
+		}
+		return token.NewOrderedMap(keys, values), nil
+	},
+}
+
+var Average = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "average",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.Decimal,
+		Description: "Calculate the average of the decimals the block evaluates to for each item in the list",
+		Example: `
+(average (. Items) Item (. Item Price))                           ; returns 3 with the binding "$Items" containing prices: [2, 4]
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		items := args[0].Children
+		if len(items) == 0 {
+			return nil, errors.New("The list is empty")
+		}
+		sum, err := sumItems(interp, items, args[1].String, args[2])
+		if err != nil {
+			return nil, err
+		}
+		return token.NewDecimal(decimal.Div(sum, decimal.NewFromInt(len(items)))), nil
+	},
+}
+
+var AverageDecimals = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "average",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.ListOf(token.Decimal),
+		},
+		Returns:     token.Decimal,
+		Description: "Calculate the average of a list of decimals",
+		Example: `
+(average (list 1 2 3 4))                                         ; returns 2.5
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		items := args[0].Children
+		if len(items) == 0 {
+			return nil, errors.New("The list is empty")
+		}
+		sum := decimal.Zero()
+		for _, item := range items {
+			sum.Add(item.Decimal)
+		}
+		return token.NewDecimal(decimal.Div(sum, decimal.NewFromInt(len(items)))), nil
+	},
+}
+
+var Avg = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:        "avg",
+		IsVariadic:  Average.IsVariadic,
+		Arguments:   Average.Arguments,
+		Returns:     Average.Returns,
+		Description: Average.Description,
+		Example: `
+(avg (. Items) Item (. Item Price))                               ; returns 3 with the binding "$Items" containing prices: [2, 4]
+`,
+	},
+	SharesArguments: true,
+	Func:            Average.Func,
+}
+
+var AvgDecimals = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:        "avg",
+		IsVariadic:  AverageDecimals.IsVariadic,
+		Arguments:   AverageDecimals.Arguments,
+		Returns:     AverageDecimals.Returns,
+		Description: AverageDecimals.Description,
+		Example: `
+(avg (list 1 2 3 4))                                             ; returns 2.5
+`,
+	},
+	SharesArguments: true,
+	Func:            AverageDecimals.Func,
+}
+
+var Partition = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "partition",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.ListOf(token.List),
+		Description: "Split a list into two lists, the first one contains the items for which the block evaluates to true, the second one the other items",
+		Example: `
+(partition (list 1 4 7 12 24) x (> (. x) 10))                    ; returns [[12, 24], [1, 4, 7]]
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0]
+		bindingName := args[1].String
+		blockToRun := args[2]
+		var passed, failed []*token.TaToken
+
+		scope := interp.NewScope()
+		for _, item := range list.Children {
+			scope.Set(bindingName, item)
+			result, err := evaluateBlock(scope, blockToRun)
+			if err != nil {
+				return nil, err
+			}
+			if !result.IsBool() {
+				return nil, errors.Errorf("Invalid type in block evaluation, expected type: Boolean got %s", result.Kind.String())
+			}
+			if result.Bool {
+				passed = append(passed, item)
+			} else {
+				failed = append(failed, item)
+			}
+		}
+		return token.NewList(token.NewList(passed...), token.NewList(failed...)), nil
+	},
+}
+
+// evaluateBlock evaluates a copy of the block in the scope
+func evaluateBlock(scope *interpreter.Interpreter, block *token.TaToken) (*token.TaToken, error) {
+	var result token.TaToken
+	token.Copy(&result, block)
+	if err := scope.Evaluate(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// group evaluates the block for each item and groups the items by the result, the keys are returned in the order they were found
+func group(interp *interpreter.Interpreter, list *token.TaToken, bindingName string, block *token.TaToken) ([]string, [][]*token.TaToken, error) {
+	var keys []string
+	var groups [][]*token.TaToken
+	index := make(map[string]int)
+
+	scope := interp.NewScope()
+	for _, item := range list.Children {
+		scope.Set(bindingName, item)
+		result, err := evaluateBlock(scope, block)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result.IsNull() || result.Kind&token.Atom == 0 {
+			return nil, nil, errors.Errorf("Cannot use %s as a key, expected a value of kind Atom got %s", result.Stringify(), result.Kind.String())
+		}
+		key := result.String
+		if !result.IsString() {
+			key = result.Stringify()
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			keys = append(keys, key)
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	return keys, groups, nil
+}
+
+// sumItems sums up the decimals the block evaluates to for each item
+func sumItems(interp *interpreter.Interpreter, items []*token.TaToken, bindingName string, block *token.TaToken) (decimal.Decimal, error) {
+	sum := decimal.Zero()
+	scope := interp.NewScope()
+	for _, item := range items {
+		scope.Set(bindingName, item)
+		result, err := evaluateBlock(scope, block)
+		if err != nil {
+			return sum, err
+		}
+		if !result.IsDecimal() {
+			return sum, errors.Errorf("Invalid type in block evaluation, expected type: DecimalKind got %s", result.Kind.String())
+		}
+		sum.Add(result.Decimal)
+	}
+	return sum, nil
+}
+
+var Slice = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "slice",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Decimal,
+			token.Decimal,
+		},
+		Returns:     token.List,
+		Description: "Returns the items from the start index up to (but not including) the end index, negative indices count from the end of the list",
+		Example: `
+(slice (list 1 2 3 4 5) 1 3)                                     ; returns a list containing 2 and 3
+(slice (list 1 2 3 4 5) -3 -1)                                   ; returns a list containing 3 and 4
+(slice (list 1 2 3 4 5) 2 6)                                     ; fails
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0].Children
+		start, err := listIndex(args[1], len(list))
+		if err != nil {
+			return nil, err
+		}
+		end, err := listIndex(args[2], len(list))
+		if err != nil {
+			return nil, err
+		}
+		if start > end {
+			return nil, errors.New("Out of bounds")
+		}
+		return token.NewList(list[start:end:end]...), nil
+	},
+}
+
+var SliceFrom = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "slice",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Decimal,
+		},
+		Returns:     token.List,
+		Description: "Returns the items from the start index up to the end of the list, a negative index counts from the end of the list",
+		Example: `
+(slice (list 1 2 3 4 5) 3)                                       ; returns a list containing 4 and 5
+(slice (list 1 2 3 4 5) -1)                                      ; returns a list containing 5
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0].Children
+		start, err := listIndex(args[1], len(list))
+		if err != nil {
+			return nil, err
+		}
+		return token.NewList(list[start:]...), nil
+	},
+}
+
+var Take = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "take",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Decimal,
+		},
+		Returns:     token.List,
+		Description: "Returns the first n items of the list, or the whole list if it has less than n items",
+		Example: `
+(take (list 1 2 3 4 5) 2)                                        ; returns a list containing 1 and 2
+(take (list 1 2) 5)                                              ; returns a list containing 1 and 2
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0].Children
+		n, err := listCount(args[1], len(list))
+		if err != nil {
+			return nil, err
+		}
+		return token.NewList(list[:n:n]...), nil
+	},
+}
+
+var Skip = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "skip",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Decimal,
+		},
+		Returns:     token.List,
+		Description: "Returns the list without the first n items, or an empty list if it has less than n items",
+		Example: `
+(skip (list 1 2 3 4 5) 2)                                        ; returns a list containing 3, 4 and 5
+(skip (list 1 2) 5)                                              ; returns an empty list
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0].Children
+		n, err := listCount(args[1], len(list))
+		if err != nil {
+			return nil, err
+		}
+		return token.NewList(list[n:]...), nil
+	},
+}
+
+var Last = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "last",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+		},
+		Returns:     token.Any,
+		Description: "Returns the last item in the list",
+		Example: `
+(last (list "Hello World" "Hello Universe"))                     ; returns "Hello Universe"
+(last (list 1 true Hello))                                       ; returns Hello
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if l := len(args[0].Children); l > 0 {
+			return args[0].Children[l-1], nil
+		}
+		return token.NewNull(), nil
+	},
+}
+
+var IndexOf = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "indexOf",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Decimal,
+		Description: "Returns the index of the first occurrence of the value in the list or -1 if the list does not contain the value, items are compared using token.Compare",
+		Example: `
+(indexOf (list "a" "b" "c") "b")                                 ; returns 1
+(indexOf (list 1 2 3) "2")                                       ; returns -1
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		for i, item := range args[0].Children {
+			if token.Compare(item, args[1]) == 0 {
+				return token.NewDecimalFromInt(int64(i)), nil
+			}
+		}
+		return token.NewDecimalFromInt(-1), nil
+	},
+}
+
+var Find = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "find",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.Any,
+		Description: "Returns the first item in the list for which the block evaluates to true or null if there is no such item",
+		Example: `
+(find (list 1 4 7 12 24) x (> (. x) 5))                          ; returns 7
+(find (list 1 4 7 12 24) x (> (. x) 50))                         ; returns null
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		i, err := findIndex(interp, args[0], args[1].String, args[2])
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 {
+			return token.NewNull(), nil
+		}
+		return args[0].Children[i], nil
+	},
+}
+
+var FindIndex = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "findIndex",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.Decimal,
+		Description: "Returns the index of the first item in the list for which the block evaluates to true or -1 if there is no such item",
+		Example: `
+(findIndex (list 1 4 7 12 24) x (> (. x) 5))                     ; returns 2
+(findIndex (list 1 4 7 12 24) x (> (. x) 50))                    ; returns -1
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		i, err := findIndex(interp, args[0], args[1].String, args[2])
+		if err != nil {
+			return nil, err
+		}
+		return token.NewDecimalFromInt(int64(i)), nil
+	},
+}
+
+var Flatten = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "flatten",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+		},
+		Returns:     token.List,
+		Description: "Replaces the lists inside the list with their items, only one level is flattened",
+		Example: `
+(flatten (. Lists))                                              ; returns [1, 2, 3, [4]] with the binding "$Lists" containing [[1, 2], 3, [[4]]]
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		items := make([]*token.TaToken, 0, len(args[0].Children))
+		for _, item := range args[0].Children {
+			if item.IsList() {
+				items = append(items, item.Children...)
+			} else {
+				items = append(items, item)
+			}
+		}
+		return token.NewList(items...), nil
+	},
+}
+
+var Zip = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "zip",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.List,
+			token.List,
+			token.List,
+		},
+		Returns:     token.ListOf(token.List),
+		Description: "Combines the items with the same index of all lists into lists, the result is as long as the shortest list",
+		Example: `
+(zip (list 1 2 3) (list "a" "b"))                                ; returns [[1, "a"], [2, "b"]]
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		size := len(args[0].Children)
+		for _, list := range args[1:] {
+			if len(list.Children) < size {
+				size = len(list.Children)
+			}
+		}
+		tuples := make([]*token.TaToken, size)
+		for i := range tuples {
+			items := make([]*token.TaToken, len(args))
+			for j, list := range args {
+				items[j] = list.Children[i]
+			}
+			tuples[i] = token.NewList(items...)
+		}
+		return token.NewList(tuples...), nil
+	},
+}
+
+var Range = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "range",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Decimal,
+			token.Decimal,
+		},
+		Returns:     token.ListOf(token.Decimal),
+		Description: "Create a list of decimals from start up to (but not including) end",
+		Example: `
+(range 0 5)                                                      ; returns a list containing 0, 1, 2, 3 and 4
+(range 3 1)                                                      ; returns a list containing 3 and 2
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		step := decimal.NewFromInt(1)
+		if decimal.Cmp(args[0].Decimal, args[1].Decimal) > 0 {
+			step = decimal.NewFromInt(-1)
+		}
+		return decimalRange(args[0].Decimal, args[1].Decimal, step)
+	},
+}
+
+var RangeStep = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "range",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Decimal,
+			token.Decimal,
+			token.Decimal,
+		},
+		Returns:     token.ListOf(token.Decimal),
+		Description: "Create a list of decimals from start up to (but not including) end, increasing by step",
+		Example: `
+(range 0 1 0.25)                                                 ; returns a list containing 0, 0.25, 0.5 and 0.75
+(range 10 0 -5)                                                  ; returns a list containing 10 and 5
+(range 0 10 -1)                                                  ; returns an empty list
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if args[2].Decimal.Equals(decimal.Zero()) {
+			return nil, errors.New("The step of a range must not be 0")
+		}
+		return decimalRange(args[0].Decimal, args[1].Decimal, args[2].Decimal)
+	},
+}
+
+var Chunk = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "chunk",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.List,
+			token.Decimal,
+		},
+		Returns:     token.ListOf(token.List),
+		Description: "Split the list into lists of the given size, the last list contains the remaining items",
+		Example: `
+(chunk (list 1 2 3 4 5) 2)                                       ; returns [[1, 2], [3, 4], [5]]
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0].Children
+		i, err := args[1].Decimal.Int64()
+		if err != nil {
+			return nil, err
+		}
+		if i <= 0 {
+			return nil, errors.New("The chunk size must be greater than 0")
+		}
+		size := int(i)
+		chunks := make([]*token.TaToken, 0, (len(list)+size-1)/size)
+		for start := 0; start < len(list); start += size {
+			end := start + size
+			if end > len(list) {
+				end = len(list)
+			}
+			chunks = append(chunks, token.NewList(list[start:end:end]...))
+		}
+		return token.NewList(chunks...), nil
+	},
+}
+
+// maxRangeSize is the maximum number of items range creates
+const maxRangeSize = 10000
+
+// listIndex converts the decimal to an index into a list of the given size,
+// negative indices count from the end, the size itself is a valid index for the end of a slice
+func listIndex(arg *token.TaToken, size int) (int, error) {
+	i, err := arg.Decimal.Int64()
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		i += int64(size)
+	}
+	if i < 0 || i > int64(size) {
+		return 0, errors.New("Out of bounds")
+	}
+	return int(i), nil
+}
+
+// listCount converts the decimal to a number of items, counts larger than the size are capped to the size
+func listCount(arg *token.TaToken, size int) (int, error) {
+	i, err := arg.Decimal.Int64()
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, errors.New("Out of bounds")
+	}
+	if i > int64(size) {
+		return size, nil
+	}
+	return int(i), nil
+}
+
+// findIndex returns the index of the first item the block evaluates to true for or -1
+func findIndex(interp *interpreter.Interpreter, list *token.TaToken, bindingName string, block *token.TaToken) (int, error) {
+	scope := interp.NewScope()
+	for i, item := range list.Children {
+		scope.Set(bindingName, item)
+		result, err := evaluateBlock(scope, block)
+		if err != nil {
+			return -1, err
+		}
+		if !result.IsBool() {
+			return -1, errors.Errorf("Invalid type in block evaluation, expected type: Boolean got %s", result.Kind.String())
+		}
+		if result.Bool {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func decimalRange(start, end, step decimal.Decimal) (*token.TaToken, error) {
+	ascending := step.Cmp(decimal.Zero()) > 0
+	var items []*token.TaToken
+	for current := decimal.NewFromDecimal(start); ; current = decimal.Add(current, step) {
+		if c := decimal.Cmp(current, end); (ascending && c >= 0) || (!ascending && c <= 0) {
+			break
+		}
+		if len(items) == maxRangeSize {
+			return nil, errors.Errorf("A range cannot contain more than %d items", maxRangeSize)
+		}
+		items = append(items, token.NewDecimal(current))
+	}
+	return token.NewList(items...), nil
+}
+
+var SortBy = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "sortBy",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.List,
+			token.String,
+			token.Token,
+			token.Token | token.String,
+		},
+		Returns:     token.List,
+		Description: "Sort a list by the keys the blocks evaluate to for each item, every key block can be followed by asc or desc. Later keys are used when the earlier keys are equal, items with equal keys keep their order and keys are compared using token.Compare",
+		Example: `
+(sortBy (list 10 9 1) x (. x))                                   ; returns a list containing 1, 9 and 10
+(sortBy (list 10 9 1) x (. x) desc)                              ; returns a list containing 10, 9 and 1
+(sortBy (. Items) Item (. Item Category) (. Item Price) desc)    ; returns the items sorted by category and the most expensive items first
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		list := args[0].Children
+		bindingName := args[1].String
+
+		type sortKey struct {
+			block      *token.TaToken
+			descending bool
+			directed   bool
+		}
+		var keys []sortKey
+		for _, arg := range args[2:] {
+			if arg.IsBlock() {
+				keys = append(keys, sortKey{block: arg})
+				continue
+			}
+			direction := strings.ToLower(arg.String)
+			if (direction != "asc" && direction != "desc") || len(keys) == 0 || keys[len(keys)-1].directed {
+				return nil, errors.Errorf("Invalid sort direction `%s', expected asc or desc after a key", arg.String)
+			}
+			keys[len(keys)-1].descending = direction == "desc"
+			keys[len(keys)-1].directed = true
+		}
+
+		// evaluate the keys once for every item
+		values := make([][]*token.TaToken, len(list))
+		scope := interp.NewScope()
+		for i, item := range list {
+			values[i] = make([]*token.TaToken, len(keys))
+			scope.Set(bindingName, item)
+			for j, key := range keys {
+				result, err := evaluateBlock(scope, key.block)
+				if err != nil {
+					return nil, err
+				}
+				values[i][j] = result
+			}
+		}
+
+		order := make([]int, len(list))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			for j, key := range keys {
+				c := compare(interp, values[order[a]][j], values[order[b]][j])
+				if key.descending {
+					c = -c
+				}
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+
+		sorted := make([]*token.TaToken, len(list))
+		for i, index := range order {
+			sorted[i] = list[index]
+		}
+		return token.NewList(sorted...), nil
+	},
+}
+
+// compare compares two items using token.Compare, in loose comparison mode the string representations are compared
+func compare(interp *interpreter.Interpreter, a, b *token.TaToken) int {
+	if interp.UsesLooseComparison() {
+		return strings.Compare(a.String, b.String)
+	}
+	return token.Compare(a, b)
+}
+
+// sortByString sorts the list by the strings the block returns for the items using the compare function
+func sortByString(interp *interpreter.Interpreter, list, binding *token.TaToken, descending bool, compare func(a, b string) int) (*token.TaToken, error) {
+	block := binding.Children[1]
+	bindingName := binding.Children[0].String
+
+	type SortByItem struct {
+		Word string
+		Item *token.TaToken
+	}
+
+	structlist := make([]*SortByItem, len(list.Children))
+	scope := interp.NewScope()
+
+	for i, item := range list.Children {
+		var result token.TaToken
+		scope.Set(bindingName, item)
+		token.Copy(&result, block)
+		if err := scope.Evaluate(&result); err != nil {
+			return nil, err
+		}
+		structlist[i] = &SortByItem{result.String, item}
+	}
+
+	sort.SliceStable(structlist, func(i, j int) bool {
+		if descending {
+			return compare(structlist[i].Word, structlist[j].Word) > 0
+		}
+		return compare(structlist[i].Word, structlist[j].Word) < 0
+	})
+
+	sorted := make([]*token.TaToken, len(structlist))
+	for i := 0; i < len(structlist); i++ {
+		sorted[i] = structlist[i].Item
+	}
+	return token.NewList(sorted...), nil
+}
+
+// newCollator returns a collator that compares strings using the rules of the locale,
+// collators are not safe for concurrent use so every call needs its own
+func newCollator(locale string) (*collate.Collator, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, errors.Errorf("Unknown locale %s", locale)
+	}
+	return collate.New(tag), nil
 }
@@ -13,6 +13,7 @@ func AllOperations() []interpreter.TaFunction {
 		Map,
 		MapLegacy,
 		Sort,
+		SortLocale,
 		Min,
 		Max,
 		Append,
@@ -24,10 +25,45 @@ func AllOperations() []interpreter.TaFunction {
 		Exists,
 		ExistsLegacy,
 		Sum,
+		SumDecimals,
 		Every,
 		EveryLegacy,
 		SortByNumber,
 		SortByString,
+		SortByStringLocale,
 		Filter,
+		Unique,
+		Union,
+		Intersection,
+		Difference,
+		IsSubset,
+		CountOf,
+		CountOfList,
+		ContainsAny,
+		ContainsAll,
+		Reduce,
+		Fold,
+		GroupBy,
+		CountBy,
+		SumBy,
+		Average,
+		AverageDecimals,
+		Avg,
+		AvgDecimals,
+		Partition,
+		Slice,
+		SliceFrom,
+		Take,
+		Skip,
+		Last,
+		IndexOf,
+		Find,
+		FindIndex,
+		Flatten,
+		Zip,
+		Range,
+		RangeStep,
+		Chunk,
+		SortBy,
 	}
 }
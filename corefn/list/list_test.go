@@ -2,6 +2,7 @@ package list_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -284,6 +285,32 @@ func TestSort(t *testing.T) {
 	require.EqualValues(t, token.NewList(token.NewString("World"), token.NewDecimalFromInt(2), token.NewString("Hello"), token.NewDecimalFromInt(1)), interp.Get("List"))
 }
 
+func TestSortLocale(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`sort (list "Zebra" "Äpfel" "apple" "Apfel") false "de"`,
+			nil,
+			token.NewList(token.NewString("Apfel"), token.NewString("Äpfel"), token.NewString("apple"), token.NewString("Zebra")),
+		}, helpers.Test{
+			`sort (list "Zebra" "Äpfel" "apple" "Apfel") false "sv"`,
+			nil,
+			token.NewList(token.NewString("Apfel"), token.NewString("apple"), token.NewString("Zebra"), token.NewString("Äpfel")),
+		}, helpers.Test{
+			`sort (list "Zebra" "Äpfel" "apple" "Apfel") true "de"`,
+			nil,
+			token.NewList(token.NewString("Zebra"), token.NewString("apple"), token.NewString("Äpfel"), token.NewString("Apfel")),
+		}, helpers.Test{
+			`sort (list "b" 2 "a" 1) false "en"`,
+			nil,
+			token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2), token.NewString("a"), token.NewString("b")),
+		}, helpers.Test{
+			`sort (list "b" "a") false "not a locale"`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
 func TestMin(t *testing.T) {
 	helpers.RunTests(t,
 		helpers.Test{
@@ -294,11 +321,21 @@ func TestMin(t *testing.T) {
 		helpers.Test{
 			`min (list 100 4 3 10 6000 Hello 90 99)`,
 			nil,
-			token.NewDecimalFromInt(3),
+			&helpers.Error{},
 		},
 		helpers.Test{
 			`min (list Hello World)`,
 			nil,
+			token.NewString("Hello"),
+		},
+		helpers.Test{
+			`min (list 2018-01-02 2017-01-02T10:00:00Z)`,
+			nil,
+			token.NewTime(time.Date(2017, 1, 2, 10, 0, 0, 0, time.UTC)),
+		},
+		helpers.Test{
+			`min (list)`,
+			nil,
 			&helpers.Error{},
 		},
 	)
@@ -314,14 +351,46 @@ func TestMax(t *testing.T) {
 		helpers.Test{
 			`max (list 100 4 3 10 6000 Hello 90 99)`,
 			nil,
-			token.NewDecimalFromInt(6000),
+			&helpers.Error{},
 		},
 		helpers.Test{
 			`max (list Hello World)`,
 			nil,
+			token.NewString("World"),
+		},
+	)
+}
+
+func TestLooseComparison(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	interp.LooseComparison = true
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			`min (list 100 4 3 10 6000 Hello 90 99)`,
+			nil,
+			token.NewDecimalFromInt(3),
+		},
+		helpers.Test{
+			`max (list 100 4 3 10 6000 Hello 90 99)`,
+			nil,
+			token.NewDecimalFromInt(6000),
+		},
+		helpers.Test{
+			`min (list Hello World)`,
+			nil,
 			&helpers.Error{},
 		},
+		helpers.Test{
+			`sort (list 10 9 1)`,
+			nil,
+			token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(10), token.NewDecimalFromInt(9)),
+		},
 	)
+	helpers.RunTests(t, helpers.Test{
+		`sort (list 10 9 1)`,
+		nil,
+		token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(9), token.NewDecimalFromInt(10)),
+	})
 }
 
 func TestCount(t *testing.T) {
@@ -339,6 +408,18 @@ func TestCount(t *testing.T) {
 	)
 }
 
+func TestSharedLists(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	interp.Set("List", token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2)))
+
+	// pushing to the same list twice must not overwrite the first result
+	interp.MustLexAndEvaluate("set A (push (drop (. List)) 3)")
+	interp.MustLexAndEvaluate("set B (push (drop (. List)) 4)")
+	require.Equal(t, "[1, 3]", interp.Get("A").Stringify())
+	require.Equal(t, "[1, 4]", interp.Get("B").Stringify())
+	require.Equal(t, "[1, 2]", interp.Get("List").Stringify())
+}
+
 func TestReverse(t *testing.T) {
 	helpers.RunTests(t,
 		helpers.Test{
@@ -376,6 +457,29 @@ func BenchmarkReverse(b *testing.B) {
 	}
 }
 
+func BenchmarkLargeList(b *testing.B) {
+	children := make([]*token.TaToken, 10000)
+	for i := range children {
+		children[i] = token.NewDecimalFromInt(int64(i))
+	}
+	interp := helpers.MustNewInterpreter()
+	interp.Set("List", token.NewList(children...))
+
+	for _, input := range []string{
+		"push (. List) 1",
+		"tail (. List)",
+		"drop (. List)",
+		"reverse (. List)",
+		"count (tail (drop (. List)))",
+	} {
+		b.Run(input, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				interp.MustLexAndEvaluate(input)
+			}
+		})
+	}
+}
+
 func TestJoin(t *testing.T) {
 	helpers.RunTests(t,
 		helpers.Test{
@@ -412,6 +516,13 @@ func TestJoin(t *testing.T) {
 			nil,
 			token.NewString("1-2-3"),
 		},
+		helpers.Test{
+			`join (. Empty) -`,
+			token.NewMap(map[string]*token.TaToken{
+				"Empty": token.NewList(),
+			}),
+			token.NewString(""),
+		},
 	)
 }
 
@@ -521,6 +632,21 @@ func TestSum(t *testing.T) {
 				),
 			}),
 			helpers.Error{},
+		}, helpers.Test{
+			`sum (list 1 2 3.5)`,
+			nil,
+			token.NewDecimalFromFloat(6.5),
+		}, helpers.Test{
+			`sum (. Empty)`,
+			token.NewMap(map[string]*token.TaToken{
+				"Empty": token.NewList(),
+			}),
+			token.NewDecimalFromInt(0),
+		}, helpers.Test{
+			// the list must only contain decimals
+			`sum (list 1 a)`,
+			nil,
+			helpers.Error{},
 		},
 	)
 }
@@ -710,6 +836,11 @@ func TestSortByNumber(t *testing.T) {
 				token.NewDecimalFromInt(4),
 			),
 		},
+		helpers.Test{
+			`sortByNumber (list 2 a 3 1) ((Item) (. Item)) false`,
+			nil,
+			helpers.Error{},
+		},
 	)
 }
 
@@ -785,6 +916,42 @@ func TestSortByString(t *testing.T) {
 	)
 }
 
+func TestSortByStringLocale(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`sortByString (. List) ((Item) (. Item Name)) false "de"`,
+			token.NewMap(map[string]*token.TaToken{
+				"List": token.NewList(
+					token.NewMap(map[string]*token.TaToken{"Name": token.NewString("Østergaard")}),
+					token.NewMap(map[string]*token.TaToken{"Name": token.NewString("Zimmer")}),
+					token.NewMap(map[string]*token.TaToken{"Name": token.NewString("Olsen")}),
+				),
+			}),
+			token.NewList(
+				token.NewMap(map[string]*token.TaToken{"Name": token.NewString("Olsen")}),
+				token.NewMap(map[string]*token.TaToken{"Name": token.NewString("Østergaard")}),
+				token.NewMap(map[string]*token.TaToken{"Name": token.NewString("Zimmer")}),
+			),
+		}, helpers.Test{
+			`sortByString (list "Zebra" "Äpfel" "apple") ((Item) (. Item)) false "sv"`,
+			nil,
+			token.NewList(token.NewString("apple"), token.NewString("Zebra"), token.NewString("Äpfel")),
+		}, helpers.Test{
+			`sortByString (list "Zebra" "Äpfel" "apple") ((Item) (. Item)) true "de"`,
+			nil,
+			token.NewList(token.NewString("Zebra"), token.NewString("apple"), token.NewString("Äpfel")),
+		}, helpers.Test{
+			`sortByString (list "Zebra" "Äpfel" "apple") ((Item) (. Item)) false`,
+			nil,
+			token.NewList(token.NewString("Zebra"), token.NewString("apple"), token.NewString("Äpfel")),
+		}, helpers.Test{
+			`sortByString (list "b" "a") ((Item) (. Item)) false "not a locale"`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
 func TestFilter(t *testing.T) {
 	helpers.RunTests(t,
 		helpers.Test{
@@ -850,3 +1017,668 @@ func TestFilter(t *testing.T) {
 		},
 	)
 }
+
+func TestUnique(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`unique (list 1 "1" 1.0 true "true" 2 1)`,
+			nil,
+			token.NewList(
+				token.NewDecimalFromInt(1),
+				token.NewString("1"),
+				token.NewBool(true),
+				token.NewString("true"),
+				token.NewDecimalFromInt(2),
+			),
+		}, helpers.Test{
+			`unique (list)`,
+			nil,
+			token.NewList(),
+		}, helpers.Test{
+			`unique (. List)`,
+			token.NewMap(map[string]*token.TaToken{
+				"List": token.NewList(
+					token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2)),
+					token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2)),
+					token.NewList(token.NewDecimalFromInt(2), token.NewDecimalFromInt(1)),
+				),
+			}),
+			token.NewList(
+				token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2)),
+				token.NewList(token.NewDecimalFromInt(2), token.NewDecimalFromInt(1)),
+			),
+		},
+	)
+}
+
+func TestUnion(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`union (list 1 2) (list 2 3)`,
+			nil,
+			token.NewList(
+				token.NewDecimalFromInt(1),
+				token.NewDecimalFromInt(2),
+				token.NewDecimalFromInt(3),
+			),
+		}, helpers.Test{
+			`union (list 1) (list "1" 1.00) (list true)`,
+			nil,
+			token.NewList(
+				token.NewDecimalFromInt(1),
+				token.NewString("1"),
+				token.NewBool(true),
+			),
+		},
+	)
+}
+
+func TestIntersection(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`intersection (list 1 2 3 2) (list 2 3 4)`,
+			nil,
+			token.NewList(
+				token.NewDecimalFromInt(2),
+				token.NewDecimalFromInt(3),
+			),
+		}, helpers.Test{
+			`intersection (list 1 "1" true) (list "1" 1.0) (list "1")`,
+			nil,
+			token.NewList(
+				token.NewString("1"),
+			),
+		}, helpers.Test{
+			`intersection (list 1 2) (list)`,
+			nil,
+			token.NewList(),
+		},
+	)
+}
+
+func TestDifference(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`difference (list 1 2 3 1) (list 2 3 4)`,
+			nil,
+			token.NewList(
+				token.NewDecimalFromInt(1),
+			),
+		}, helpers.Test{
+			`difference (list 1 "1" true "true") (list "1") (list true)`,
+			nil,
+			token.NewList(
+				token.NewDecimalFromInt(1),
+				token.NewString("true"),
+			),
+		},
+	)
+}
+
+func TestIsSubset(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`isSubset (list 1 2) (list 3 2 1)`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`isSubset (list 1 "2") (list 1 2)`,
+			nil,
+			token.NewBool(false),
+		}, helpers.Test{
+			`isSubset (list) (list 1)`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`isSubset (list 1.00 1) (list 1)`,
+			nil,
+			token.NewBool(true),
+		},
+	)
+}
+
+func TestCountOf(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`countOf (list 1 2 1.0 "1" true) 1`,
+			nil,
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			`countOf (list 1 2 1.0 "1" true) "1"`,
+			nil,
+			token.NewDecimalFromInt(1),
+		}, helpers.Test{
+			`countOf (list 1 2) "3"`,
+			nil,
+			token.NewDecimalFromInt(0),
+		}, helpers.Test{
+			`countOf (list SKU1 SKU2 SKU2 SKU3) (list SKU2 SKU3 SKU4)`,
+			nil,
+			token.NewDecimalFromInt(3),
+		}, helpers.Test{
+			`countOf (list 1 "2" 3) (list "1" 2 3)`,
+			nil,
+			token.NewDecimalFromInt(1),
+		},
+	)
+}
+
+func TestContainsAnyAll(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`containsAny (list 1 2 3) (list 3 4)`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`containsAny (list 1 2 3) (list "3" false)`,
+			nil,
+			token.NewBool(false),
+		}, helpers.Test{
+			`containsAny (list 1 2 3) (list)`,
+			nil,
+			token.NewBool(false),
+		}, helpers.Test{
+			`containsAll (list 1 2 3) (list 3 1)`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`containsAll (list 1 2 3) (list 3 "1")`,
+			nil,
+			token.NewBool(false),
+		}, helpers.Test{
+			`containsAll (list 1 2 3) (list)`,
+			nil,
+			token.NewBool(true),
+		},
+	)
+}
+
+func shoppingCart() *token.TaToken {
+	item := func(category string, price, quantity int64) *token.TaToken {
+		return token.NewMap(map[string]*token.TaToken{
+			"Category": token.NewString(category),
+			"Price":    token.NewDecimalFromInt(price),
+			"Quantity": token.NewDecimalFromInt(quantity),
+		})
+	}
+	return token.NewMap(map[string]*token.TaToken{
+		"Items": token.NewList(
+			item("Shoes", 50, 1),
+			item("Shirts", 20, 3),
+			item("Shoes", 80, 2),
+		),
+	})
+}
+
+func TestReduce(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`reduce (list 1 2 3) Sum x 0 (+ (. Sum) (. x))`,
+			nil,
+			token.NewDecimalFromInt(6),
+		}, helpers.Test{
+			`fold (list 1 2 3) Sum x 0 (+ (. Sum) (. x))`,
+			nil,
+			token.NewDecimalFromInt(6),
+		}, helpers.Test{
+			`reduce (list) Sum x 10 (+ (. Sum) (. x))`,
+			nil,
+			token.NewDecimalFromInt(10),
+		}, helpers.Test{
+			`reduce (list "a" "b" "c") Text x "" (+ (. x) (. Text))`,
+			nil,
+			token.NewString("cba"),
+		}, helpers.Test{
+			`reduce (. Items) Total Item 0 (+ (. Total) (* (. Item Price) (. Item Quantity)))`,
+			shoppingCart(),
+			token.NewDecimalFromInt(270),
+		}, helpers.Test{
+			`reduce (. Items) Max Item 0 (max (list (. Max) (. Item Price)))`,
+			shoppingCart(),
+			token.NewDecimalFromInt(80),
+		}, helpers.Test{
+			`reduce (list 1 2 3) Sum x 0 (+ (. Sum) (. y))`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestGroupBy(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`groupBy (list 1 2 3 4 5) x (= 0 (mod (. x) 2))`,
+			nil,
+			token.NewOrderedMap([]string{"false", "true"}, []*token.TaToken{
+				token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(3), token.NewDecimalFromInt(5)),
+				token.NewList(token.NewDecimalFromInt(2), token.NewDecimalFromInt(4)),
+			}),
+		}, helpers.Test{
+			`groupBy (list) x (. x)`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{}),
+		}, helpers.Test{
+			`groupBy (. Items) Item (. Item Category)`,
+			shoppingCart(),
+			token.NewOrderedMap([]string{"Shoes", "Shirts"}, []*token.TaToken{
+				token.NewList(
+					shoppingCart().MapItem("Items").Children[0],
+					shoppingCart().MapItem("Items").Children[2],
+				),
+				token.NewList(
+					shoppingCart().MapItem("Items").Children[1],
+				),
+			}),
+		}, helpers.Test{
+			`groupBy (list 1 2) x (list (. x))`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestCountBy(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`countBy (list 1 2 3 4 5) x (= 0 (mod (. x) 2))`,
+			nil,
+			token.NewOrderedMap([]string{"false", "true"}, []*token.TaToken{
+				token.NewDecimalFromInt(3),
+				token.NewDecimalFromInt(2),
+			}),
+		}, helpers.Test{
+			`countBy (. Items) Item (. Item Category)`,
+			shoppingCart(),
+			token.NewOrderedMap([]string{"Shoes", "Shirts"}, []*token.TaToken{
+				token.NewDecimalFromInt(2),
+				token.NewDecimalFromInt(1),
+			}),
+		},
+	)
+}
+
+func TestSumBy(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`sumBy (. Items) Item (. Item Category) (* (. Item Price) (. Item Quantity))`,
+			shoppingCart(),
+			token.NewOrderedMap([]string{"Shoes", "Shirts"}, []*token.TaToken{
+				token.NewDecimalFromInt(210),
+				token.NewDecimalFromInt(60),
+			}),
+		}, helpers.Test{
+			`sumBy (. Items) Item (. Item Category) (. Item Category)`,
+			shoppingCart(),
+			helpers.Error{},
+		},
+	)
+}
+
+func TestAverage(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`average (list 1 2 3 4)`,
+			nil,
+			token.NewDecimalFromString("2.5"),
+		}, helpers.Test{
+			`avg (list 1 2 3)`,
+			nil,
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			`average (. Items) Item (. Item Price)`,
+			shoppingCart(),
+			token.NewDecimalFromInt(50),
+		}, helpers.Test{
+			`avg (. Items) Item (. Item Quantity)`,
+			shoppingCart(),
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			`average (list)`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`avg (list) x (. x)`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestPartition(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`partition (list 1 4 7 12 24) x (> (. x) 10)`,
+			nil,
+			token.NewList(
+				token.NewList(token.NewDecimalFromInt(12), token.NewDecimalFromInt(24)),
+				token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(4), token.NewDecimalFromInt(7)),
+			),
+		}, helpers.Test{
+			`partition (list) x (> (. x) 10)`,
+			nil,
+			token.NewList(token.NewList(), token.NewList()),
+		}, helpers.Test{
+			`partition (list 1 2) x (. x)`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func decimals(values ...int64) *token.TaToken {
+	items := make([]*token.TaToken, len(values))
+	for i, v := range values {
+		items[i] = token.NewDecimalFromInt(v)
+	}
+	return token.NewList(items...)
+}
+
+func TestSlice(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`slice (list 1 2 3 4 5) 1 3`,
+			nil,
+			decimals(2, 3),
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) -3 -1`,
+			nil,
+			decimals(3, 4),
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) 0 5`,
+			nil,
+			decimals(1, 2, 3, 4, 5),
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) 2 2`,
+			nil,
+			decimals(),
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) 3`,
+			nil,
+			decimals(4, 5),
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) -1`,
+			nil,
+			decimals(5),
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) 2 6`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) -6`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`slice (list 1 2 3 4 5) 3 1`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestTakeSkip(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`take (list 1 2 3 4 5) 2`,
+			nil,
+			decimals(1, 2),
+		}, helpers.Test{
+			`take (list 1 2) 5`,
+			nil,
+			decimals(1, 2),
+		}, helpers.Test{
+			`take (list 1 2) 0`,
+			nil,
+			decimals(),
+		}, helpers.Test{
+			`take (list 1 2) -1`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`skip (list 1 2 3 4 5) 2`,
+			nil,
+			decimals(3, 4, 5),
+		}, helpers.Test{
+			`skip (list 1 2) 5`,
+			nil,
+			decimals(),
+		}, helpers.Test{
+			`skip (list 1 2) -1`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestLast(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`last (list "Hello World" "Hello Universe")`,
+			nil,
+			token.NewString("Hello Universe"),
+		}, helpers.Test{
+			`last (list)`,
+			nil,
+			token.NewNull(),
+		},
+	)
+}
+
+func TestIndexOf(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`indexOf (list "a" "b" "c" "b") "b"`,
+			nil,
+			token.NewDecimalFromInt(1),
+		}, helpers.Test{
+			`indexOf (list 1 2 3) "2"`,
+			nil,
+			token.NewDecimalFromInt(-1),
+		}, helpers.Test{
+			`indexOf (list "1" 1.0 1) 1`,
+			nil,
+			token.NewDecimalFromInt(1),
+		}, helpers.Test{
+			`indexOf (list) 1`,
+			nil,
+			token.NewDecimalFromInt(-1),
+		},
+	)
+}
+
+func TestFind(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`find (list 1 4 7 12 24) x (> (. x) 5)`,
+			nil,
+			token.NewDecimalFromInt(7),
+		}, helpers.Test{
+			`find (list 1 4 7 12 24) x (> (. x) 50)`,
+			nil,
+			token.NewNull(),
+		}, helpers.Test{
+			`find (. Items) Item (= (. Item Category) "Shirts")`,
+			shoppingCart(),
+			shoppingCart().MapItem("Items").Children[1],
+		}, helpers.Test{
+			`find (list 1 2) x (. x)`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`findIndex (list 1 4 7 12 24) x (> (. x) 5)`,
+			nil,
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			`findIndex (list 1 4 7 12 24) x (> (. x) 50)`,
+			nil,
+			token.NewDecimalFromInt(-1),
+		},
+	)
+}
+
+func TestFlatten(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`flatten (. Lists)`,
+			token.NewMap(map[string]*token.TaToken{
+				"Lists": token.NewList(decimals(1, 2), token.NewDecimalFromInt(3), token.NewList(decimals(4))),
+			}),
+			token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2), token.NewDecimalFromInt(3), decimals(4)),
+		}, helpers.Test{
+			`flatten (list)`,
+			nil,
+			decimals(),
+		},
+	)
+}
+
+func TestZip(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`zip (list 1 2 3) (list "a" "b")`,
+			nil,
+			token.NewList(
+				token.NewList(token.NewDecimalFromInt(1), token.NewString("a")),
+				token.NewList(token.NewDecimalFromInt(2), token.NewString("b")),
+			),
+		}, helpers.Test{
+			`zip (list 1 2) (list 3 4) (list 5 6)`,
+			nil,
+			token.NewList(decimals(1, 3, 5), decimals(2, 4, 6)),
+		}, helpers.Test{
+			`zip (list 1 2) (list)`,
+			nil,
+			token.NewList(),
+		},
+	)
+}
+
+func TestRange(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`range 0 5`,
+			nil,
+			decimals(0, 1, 2, 3, 4),
+		}, helpers.Test{
+			`range 3 1`,
+			nil,
+			decimals(3, 2),
+		}, helpers.Test{
+			`range 1 1`,
+			nil,
+			decimals(),
+		}, helpers.Test{
+			`range 0 1 0.25`,
+			nil,
+			token.NewList(
+				token.NewDecimalFromInt(0),
+				token.NewDecimalFromString("0.25"),
+				token.NewDecimalFromString("0.5"),
+				token.NewDecimalFromString("0.75"),
+			),
+		}, helpers.Test{
+			`range 10 0 -5`,
+			nil,
+			decimals(10, 5),
+		}, helpers.Test{
+			`range 0 10 -1`,
+			nil,
+			decimals(),
+		}, helpers.Test{
+			`range 0 10 0`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`range 0 10001`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestChunk(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`chunk (list 1 2 3 4 5) 2`,
+			nil,
+			token.NewList(decimals(1, 2), decimals(3, 4), decimals(5)),
+		}, helpers.Test{
+			`chunk (list 1 2) 5`,
+			nil,
+			token.NewList(decimals(1, 2)),
+		}, helpers.Test{
+			`chunk (list) 2`,
+			nil,
+			token.NewList(),
+		}, helpers.Test{
+			`chunk (list 1 2) 0`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestSortBy(t *testing.T) {
+	items := shoppingCart().MapItem("Items").Children
+	helpers.RunTests(t,
+		helpers.Test{
+			`sortBy (list 10 9 1) x (. x)`,
+			nil,
+			decimals(1, 9, 10),
+		}, helpers.Test{
+			`sortBy (list 10 9 1) x (. x) desc`,
+			nil,
+			decimals(10, 9, 1),
+		}, helpers.Test{
+			`sortBy (list 10 9 1) x (. x) ASC`,
+			nil,
+			decimals(1, 9, 10),
+		}, helpers.Test{
+			`sortBy (list) x (. x)`,
+			nil,
+			decimals(),
+		}, helpers.Test{
+			`sortBy (. Items) Item (. Item Category) (. Item Price) desc`,
+			shoppingCart(),
+			token.NewList(items[1], items[2], items[0]),
+		}, helpers.Test{
+			`sortBy (. Items) Item (. Item Category) desc (. Item Price)`,
+			shoppingCart(),
+			token.NewList(items[0], items[2], items[1]),
+		}, helpers.Test{
+			// items with equal keys keep their order
+			`sortBy (. Items) Item (. Item Category)`,
+			shoppingCart(),
+			token.NewList(items[1], items[0], items[2]),
+		}, helpers.Test{
+			`sortBy (. Items) Item (. Item Category) desc`,
+			shoppingCart(),
+			token.NewList(items[0], items[2], items[1]),
+		}, helpers.Test{
+			`sortBy (. Dates) x (. x)`,
+			token.NewMap(map[string]*token.TaToken{
+				"Dates": token.NewList(
+					token.NewTime(time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)),
+					token.NewTime(time.Date(2018, 1, 1, 23, 0, 0, 0, time.FixedZone("", -3600))),
+					token.NewTime(time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)),
+				),
+			}),
+			token.NewList(
+				token.NewTime(time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)),
+				token.NewTime(time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)),
+				token.NewTime(time.Date(2018, 1, 1, 23, 0, 0, 0, time.FixedZone("", -3600))),
+			),
+		}, helpers.Test{
+			`sortBy (list 2 "a" 1 true) x (. x)`,
+			nil,
+			token.NewList(token.NewBool(true), token.NewDecimalFromInt(1), token.NewDecimalFromInt(2), token.NewString("a")),
+		}, helpers.Test{
+			`sortBy (list 1 2) x (. x) up`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`sortBy (list 1 2) x (. x) asc desc`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
@@ -3,6 +3,8 @@ package mapping
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/talon-one/talang/interpreter"
 	"github.com/talon-one/talang/token"
@@ -19,30 +21,660 @@ var KV = interpreter.TaFunction{
 		Name:       "kv",
 		IsVariadic: true,
 		Arguments: []token.Kind{
-			token.Token,
+			token.Token | token.Atom,
 		},
 		Returns:     token.Map,
-		Description: "Create a map with any key value pairs passed as arguments.",
+		Description: "Create a map with any key value pairs passed as arguments. A block (Key value) adds the key Key, even if Key is the name of a function. Any other argument is a key followed by its value, blocks with more than one value and binding accesses like (. Id) are evaluated so keys can be computed. Later values of a key replace earlier values",
 		Example: `
 (kv (Key1 "Hello World") (Key2 true) (Key3 123))                 ; returns a Map with the keys key1, key2, key3
+(kv Key1 "Hello World" Key2 true)                                ; returns a Map with the keys Key1 and Key2
+(kv (+ "sku_" (. Id)) 5)                                         ; returns {sku_1:5} with the binding "$Id" containing "1"
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		m := make(map[string]*token.TaToken)
+		// keep the order of the arguments
+		m := token.NewOrderedMap(nil, nil)
 		for i := 0; i < len(args); i++ {
-			if args[i].IsBlock() && len(args[i].String) > 0 {
-				if len(args[i].Children) > 1 {
-					return nil, errors.New("Unable to add multiple values to one key")
-				}
+			// blocks with one value are pairs even if the key is a function name, so new functions never change existing maps,
+			// only binding accesses like (. Id) are keys
+			if args[i].IsBlock() && len(args[i].String) > 0 && len(args[i].Children) == 1 && args[i].String != "." && args[i].String != ".?" {
 				value := args[i].Children[0]
 				if value.IsBlock() {
 					if err := interp.Evaluate(value); err != nil {
 						return nil, err
 					}
 				}
-				m[args[i].String] = value
+				m.SetMapItem(args[i].String, value)
+				continue
+			}
+
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("Missing value for key `%s'", args[i].Stringify())
+			}
+			key, value := args[i], args[i+1]
+			i++
+			for _, arg := range []*token.TaToken{key, value} {
+				if arg.IsBlock() {
+					if err := interp.Evaluate(arg); err != nil {
+						return nil, err
+					}
+				}
+			}
+			k, err := mapKey(key)
+			if err != nil {
+				return nil, err
+			}
+			m.SetMapItem(k, value)
+		}
+		return m, nil
+	},
+}
+
+var FromEntries = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "fromEntries",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.ListOf(token.List),
+			token.String,
+		},
+		ArgumentNames:    []string{"entries", "duplicates"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewString("last")},
+		Returns:          token.Map,
+		Description:      "Create a map from a list of key value pairs, every pair is a list containing the key and the value. Duplicate keys are handled by the duplicates policy: last keeps the last value, first keeps the first value and error fails",
+		Example: `
+(fromEntries (. Pairs))                                          ; returns {A:1, B:3} with the binding "$Pairs" containing [["A", 1], ["B", 2], ["B", 3]]
+(fromEntries (. Pairs) first)                                    ; returns {A:1, B:2} with the binding "$Pairs" containing [["A", 1], ["B", 2], ["B", 3]]
+(fromEntries (. Pairs) error)                                    ; fails with the binding "$Pairs" containing [["A", 1], ["B", 2], ["B", 3]]
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		policy := strings.ToLower(args[1].String)
+		if policy != "last" && policy != "first" && policy != "error" {
+			return nil, fmt.Errorf("Unknown duplicates policy `%s', expected last, first or error", args[1].String)
+		}
+		m := token.NewOrderedMap(nil, nil)
+		for _, entry := range args[0].Children {
+			if len(entry.Children) != 2 {
+				return nil, fmt.Errorf("Invalid entry `%s', expected a list containing a key and a value", entry.Stringify())
+			}
+			key, err := mapKey(entry.Children[0])
+			if err != nil {
+				return nil, err
+			}
+			if m.HasMapItem(key) {
+				if policy == "first" {
+					continue
+				} else if policy == "error" {
+					return nil, fmt.Errorf("Duplicate key `%s'", key)
+				}
 			}
+			m.SetMapItem(key, entry.Children[1])
 		}
-		return token.NewMap(m), nil
+		return m, nil
+	},
+}
+
+// mapKey converts an atom to a map key, strings are used as they are and other atoms are written like they are printed
+func mapKey(key *token.TaToken) (string, error) {
+	if key.IsNull() || key.Kind&token.Atom == 0 {
+		return "", fmt.Errorf("Cannot use `%s' as a key, expected a value of kind Atom got %s", key.Stringify(), key.Kind.String())
+	}
+	if key.IsString() {
+		return key.String, nil
+	}
+	return key.Stringify(), nil
+}
+
+var Record = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "record",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String, // record type
+			token.Map,
+		},
+		Returns:     token.Map,
+		Description: "Attach a map to a registered record type, fails if the map does not have the fields of the record type",
+		Example: `
+(record CartItem (kv (Name "Shoe") (Price 20)))                  ; returns a Map with the keys Name and Price attached to CartItem
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		record := interp.RecordType(args[0].String)
+		if record == nil {
+			return nil, fmt.Errorf("Unknown record type `%s'", args[0].String)
+		}
+		if !record.Matches(args[1]) {
+			return nil, fmt.Errorf("`%s' does not match record type `%s'", args[1].Stringify(), record.String())
+		}
+		var m token.TaToken
+		token.Copy(&m, args[1])
+		m.Record = record.Name
+		return &m, nil
+	},
+}
+
+var Keys = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "keys",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+		},
+		Returns:     token.ListOf(token.String),
+		Description: "Returns the keys of a map in the order of the map",
+		Example: `
+(keys (kv (Name "Shoe") (Price 20)))                             ; returns a list containing "Name" and "Price"
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		keys := make([]*token.TaToken, len(args[0].Keys))
+		for i, key := range args[0].Keys {
+			keys[i] = token.NewString(key)
+		}
+		return token.NewList(keys...), nil
+	},
+}
+
+var Values = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "values",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+		},
+		Returns:     token.List,
+		Description: "Returns the values of a map in the order of the map",
+		Example: `
+(values (kv (Name "Shoe") (Price 20)))                           ; returns a list containing "Shoe" and 20
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewList(args[0].Children...), nil
+	},
+}
+
+var Entries = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "entries",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+		},
+		Returns:     token.ListOf(token.List),
+		Description: "Returns the key value pairs of a map as lists in the order of the map",
+		Example: `
+(entries (kv (Name "Shoe") (Price 20)))                          ; returns [["Name", "Shoe"], ["Price", 20]]
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		entries := make([]*token.TaToken, len(args[0].Keys))
+		for i, key := range args[0].Keys {
+			entries[i] = token.NewList(token.NewString(key), args[0].Children[i])
+		}
+		return token.NewList(entries...), nil
+	},
+}
+
+var Get = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "get",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+		},
+		Returns:     token.Atom | token.Collection,
+		Description: "Returns the value of a key in a map or null if the map does not contain the key",
+		Example: `
+(get (kv (Name "Shoe") (Price 20)) Name)                         ; returns "Shoe"
+(get (kv (Name "Shoe") (Price 20)) Color)                        ; returns null
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return args[0].MapItem(args[1].String), nil
+	},
+}
+
+var GetWithDefault = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "get",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Atom | token.Collection,
+		Description: "Returns the value of a key in a map or the default value if the map does not contain the key",
+		Example: `
+(get (kv (Name "Shoe") (Price 20)) Color "Black")                ; returns "Black"
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if !args[0].HasMapItem(args[1].String) {
+			return args[2], nil
+		}
+		return args[0].MapItem(args[1].String), nil
+	},
+}
+
+var HasKey = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "hasKey",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if a map contains the key, keys with a null value are contained",
+		Example: `
+(hasKey (kv (Name "Shoe") (Price 20)) Name)                      ; returns true
+(hasKey (kv (Name "Shoe") (Price 20)) Color)                     ; returns false
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewBool(args[0].HasMapItem(args[1].String)), nil
+	},
+}
+
+var Merge = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "merge",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Map,
+			token.Map,
+			token.Map,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map containing the keys of all maps, the values of later maps replace the values of earlier maps",
+		Example: `
+(merge (kv (Name "Shoe") (Price 20)) (kv (Price 15)))            ; returns {Name:"Shoe", Price:15}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		result := copyMap(args[0])
+		for _, m := range args[1:] {
+			for i, key := range m.Keys {
+				result.SetMapItem(key, m.Children[i])
+			}
+		}
+		return result, nil
+	},
+}
+
+var MergeDeep = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "mergeDeep",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Map,
+			token.Map,
+			token.Map,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map containing the keys of all maps, maps inside the maps are merged as well, other values of later maps replace the values of earlier maps",
+		Example: `
+(mergeDeep (kv (Item (kv (Name "Shoe") (Price 20)))) (kv (Item (kv (Price 15))))) ; returns {Item:{Name:"Shoe", Price:15}}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		result := args[0]
+		for _, m := range args[1:] {
+			result = mergeDeep(result, m)
+		}
+		return result, nil
+	},
+}
+
+var Assoc = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "assoc",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map with the key set to the value, the input map is not changed",
+		Example: `
+(assoc (kv (Name "Shoe")) Price 20)                              ; returns {Name:"Shoe", Price:20}
+`,
 	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		result := copyMap(args[0])
+		result.SetMapItem(args[1].String, args[2])
+		return result, nil
+	},
+}
+
+var Dissoc = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "dissoc",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map without the keys, the input map is not changed",
+		Example: `
+(dissoc (kv (Name "Shoe") (Price 20) (Color "Black")) Price Color) ; returns {Name:"Shoe"}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		result := copyMap(args[0])
+		for _, key := range args[1:] {
+			result.RemoveMapItem(key.String)
+		}
+		return result, nil
+	},
+}
+
+var SelectKeys = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "selectKeys",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map that only contains the given keys, keys the map does not contain are ignored",
+		Example: `
+(selectKeys (kv (Name "Shoe") (Price 20) (Color "Black")) Name Color) ; returns {Name:"Shoe", Color:"Black"}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		selected := make(map[string]bool, len(args)-1)
+		for _, key := range args[1:] {
+			selected[key.String] = true
+		}
+		var keys []string
+		var values []*token.TaToken
+		for i, key := range args[0].Keys {
+			if selected[key] {
+				keys = append(keys, key)
+				values = append(values, args[0].Children[i])
+			}
+		}
+		return token.NewOrderedMap(keys, values), nil
+	},
+}
+
+var MapValues = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "mapValues",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map with the same keys by evaluating the given block for each value in the input map",
+		Example: `
+(mapValues (kv (Shoe 20) (Shirt 10)) Price (* (. Price) 2))      ; returns {Shoe:40, Shirt:20}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return MapValuesWithKey.Func(interp, args[0], token.NewString(""), args[1], args[2])
+	},
+}
+
+var MapValuesWithKey = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "mapValues",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map with the same keys by evaluating the given block for each key and value in the input map",
+		Example: `
+(mapValues (kv (Shoe 20) (Shirt 10)) Name Price (+ (. Name) ": " (toString (. Price)))) ; returns {Shoe:"Shoe: 20", Shirt:"Shirt: 10"}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		m := args[0]
+		values := make([]*token.TaToken, len(m.Keys))
+		scope := interp.NewScope()
+		for i, key := range m.Keys {
+			setEntry(scope, args[1].String, key, args[2].String, m.Children[i])
+			result, err := evaluateBlock(scope, args[3])
+			if err != nil {
+				return nil, err
+			}
+			values[i] = result
+		}
+		return token.NewOrderedMap(m.Keys, values), nil
+	},
+}
+
+var FilterMap = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "filterMap",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map containing the keys of the input map whose value the block evaluates to true for",
+		Example: `
+(filterMap (kv (Shoe 20) (Shirt 10)) Price (> (. Price) 15))     ; returns {Shoe:20}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return FilterMapWithKey.Func(interp, args[0], token.NewString(""), args[1], args[2])
+	},
+}
+
+var FilterMapWithKey = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "filterMap",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.String,
+			token.String,
+			token.Token,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map containing the keys and values of the input map the block evaluates to true for",
+		Example: `
+(filterMap (kv (Shoe 20) (Shirt 10)) Name Price (= (. Name) "Shirt")) ; returns {Shirt:10}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		m := args[0]
+		var keys []string
+		var values []*token.TaToken
+		scope := interp.NewScope()
+		for i, key := range m.Keys {
+			setEntry(scope, args[1].String, key, args[2].String, m.Children[i])
+			result, err := evaluateBlock(scope, args[3])
+			if err != nil {
+				return nil, err
+			}
+			if !result.IsBool() {
+				return nil, fmt.Errorf("Invalid type in block evaluation, expected type: Boolean got %s", result.Kind.String())
+			}
+			if result.Bool {
+				keys = append(keys, key)
+				values = append(values, m.Children[i])
+			}
+		}
+		return token.NewOrderedMap(keys, values), nil
+	},
+}
+
+var GetIn = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "getIn",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.ListOf(token.String | token.Decimal),
+		},
+		Returns:     token.Atom | token.Collection,
+		Description: "Returns the value at the path in nested maps and lists or null if the path does not exist, decimals in the path are list indices",
+		Example: `
+(getIn (. Cart) (list Customer Name))                            ; returns the name of the customer in the cart
+(getIn (. Cart) (list Items 0 Price))                            ; returns the price of the first item in the cart
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		value, _ := getIn(args[0], args[1].Children)
+		return value, nil
+	},
+}
+
+var GetInWithDefault = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "getIn",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.ListOf(token.String | token.Decimal),
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Atom | token.Collection,
+		Description: "Returns the value at the path in nested maps and lists or the default value if the path does not exist, decimals in the path are list indices",
+		Example: `
+(getIn (. Cart) (list Customer Email) "unknown")                 ; returns "unknown" if the customer has no email
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if value, ok := getIn(args[0], args[1].Children); ok {
+			return value, nil
+		}
+		return args[2], nil
+	},
+}
+
+var SetIn = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "setIn",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Map,
+			token.ListOf(token.String),
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Map,
+		Description: "Create a new map with the value set at the path in nested maps, missing maps on the path are created and the input map is not changed",
+		Example: `
+(setIn (kv (Customer (kv (Name "Alex")))) (list Customer Age) 42) ; returns {Customer:{Name:"Alex", Age:42}}
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if len(args[1].Children) == 0 {
+			return nil, errors.New("The path must not be empty")
+		}
+		return setIn(args[0], args[1].Children, args[2])
+	},
+}
+
+// copyMap returns a new map with the keys and values of m that can be changed without changing m
+func copyMap(m *token.TaToken) *token.TaToken {
+	return token.NewOrderedMap(m.Keys, m.Children).Mutable()
+}
+
+func mergeDeep(a, b *token.TaToken) *token.TaToken {
+	result := copyMap(a)
+	for i, key := range b.Keys {
+		value := b.Children[i]
+		if current := result.MapItem(key); current.IsMap() && value.IsMap() {
+			value = mergeDeep(current, value)
+		}
+		result.SetMapItem(key, value)
+	}
+	return result.Freeze()
+}
+
+// getIn follows the path through maps and lists, the boolean is false if the path does not exist
+func getIn(value *token.TaToken, path []*token.TaToken) (*token.TaToken, bool) {
+	for _, segment := range path {
+		switch {
+		case value.IsMap():
+			if !value.HasMapItem(segment.String) {
+				return token.NewNull(), false
+			}
+			value = value.MapItem(segment.String)
+		case value.IsList() && segment.IsDecimal():
+			i, err := segment.Decimal.Int64()
+			if err != nil || i < 0 || i >= int64(len(value.Children)) {
+				return token.NewNull(), false
+			}
+			value = value.Children[i]
+		default:
+			return token.NewNull(), false
+		}
+	}
+	return value, true
+}
+
+func setIn(m *token.TaToken, path []*token.TaToken, value *token.TaToken) (*token.TaToken, error) {
+	key := path[0].String
+	if len(path) > 1 {
+		child := m.MapItem(key)
+		if child.IsNull() {
+			child = token.NewMap(map[string]*token.TaToken{})
+		} else if !child.IsMap() {
+			return nil, fmt.Errorf("Cannot set `%s', `%s' is not a map", path[len(path)-1].String, key)
+		}
+		var err error
+		if value, err = setIn(child, path[1:], value); err != nil {
+			return nil, err
+		}
+	}
+	result := copyMap(m)
+	result.SetMapItem(key, value)
+	return result.Freeze(), nil
+}
+
+// setEntry binds the key and the value of a map entry in the scope, an empty key name does not bind the key
+func setEntry(scope *interpreter.Interpreter, keyName, key, valueName string, value *token.TaToken) {
+	if len(keyName) > 0 {
+		scope.Set(keyName, token.NewString(key))
+	}
+	scope.Set(valueName, value)
+}
+
+// evaluateBlock evaluates a copy of the block in the scope
+func evaluateBlock(scope *interpreter.Interpreter, block *token.TaToken) (*token.TaToken, error) {
+	var result token.TaToken
+	token.Copy(&result, block)
+	if err := scope.Evaluate(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
@@ -5,5 +5,25 @@ import "github.com/talon-one/talang/interpreter"
 func AllOperations() []interpreter.TaFunction {
 	return []interpreter.TaFunction{
 		KV,
+		FromEntries,
+		Record,
+		Keys,
+		Values,
+		Entries,
+		Get,
+		GetWithDefault,
+		HasKey,
+		Merge,
+		MergeDeep,
+		Assoc,
+		Dissoc,
+		SelectKeys,
+		MapValues,
+		MapValuesWithKey,
+		FilterMap,
+		FilterMapWithKey,
+		GetIn,
+		GetInWithDefault,
+		SetIn,
 	}
 }
@@ -3,6 +3,7 @@ package mapping_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
 	helpers "github.com/talon-one/talang/testhelpers"
 	"github.com/talon-one/talang/token"
 )
@@ -53,3 +54,448 @@ func TestKV(t *testing.T) {
 		},
 	)
 }
+
+func TestRecord(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	interp.MustRegisterRecordType(token.RecordType{
+		Name: "CartItem",
+		Fields: map[string]token.Kind{
+			"Name":  token.String,
+			"Price": token.Decimal,
+		},
+	})
+
+	result := interp.MustLexAndEvaluate(`record CartItem (kv (Name Shoe) (Price 20))`)
+	require.Equal(t, "CartItem", result.Record)
+	require.Equal(t, "20", result.MapItem("Price").String)
+
+	_, err := interp.LexAndEvaluate(`record CartItem (kv (Name Shoe))`)
+	require.Error(t, err)
+	_, err = interp.LexAndEvaluate(`record Parcel (kv (Name Shoe))`)
+	require.Error(t, err)
+}
+
+func TestKVComputedKeys(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`kv (+ "sku_" (. Id)) 5`,
+			token.NewMap(map[string]*token.TaToken{
+				"Id": token.NewString("1"),
+			}),
+			token.NewMap(map[string]*token.TaToken{
+				"sku_1": token.NewDecimalFromInt(5),
+			}),
+		},
+		helpers.Test{
+			`kv (concat "id_" (toString (. Id))) (. Id) (Name "Shoe")`,
+			token.NewMap(map[string]*token.TaToken{
+				"Id": token.NewDecimalFromInt(1),
+			}),
+			token.NewOrderedMap([]string{"id_1", "Name"}, []*token.TaToken{
+				token.NewDecimalFromInt(1),
+				token.NewString("Shoe"),
+			}),
+		},
+		helpers.Test{
+			`kv (. Id) true`,
+			token.NewMap(map[string]*token.TaToken{
+				"Id": token.NewDecimalFromInt(1),
+			}),
+			token.NewMap(map[string]*token.TaToken{
+				"1": token.NewBool(true),
+			}),
+		},
+		helpers.Test{
+			`kv (. Id) true`,
+			token.NewMap(map[string]*token.TaToken{
+				"Id": token.NewList(),
+			}),
+			helpers.Error{},
+		},
+		helpers.Test{
+			// function names are case insensitive, like calls
+			`kv (Concat "a" "b") 5`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"ab": token.NewDecimalFromInt(5),
+			}),
+		},
+		helpers.Test{
+			// a block with one value is always a key and its value, even if the key is a function name
+			`kv (Item 1) (List 2) (count 5) (sum 6)`,
+			nil,
+			token.NewOrderedMap([]string{"Item", "List", "count", "sum"}, []*token.TaToken{
+				token.NewDecimalFromInt(1),
+				token.NewDecimalFromInt(2),
+				token.NewDecimalFromInt(5),
+				token.NewDecimalFromInt(6),
+			}),
+		},
+		helpers.Test{
+			`kv (+ "sku_" "1")`,
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			`kv (Key1)`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestKVLiteral(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`kv Name "Shoe" Price (+ 10 10) count 2`,
+			nil,
+			token.NewOrderedMap([]string{"Name", "Price", "count"}, []*token.TaToken{
+				token.NewString("Shoe"),
+				token.NewDecimalFromInt(20),
+				token.NewDecimalFromInt(2),
+			}),
+		},
+		helpers.Test{
+			`kv Name "Shoe" Name "Shirt"`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Name": token.NewString("Shirt"),
+			}),
+		},
+		helpers.Test{
+			`kv Name "Shoe" Price`,
+			nil,
+			helpers.Error{},
+		},
+		// words that are durations keep their text as keys
+		helpers.Test{
+			`kv 1m "monthly" P1D "daily"`,
+			nil,
+			token.NewOrderedMap([]string{"1m", "P1D"}, []*token.TaToken{
+				token.NewString("monthly"),
+				token.NewString("daily"),
+			}),
+		},
+	)
+}
+
+func TestFromEntries(t *testing.T) {
+	pairs := token.NewMap(map[string]*token.TaToken{
+		"Pairs": token.NewList(
+			token.NewList(token.NewString("A"), token.NewDecimalFromInt(1)),
+			token.NewList(token.NewString("B"), token.NewDecimalFromInt(2)),
+			token.NewList(token.NewString("B"), token.NewDecimalFromInt(3)),
+		),
+	})
+	helpers.RunTests(t,
+		helpers.Test{
+			`fromEntries (. Pairs)`,
+			pairs,
+			token.NewOrderedMap([]string{"A", "B"}, []*token.TaToken{
+				token.NewDecimalFromInt(1),
+				token.NewDecimalFromInt(3),
+			}),
+		},
+		helpers.Test{
+			`fromEntries (. Pairs) last`,
+			pairs,
+			token.NewOrderedMap([]string{"A", "B"}, []*token.TaToken{
+				token.NewDecimalFromInt(1),
+				token.NewDecimalFromInt(3),
+			}),
+		},
+		helpers.Test{
+			`fromEntries (. Pairs) first`,
+			pairs,
+			token.NewOrderedMap([]string{"A", "B"}, []*token.TaToken{
+				token.NewDecimalFromInt(1),
+				token.NewDecimalFromInt(2),
+			}),
+		},
+		helpers.Test{
+			`fromEntries (. Pairs) error`,
+			pairs,
+			helpers.Error{},
+		},
+		helpers.Test{
+			`fromEntries (. Pairs) newest`,
+			pairs,
+			helpers.Error{},
+		},
+		helpers.Test{
+			`fromEntries (entries (kv (A 1) (B 2)))`,
+			nil,
+			token.NewOrderedMap([]string{"A", "B"}, []*token.TaToken{
+				token.NewDecimalFromInt(1),
+				token.NewDecimalFromInt(2),
+			}),
+		},
+		helpers.Test{
+			`fromEntries (zip (list 1 2) (list "a" "b"))`,
+			nil,
+			token.NewOrderedMap([]string{"1", "2"}, []*token.TaToken{
+				token.NewString("a"),
+				token.NewString("b"),
+			}),
+		},
+		helpers.Test{
+			`fromEntries (list)`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{}),
+		},
+		helpers.Test{
+			`fromEntries (chunk (list 1 2 3) 3)`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestKVOrder(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	require.Equal(t, `{Key2:"b", Key1:"a", Key3:"c"}`, interp.MustLexAndEvaluate(`kv (Key2 b) (Key1 a) (Key3 c)`).Stringify())
+}
+
+func cart() *token.TaToken {
+	return token.NewMap(map[string]*token.TaToken{
+		"Cart": token.NewOrderedMap([]string{"Customer", "Items"}, []*token.TaToken{
+			token.NewOrderedMap([]string{"Name", "Age"}, []*token.TaToken{
+				token.NewString("Alex"),
+				token.NewDecimalFromInt(42),
+			}),
+			token.NewList(
+				token.NewOrderedMap([]string{"Name", "Price"}, []*token.TaToken{
+					token.NewString("Shoe"),
+					token.NewDecimalFromInt(20),
+				}),
+			),
+		}),
+	})
+}
+
+func TestKeysValuesEntries(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`keys (kv (Name "Shoe") (Price 20))`,
+			nil,
+			token.NewList(token.NewString("Name"), token.NewString("Price")),
+		}, helpers.Test{
+			`keys (kv)`,
+			nil,
+			token.NewList(),
+		}, helpers.Test{
+			`values (kv (Name "Shoe") (Price 20))`,
+			nil,
+			token.NewList(token.NewString("Shoe"), token.NewDecimalFromInt(20)),
+		}, helpers.Test{
+			`entries (kv (Name "Shoe") (Price 20))`,
+			nil,
+			token.NewList(
+				token.NewList(token.NewString("Name"), token.NewString("Shoe")),
+				token.NewList(token.NewString("Price"), token.NewDecimalFromInt(20)),
+			),
+		},
+	)
+}
+
+func TestGet(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`get (kv (Name "Shoe") (Price 20)) Name`,
+			nil,
+			token.NewString("Shoe"),
+		}, helpers.Test{
+			`get (kv (Name "Shoe") (Price 20)) Color`,
+			nil,
+			token.NewNull(),
+		}, helpers.Test{
+			`get (kv (Name "Shoe") (Price 20)) Color "Black"`,
+			nil,
+			token.NewString("Black"),
+		}, helpers.Test{
+			`get (kv (Name "Shoe") (Price 20)) Price 0`,
+			nil,
+			token.NewDecimalFromInt(20),
+		}, helpers.Test{
+			`hasKey (kv (Name "Shoe") (Price 20)) Name`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`hasKey (kv (Name "Shoe") (Price 20)) Color`,
+			nil,
+			token.NewBool(false),
+		},
+	)
+}
+
+func TestMerge(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`merge (kv (Name "Shoe") (Price 20)) (kv (Price 15) (Color "Black"))`,
+			nil,
+			token.NewOrderedMap([]string{"Name", "Price", "Color"}, []*token.TaToken{
+				token.NewString("Shoe"),
+				token.NewDecimalFromInt(15),
+				token.NewString("Black"),
+			}),
+		}, helpers.Test{
+			`merge (kv (Item (kv (Name "Shoe") (Price 20)))) (kv (Item (kv (Price 15))))`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Item": token.NewMap(map[string]*token.TaToken{
+					"Price": token.NewDecimalFromInt(15),
+				}),
+			}),
+		}, helpers.Test{
+			`mergeDeep (kv (Item (kv (Name "Shoe") (Price 20)))) (kv (Item (kv (Price 15)))) (kv (Count 1))`,
+			nil,
+			token.NewOrderedMap([]string{"Item", "Count"}, []*token.TaToken{
+				token.NewOrderedMap([]string{"Name", "Price"}, []*token.TaToken{
+					token.NewString("Shoe"),
+					token.NewDecimalFromInt(15),
+				}),
+				token.NewDecimalFromInt(1),
+			}),
+		}, helpers.Test{
+			`mergeDeep (kv (Item (kv (Name "Shoe")))) (kv (Item "Shoe"))`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Item": token.NewString("Shoe"),
+			}),
+		},
+	)
+}
+
+func TestAssocDissoc(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`assoc (kv (Name "Shoe")) Price 20`,
+			nil,
+			token.NewOrderedMap([]string{"Name", "Price"}, []*token.TaToken{
+				token.NewString("Shoe"),
+				token.NewDecimalFromInt(20),
+			}),
+		}, helpers.Test{
+			`assoc (kv (Name "Shoe")) Name "Shirt"`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Name": token.NewString("Shirt"),
+			}),
+		}, helpers.Test{
+			`dissoc (kv (Name "Shoe") (Price 20) (Color "Black")) Price Color Size`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Name": token.NewString("Shoe"),
+			}),
+		}, helpers.Test{
+			`selectKeys (kv (Name "Shoe") (Price 20) (Color "Black")) Color Name Size`,
+			nil,
+			token.NewOrderedMap([]string{"Name", "Color"}, []*token.TaToken{
+				token.NewString("Shoe"),
+				token.NewString("Black"),
+			}),
+		},
+	)
+}
+
+func TestAssocDoesNotChangeTheInput(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	interp.Binding = cart()
+	require.Equal(t, `{Name:"Alex", Age:42, Email:"alex@example.com"}`, interp.MustLexAndEvaluate(`assoc (. Cart Customer) Email "alex@example.com"`).Stringify())
+	require.Equal(t, `{Name:"Alex"}`, interp.MustLexAndEvaluate(`dissoc (. Cart Customer) Age`).Stringify())
+	require.Equal(t, `{Customer:{Name:"Alex", Age:43}}`, interp.MustLexAndEvaluate(`selectKeys (setIn (. Cart) (list Customer Age) 43) Customer`).Stringify())
+	require.Equal(t, `{Name:"Alex", Age:42}`, interp.MustLexAndEvaluate(`. Cart Customer`).Stringify())
+}
+
+func TestMapValues(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`mapValues (kv (Shoe 20) (Shirt 10)) Price (* (. Price) 2)`,
+			nil,
+			token.NewOrderedMap([]string{"Shoe", "Shirt"}, []*token.TaToken{
+				token.NewDecimalFromInt(40),
+				token.NewDecimalFromInt(20),
+			}),
+		}, helpers.Test{
+			`mapValues (kv (Shoe 20) (Shirt 10)) Name Price (+ (. Name) "!")`,
+			nil,
+			token.NewOrderedMap([]string{"Shoe", "Shirt"}, []*token.TaToken{
+				token.NewString("Shoe!"),
+				token.NewString("Shirt!"),
+			}),
+		}, helpers.Test{
+			`filterMap (kv (Shoe 20) (Shirt 10)) Price (> (. Price) 15)`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Shoe": token.NewDecimalFromInt(20),
+			}),
+		}, helpers.Test{
+			`filterMap (kv (Shoe 20) (Shirt 10)) Name Price (= (. Name) "Shirt")`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Shirt": token.NewDecimalFromInt(10),
+			}),
+		}, helpers.Test{
+			`filterMap (kv (Shoe 20) (Shirt 10)) Price (. Price)`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestGetInSetIn(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`getIn (. Cart) (list Customer Name)`,
+			cart(),
+			token.NewString("Alex"),
+		}, helpers.Test{
+			`getIn (. Cart) (list Items 0 Price)`,
+			cart(),
+			token.NewDecimalFromInt(20),
+		}, helpers.Test{
+			`getIn (. Cart) (list Items 1 Price)`,
+			cart(),
+			token.NewNull(),
+		}, helpers.Test{
+			`getIn (. Cart) (list Customer Email)`,
+			cart(),
+			token.NewNull(),
+		}, helpers.Test{
+			`getIn (. Cart) (list Customer Email) "unknown"`,
+			cart(),
+			token.NewString("unknown"),
+		}, helpers.Test{
+			`getIn (. Cart) (list Customer Name Length) "unknown"`,
+			cart(),
+			token.NewString("unknown"),
+		}, helpers.Test{
+			`setIn (kv (Customer (kv (Name "Alex")))) (list Customer Age) 42`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Customer": token.NewOrderedMap([]string{"Name", "Age"}, []*token.TaToken{
+					token.NewString("Alex"),
+					token.NewDecimalFromInt(42),
+				}),
+			}),
+		}, helpers.Test{
+			`setIn (kv) (list Customer Address City) "Berlin"`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{
+				"Customer": token.NewMap(map[string]*token.TaToken{
+					"Address": token.NewMap(map[string]*token.TaToken{
+						"City": token.NewString("Berlin"),
+					}),
+				}),
+			}),
+		}, helpers.Test{
+			`setIn (kv (Customer "Alex")) (list Customer Age) 42`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`setIn (kv) (list) 42`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
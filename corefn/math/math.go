@@ -2,6 +2,9 @@
 package math
 
 import (
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/talon-one/decimal"
 	"github.com/talon-one/talang/interpreter"
 	"github.com/talon-one/talang/token"
@@ -203,3 +206,286 @@ var Ceil = interpreter.TaFunction{
 		return token.NewDecimal(args[0].Decimal), nil
 	},
 }
+
+var Money = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "money",
+		Arguments: []token.Kind{
+			token.Decimal,
+			token.String,
+		},
+		Returns:     token.Money,
+		Description: "Create a money value from an amount and an ISO 4217 currency code",
+		Example: `
+(money 12.5 EUR)                                                 ; returns 12.50 EUR
+(money 100 jpy)                                                  ; returns 100 JPY
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if _, ok := token.CurrencyMinorUnits(args[1].String); !ok {
+			return nil, errors.Errorf("Unknown currency `%s'", args[1].String)
+		}
+		return token.NewMoney(decimal.NewFromDecimal(args[0].Decimal), args[1].String), nil
+	},
+}
+
+var AddMoney = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "+",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Money,
+			token.Money,
+			token.Money,
+		},
+		Returns:     token.Money,
+		Description: "Adds the money arguments, all arguments must have the same currency",
+		Example: `
+(+ (money 1.5 EUR) (money 2.25 EUR))                             ; returns 3.75 EUR
+(+ (money 1.5 EUR) (money 2.25 USD))                             ; fails because the currencies differ
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if err := sameCurrency(args...); err != nil {
+			return nil, err
+		}
+		d := decimal.NewFromDecimal(args[0].Decimal)
+		for i := 1; i < len(args); i++ {
+			d = d.Add(args[i].Decimal)
+		}
+		return token.NewMoney(d, args[0].Currency), nil
+	},
+}
+
+var SubMoney = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "-",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Money,
+			token.Money,
+			token.Money,
+		},
+		Returns:     token.Money,
+		Description: "Subtracts the money arguments, all arguments must have the same currency",
+		Example: `
+(- (money 5 EUR) (money 2.25 EUR))                               ; returns 2.75 EUR
+(- (money 5 EUR) (money 2.25 USD))                               ; fails because the currencies differ
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if err := sameCurrency(args...); err != nil {
+			return nil, err
+		}
+		d := decimal.NewFromDecimal(args[0].Decimal)
+		for i := 1; i < len(args); i++ {
+			d = d.Sub(args[i].Decimal)
+		}
+		return token.NewMoney(d, args[0].Currency), nil
+	},
+}
+
+var MulMoney = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "*",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Money,
+			token.Decimal,
+			token.Decimal,
+		},
+		Returns:     token.Money,
+		Description: "Multiplies the money argument with the decimal arguments, the result is rounded to the minor unit of the currency using banker's rounding",
+		Example: `
+(* (money 10 EUR) 0.15)                                          ; returns 1.50 EUR
+(* (money 0.25 EUR) 0.5)                                         ; returns 0.12 EUR
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		d := decimal.NewFromDecimal(args[0].Decimal)
+		for i := 1; i < len(args); i++ {
+			d = d.Mul(args[i].Decimal)
+		}
+		return roundMoney(d, args[0].Currency), nil
+	},
+}
+
+var DivMoney = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "/",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Money,
+			token.Decimal,
+			token.Decimal,
+		},
+		Returns:     token.Money,
+		Description: "Divides the money argument by the decimal arguments, the result is rounded to the minor unit of the currency using banker's rounding",
+		Example: `
+(/ (money 10 EUR) 4)                                             ; returns 2.50 EUR
+(/ (money 10 EUR) 3)                                             ; returns 3.33 EUR
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		d := decimal.NewFromDecimal(args[0].Decimal)
+		for i := 1; i < len(args); i++ {
+			if args[i].Decimal.Equals(decimal.Zero()) {
+				return nil, errors.New("Division by zero")
+			}
+			d = d.Div(args[i].Decimal)
+		}
+		return roundMoney(d, args[0].Currency), nil
+	},
+}
+
+var RoundMoney = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "round",
+		Arguments: []token.Kind{
+			token.Money,
+		},
+		Returns:     token.Money,
+		Description: "Round the money argument to the minor unit of its currency using banker's rounding (round half to even)",
+		Example: `
+(round (money 1.125 EUR))                                        ; returns 1.12 EUR
+(round (money 1.135 EUR))                                        ; returns 1.14 EUR
+(round (money 2.5 JPY))                                          ; returns 2 JPY
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return roundMoney(args[0].Decimal, args[0].Currency), nil
+	},
+}
+
+var Allocate = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "allocate",
+		Arguments: []token.Kind{
+			token.Money,
+			token.Decimal,
+		},
+		Returns:     token.List,
+		Description: "Split the money argument into n parts (at most 10000), the remaining minor units are distributed over the first parts so the sum of the parts equals the (rounded) amount",
+		Example: `
+(allocate (money 10 EUR) 3)                                      ; returns [3.34 EUR, 3.33 EUR, 3.33 EUR]
+(allocate (money 0.05 EUR) 2)                                    ; returns [0.03 EUR, 0.02 EUR]
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		n, err := args[1].Decimal.Int64()
+		if err != nil || n <= 0 || !decimal.NewFromInt64(n).Equals(args[1].Decimal) {
+			return nil, errors.Errorf("Invalid number of parts `%s'", args[1].String)
+		}
+		if n > maxAllocateParts {
+			return nil, errors.Errorf("Cannot allocate more than %d parts", maxAllocateParts)
+		}
+		currency := args[0].Currency
+		scale := minorUnitScale(currency)
+
+		// work on the minor units (e.g. cents)
+		total := roundMoney(args[0].Decimal, currency).Decimal
+		total = decimal.Mul(total, scale)
+		parts := decimal.NewFromInt64(n)
+		base := decimal.Floor(decimal.Div(total, parts))
+		remainder, err := decimal.Sub(total, decimal.Mul(base, parts)).Int64()
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]*token.TaToken, n)
+		for i := int64(0); i < n; i++ {
+			part := decimal.NewFromDecimal(base)
+			if i < remainder {
+				part = part.Add(decimal.NewFromInt(1))
+			}
+			result[i] = token.NewMoney(part.Div(scale), currency)
+		}
+		return token.NewList(result...), nil
+	},
+}
+
+var FormatMoney = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "formatMoney",
+		Arguments: []token.Kind{
+			token.Money,
+		},
+		Returns:     token.String,
+		Description: "Format the money argument rounded to the minor unit of its currency, followed by the currency code",
+		Example: `
+(formatMoney (money 1234.5 EUR))                                 ; returns "1234.50 EUR"
+(formatMoney (money 0.125 USD))                                  ; returns "0.12 USD"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(roundMoney(args[0].Decimal, args[0].Currency).String), nil
+	},
+}
+
+var Amount = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "amount",
+		Arguments: []token.Kind{
+			token.Money,
+		},
+		Returns:     token.Decimal,
+		Description: "Returns the amount of the money argument",
+		Example: `
+(amount (money 12.5 EUR))                                        ; returns 12.5
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDecimal(decimal.NewFromDecimal(args[0].Decimal)), nil
+	},
+}
+
+var Currency = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "currency",
+		Arguments: []token.Kind{
+			token.Money,
+		},
+		Returns:     token.String,
+		Description: "Returns the ISO 4217 currency code of the money argument",
+		Example: `
+(currency (money 12.5 EUR))                                      ; returns "EUR"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(args[0].Currency), nil
+	},
+}
+
+func sameCurrency(args ...*token.TaToken) error {
+	for i := 1; i < len(args); i++ {
+		if args[i].Currency != args[0].Currency {
+			return errors.Errorf("Unable to mix currencies `%s' and `%s'", args[0].Currency, args[i].Currency)
+		}
+	}
+	return nil
+}
+
+// maxAllocateParts is the maximum number of parts allocate creates
+const maxAllocateParts = 10000
+
+// minorUnitScale returns 10^n where n is the number of minor units of the currency
+func minorUnitScale(currency string) decimal.Decimal {
+	units, _ := token.CurrencyMinorUnits(currency)
+	return decimal.MustNewFromString("1" + strings.Repeat("0", units))
+}
+
+// roundMoney rounds the amount to the minor unit of the currency, ties are rounded to the even neighbour
+func roundMoney(amount decimal.Decimal, currency string) *token.TaToken {
+	scale := minorUnitScale(currency)
+	scaled := decimal.Mul(amount, scale)
+	rounded := decimal.Floor(scaled)
+	switch decimal.Sub(scaled, rounded).Cmp(decimal.New(5, 1)) {
+	case 1:
+		rounded = rounded.Add(decimal.NewFromInt(1))
+	case 0:
+		if !decimal.Mod(rounded, decimal.NewFromInt(2)).Equals(decimal.Zero()) {
+			rounded = rounded.Add(decimal.NewFromInt(1))
+		}
+	}
+	return token.NewMoney(rounded.Div(scale), currency)
+}
@@ -11,5 +11,15 @@ func AllOperations() []interpreter.TaFunction {
 		Mod,
 		Floor,
 		Ceil,
+		Money,
+		AddMoney,
+		SubMoney,
+		MulMoney,
+		DivMoney,
+		RoundMoney,
+		Allocate,
+		FormatMoney,
+		Amount,
+		Currency,
 	}
 }
@@ -203,3 +203,187 @@ func TestCeil(t *testing.T) {
 		},
 	)
 }
+
+func TestMoney(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			"money 12.5 EUR",
+			nil,
+			token.NewMoneyFromString("12.5", "EUR"),
+		},
+		helpers.Test{
+			"money 12.5 eur",
+			nil,
+			token.NewMoneyFromString("12.5", "EUR"),
+		},
+		helpers.Test{
+			"money 12.5 XYZ",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"+ (money 1.5 EUR) (money 2.25 EUR) (money 1 EUR)",
+			nil,
+			token.NewMoneyFromString("4.75", "EUR"),
+		},
+		helpers.Test{
+			"+ (money 1.5 EUR) (money 2.25 USD)",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"+ (money 1.5 EUR) 2",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"- (money 5 EUR) (money 2.25 EUR)",
+			nil,
+			token.NewMoneyFromString("2.75", "EUR"),
+		},
+		helpers.Test{
+			"- (money 5 EUR) (money 2.25 USD)",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"* (money 10 EUR) 0.15",
+			nil,
+			token.NewMoneyFromString("1.5", "EUR"),
+		},
+		helpers.Test{
+			"* (money 0.25 EUR) 0.5",
+			nil,
+			token.NewMoneyFromString("0.12", "EUR"),
+		},
+		helpers.Test{
+			"/ (money 10 EUR) 3",
+			nil,
+			token.NewMoneyFromString("3.33", "EUR"),
+		},
+		helpers.Test{
+			"/ (money 10 EUR) 0",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"amount (money 12.5 EUR)",
+			nil,
+			token.NewDecimalFromString("12.5"),
+		},
+		helpers.Test{
+			"currency (money 12.5 usd)",
+			nil,
+			token.NewString("USD"),
+		},
+	)
+}
+
+func TestRoundMoney(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			"round (money 1.125 EUR)",
+			nil,
+			token.NewMoneyFromString("1.12", "EUR"),
+		},
+		helpers.Test{
+			"round (money 1.135 EUR)",
+			nil,
+			token.NewMoneyFromString("1.14", "EUR"),
+		},
+		helpers.Test{
+			"round (money 1.1351 EUR)",
+			nil,
+			token.NewMoneyFromString("1.14", "EUR"),
+		},
+		helpers.Test{
+			"round (money -1.125 EUR)",
+			nil,
+			token.NewMoneyFromString("-1.12", "EUR"),
+		},
+		helpers.Test{
+			"round (money 2.5 JPY)",
+			nil,
+			token.NewMoneyFromString("2", "JPY"),
+		},
+		helpers.Test{
+			"round (money 1.0005 KWD)",
+			nil,
+			token.NewMoneyFromString("1", "KWD"),
+		},
+		helpers.Test{
+			"formatMoney (money 1234.5 EUR)",
+			nil,
+			token.NewString("1234.50 EUR"),
+		},
+		helpers.Test{
+			"formatMoney (money 0.125 USD)",
+			nil,
+			token.NewString("0.12 USD"),
+		},
+		helpers.Test{
+			"formatMoney (money 7 JPY)",
+			nil,
+			token.NewString("7 JPY"),
+		},
+	)
+}
+
+func TestAllocate(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			"allocate (money 10 EUR) 3",
+			nil,
+			token.NewList(
+				token.NewMoneyFromString("3.34", "EUR"),
+				token.NewMoneyFromString("3.33", "EUR"),
+				token.NewMoneyFromString("3.33", "EUR"),
+			),
+		},
+		helpers.Test{
+			"allocate (money 0.05 EUR) 2",
+			nil,
+			token.NewList(
+				token.NewMoneyFromString("0.03", "EUR"),
+				token.NewMoneyFromString("0.02", "EUR"),
+			),
+		},
+		helpers.Test{
+			"allocate (money -0.05 EUR) 2",
+			nil,
+			token.NewList(
+				token.NewMoneyFromString("-0.02", "EUR"),
+				token.NewMoneyFromString("-0.03", "EUR"),
+			),
+		},
+		helpers.Test{
+			"allocate (money 100 JPY) 3",
+			nil,
+			token.NewList(
+				token.NewMoneyFromString("34", "JPY"),
+				token.NewMoneyFromString("33", "JPY"),
+				token.NewMoneyFromString("33", "JPY"),
+			),
+		},
+		helpers.Test{
+			"allocate (money 10 EUR) 0",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"allocate (money 10 EUR) 1.5",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"allocate (money 10 EUR) 1000000000",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"count (allocate (money 10 EUR) 10000)",
+			nil,
+			token.NewDecimalFromInt(10000),
+		},
+	)
+}
@@ -3,7 +3,15 @@
 package misc
 
 import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
 	"github.com/pkg/errors"
+	"github.com/talon-one/decimal"
 
 	"github.com/talon-one/talang/interpreter"
 	"github.com/talon-one/talang/token"
@@ -32,17 +40,266 @@ var ToString = interpreter.TaFunction{
 	CommonSignature: interpreter.CommonSignature{
 		Name: "toString",
 		Arguments: []token.Kind{
-			token.Decimal | token.String | token.Boolean | token.Time,
+			token.Atom | token.Collection,
+			token.Boolean,
 		},
-		Returns:     token.String,
-		Description: "Converts the parameter to a string",
+		ArgumentNames:    []string{"value", "lenient"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewBool(false)},
+		Returns:          token.Optional(token.String),
+		Description:      "Converts the parameter to a string, lists and maps are written like they are printed. Null can only be converted if lenient is true",
 		Example: `
 (toString 1)                                                     ; returns "1"
 (toString true)                                                  ; returns "true"
+(toString (list 1 "A"))                                          ; returns "[1, \"A\"]"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return convert(args, token.String, func(value *token.TaToken, lenient bool) *token.TaToken {
+			switch {
+			case value.IsNull():
+				return nil
+			case value.IsList() || value.IsMap():
+				return token.NewString(value.Stringify())
+			}
+			return token.NewString(value.String)
+		})
+	},
+}
+
+var ToDecimal = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "toDecimal",
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Boolean,
+		},
+		ArgumentNames:    []string{"value", "lenient"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewBool(false)},
+		Returns:          token.Optional(token.Decimal),
+		Description:      "Converts strings and the amount of money to a decimal. If lenient is true whitespace and thousands separators (commas between groups of three digits) are ignored, booleans are converted to 1 and 0, times to unix seconds, durations to seconds and null is returned if the value cannot be converted",
+		Example: `
+(toDecimal "42")                                                 ; returns 42
+(toDecimal " 1,000.5 " true)                                     ; returns 1000.5
+(toDecimal "abc" true)                                           ; returns null
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return convert(args, token.Decimal, func(value *token.TaToken, lenient bool) *token.TaToken {
+			switch {
+			case value.IsString():
+				if !lenient {
+					return literalOf(value.String, token.Decimal)
+				}
+				return lenientDecimal(value.String)
+			case value.IsMoney():
+				return token.NewDecimal(decimal.NewFromDecimal(value.Decimal))
+			case !lenient:
+				return nil
+			case value.IsBool():
+				if value.Bool {
+					return token.NewDecimalFromInt(1)
+				}
+				return token.NewDecimalFromInt(0)
+			case value.IsTime():
+				return token.NewDecimalFromInt(value.Time.Unix())
+			case value.IsDuration():
+				return token.NewDecimal(decimal.Div(decimal.NewFromInt64(int64(value.Duration)), decimal.NewFromInt64(int64(time.Second))))
+			}
+			return nil
+		})
+	},
+}
+
+// lenientDecimal parses a decimal that can contain whitespace around it, underscores and commas as thousands separators.
+// Commas are only accepted between groups of three digits, so a decimal comma like 12,50 returns nil instead of 1250
+func lenientDecimal(s string) *token.TaToken {
+	s = strings.Replace(strings.TrimSpace(s), "_", "", -1)
+	if strings.Contains(s, ",") {
+		integer := strings.TrimLeft(s, "+-")
+		if i := strings.IndexByte(integer, '.'); i >= 0 {
+			if strings.Contains(integer[i:], ",") {
+				return nil
+			}
+			integer = integer[:i]
+		}
+		groups := strings.Split(integer, ",")
+		if len(groups[0]) < 1 || len(groups[0]) > 3 {
+			return nil
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return nil
+			}
+		}
+		s = strings.Replace(s, ",", "", -1)
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return nil
+	}
+	return token.NewDecimal(d)
+}
+
+var ToBoolean = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "toBoolean",
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Boolean,
+		},
+		ArgumentNames:    []string{"value", "lenient"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewBool(false)},
+		Returns:          token.Optional(token.Boolean),
+		Description:      "Converts the strings true and false to a boolean. If lenient is true yes/no, on/off, 1/0 and decimals (not 0) are converted as well and null is returned if the value cannot be converted",
+		Example: `
+(toBoolean "true")                                               ; returns true
+(toBoolean "Yes" true)                                           ; returns true
+(toBoolean 0 true)                                               ; returns false
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return convert(args, token.Boolean, func(value *token.TaToken, lenient bool) *token.TaToken {
+			switch {
+			case value.IsString():
+				if !lenient {
+					return literalOf(value.String, token.Boolean)
+				}
+				switch strings.ToLower(strings.TrimSpace(value.String)) {
+				case "true", "yes", "y", "on", "1":
+					return token.NewBool(true)
+				case "false", "no", "n", "off", "0":
+					return token.NewBool(false)
+				}
+			case value.IsDecimal() && lenient:
+				return token.NewBool(!decimal.Equals(value.Decimal, decimal.Zero()))
+			}
+			return nil
+		})
+	},
+}
+
+var ToTime = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "toTime",
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Boolean,
+		},
+		ArgumentNames:    []string{"value", "lenient"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewBool(false)},
+		Returns:          token.Optional(token.Time),
+		Description:      "Converts RFC3339 timestamps and dates (2006-01-02) to a time. If lenient is true other time formats (see parseTime) and unix seconds are converted as well and null is returned if the value cannot be converted",
+		Example: `
+(toTime "2018-01-02T19:04:05Z")                                  ; returns "2018-01-02 19:04:05 +0000 UTC"
+(toTime "01/02/2018 19:04" true)                                 ; returns "2018-01-02 19:04:00 +0000 UTC"
+(toTime 1514919845 true)                                         ; returns "2018-01-02 19:04:05 +0000 UTC"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return convert(args, token.Time, func(value *token.TaToken, lenient bool) *token.TaToken {
+			switch {
+			case value.IsString():
+				if result := literalOf(value.String, token.Time); result != nil || !lenient {
+					return result
+				}
+				t, err := dateparse.ParseIn(strings.TrimSpace(value.String), interp.CurrentLocation())
+				if err != nil {
+					return nil
+				}
+				return token.NewTime(t)
+			case value.IsDecimal() && lenient:
+				nanoseconds, err := decimal.Mul(value.Decimal, decimal.NewFromInt64(int64(time.Second))).Int64()
+				if err != nil {
+					return nil
+				}
+				return token.NewTime(time.Unix(0, nanoseconds).UTC())
+			}
+			return nil
+		})
+	},
+}
+
+var ToList = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "toList",
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+			token.Boolean,
+		},
+		ArgumentNames:    []string{"value", "lenient"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewBool(false)},
+		Returns:          token.Optional(token.List),
+		Description:      "Converts a map to the list of its values. If lenient is true any other value is converted to a list containing the value and null is returned for null",
+		Example: `
+(toList (kv (A 1) (B 2)))                                        ; returns a list containing 1 and 2
+(toList "Hello" true)                                            ; returns a list containing "Hello"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return convert(args, token.List, func(value *token.TaToken, lenient bool) *token.TaToken {
+			switch {
+			case value.IsMap():
+				return token.NewList(value.Children...)
+			case value.IsNull() || !lenient:
+				return nil
+			}
+			return token.NewList(value)
+		})
+	},
+}
+
+var ToJSON = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "toJSON",
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+		},
+		Returns:     token.String,
+		Description: "Encodes the parameter as JSON, times and durations are encoded as strings and money as an object with the Amount and the Currency",
+		Example: `
+(toJSON (kv (A 1) (B (list true "x"))))                          ; returns "{\"A\":1,\"B\":[true,\"x\"]}"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		var builder strings.Builder
+		if err := writeJSON(&builder, args[0]); err != nil {
+			return nil, err
+		}
+		return token.NewString(builder.String()), nil
+	},
+}
+
+var FromJSON = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "fromJSON",
+		Arguments: []token.Kind{
+			token.String,
+			token.Boolean,
+		},
+		ArgumentNames:    []string{"json", "lenient"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewBool(false)},
+		Returns:          token.Atom | token.Collection,
+		Description:      "Decodes a JSON string, numbers are decoded as decimals and objects as maps that keep the order of their keys. If lenient is true null is returned if the string is not valid JSON",
+		Example: `
+(fromJSON "[1, 2]")                                              ; returns a list containing 1 and 2
+(fromJSON "{\"A\": true}")                                       ; returns a map containing A: true
+(fromJSON "{" true)                                              ; returns null
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		return token.NewString(args[0].String), nil
+		return convert(args, 0, func(value *token.TaToken, lenient bool) *token.TaToken {
+			decoder := json.NewDecoder(strings.NewReader(value.String))
+			decoder.UseNumber()
+			result, err := readJSON(decoder)
+			if err != nil {
+				return nil
+			}
+			// the string must only contain one value
+			if _, err := decoder.Token(); err != io.EOF {
+				return nil
+			}
+			return result
+		})
 	},
 }
 
@@ -64,6 +321,73 @@ var Not = interpreter.TaFunction{
 	},
 }
 
+var Coalesce = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "coalesce",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Atom | token.Collection | token.Token,
+		},
+		Returns:     token.Any,
+		Description: "Returns the first argument that is not null, arguments are evaluated from left to right until one is not null",
+		Example: `
+(coalesce (.? Profile Name) "Guest")                             ; returns "Guest" (assuming Profile has no Name)
+(coalesce (noop) (noop))                                         ; returns null
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		for i := 0; i < len(args); i++ {
+			if args[i].IsBlock() {
+				if err := interp.Evaluate(args[i]); err != nil {
+					return nil, err
+				}
+			}
+			if !args[i].IsNull() {
+				return args[i], nil
+			}
+		}
+		return token.NewNull(), nil
+	},
+}
+
+var IsNull = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "isNull",
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the argument is null",
+		Example: `
+(isNull (.? Profile Name))                                       ; returns true (assuming Profile has no Name)
+(isNull "")                                                      ; returns false
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewBool(args[0].IsNull()), nil
+	},
+}
+
+var IsSet = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name: "isSet",
+		Arguments: []token.Kind{
+			token.Atom | token.Collection,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if the argument is not null",
+		Example: `
+(isSet (.? Profile Name))                                        ; returns false (assuming Profile has no Name)
+(isSet (list))                                                   ; returns true
+`,
+	},
+	SharesArguments: true,
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewBool(!args[0].IsNull()), nil
+	},
+}
+
 var Catch = interpreter.TaFunction{
 	CommonSignature: interpreter.CommonSignature{
 		Name: "catch",
@@ -148,10 +472,11 @@ var SafeRead = interpreter.TaFunction{
 			token.Token, // binding
 		},
 		Returns:     token.Any,
-		Description: "Safe read a binding",
+		Description: "Safe read a binding, the value must have the kind of the fallback unless one of them is null",
 		Example: `
 .| boo (. List)                                                  ; returns "XJK_992" (assuming $List = "XJK_992")
 .| boo (. Meh)                                                   ; returns "boo" (assuming $Meh is not set)
+.| (noop) (. List)                                               ; returns "XJK_992" (assuming $List = "XJK_992")
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
@@ -160,9 +485,154 @@ var SafeRead = interpreter.TaFunction{
 		if err := interp.Evaluate(blockToRun); err != nil {
 			return fallback, nil
 		}
-		if blockToRun.Kind != fallback.Kind {
+		if fallback.IsBlock() {
+			if err := interp.Evaluate(fallback); err != nil {
+				return nil, err
+			}
+		}
+		if blockToRun.Kind != fallback.Kind && !blockToRun.IsNull() && !fallback.IsNull() {
 			return nil, errors.Errorf("Cannot reconciliate type %s with %s", fallback.Kind.String(), blockToRun.Kind.String())
 		}
 		return blockToRun, nil
 	},
 }
+
+// convert runs a conversion function on the first argument, the second argument is the lenient flag.
+// Values that already have the kind are returned as they are, if the conversion fails
+// an error is returned or null if the conversion is lenient
+func convert(args []*token.TaToken, kind token.Kind, fn func(value *token.TaToken, lenient bool) *token.TaToken) (*token.TaToken, error) {
+	value := args[0]
+	if kind != 0 && value.Kind == kind {
+		return value, nil
+	}
+	lenient := len(args) > 1 && args[1].Bool
+	if result := fn(value, lenient); result != nil {
+		return result, nil
+	}
+	if lenient {
+		return token.NewNull(), nil
+	}
+	target := "JSON"
+	if kind != 0 {
+		target = kind.String()
+	}
+	return nil, errors.Errorf("Cannot convert %s %s to %s", value.Kind.String(), value.Stringify(), target)
+}
+
+// literalOf returns the value of the string if it is written like a literal of the kind, otherwise nil
+func literalOf(s string, kind token.Kind) *token.TaToken {
+	if result := token.New(s); result.Kind == kind {
+		return result
+	}
+	return nil
+}
+
+func writeJSON(builder *strings.Builder, value *token.TaToken) error {
+	switch value.Kind {
+	case token.Decimal:
+		builder.WriteString(value.Decimal.String())
+	case token.Boolean:
+		builder.WriteString(strconv.FormatBool(value.Bool))
+	case token.Null:
+		builder.WriteString("null")
+	case token.Money:
+		builder.WriteString(`{"Amount":`)
+		builder.WriteString(value.Decimal.String())
+		builder.WriteString(`,"Currency":`)
+		builder.WriteString(strconv.Quote(value.Currency))
+		builder.WriteString("}")
+	case token.List:
+		builder.WriteString("[")
+		for i, child := range value.Children {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			if err := writeJSON(builder, child); err != nil {
+				return err
+			}
+		}
+		builder.WriteString("]")
+	case token.Map:
+		builder.WriteString("{")
+		for i, key := range value.Keys {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			writeJSONString(builder, key)
+			builder.WriteString(":")
+			if err := writeJSON(builder, value.Children[i]); err != nil {
+				return err
+			}
+		}
+		builder.WriteString("}")
+	case token.String, token.Time, token.Duration:
+		writeJSONString(builder, value.String)
+	default:
+		return errors.Errorf("Cannot convert %s to JSON", value.Kind.String())
+	}
+	return nil
+}
+
+func writeJSONString(builder *strings.Builder, s string) {
+	// encoding a string never fails
+	b, _ := json.Marshal(s)
+	builder.Write(b)
+}
+
+// readJSON reads the next value from the decoder, the keys of objects keep their order
+func readJSON(decoder *json.Decoder) (*token.TaToken, error) {
+	t, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := t.(type) {
+	case json.Delim:
+		switch v {
+		case '[':
+			var children []*token.TaToken
+			for decoder.More() {
+				child, err := readJSON(decoder)
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, child)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return token.NewList(children...), nil
+		case '{':
+			var keys []string
+			var children []*token.TaToken
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				child, err := readJSON(decoder)
+				if err != nil {
+					return nil, err
+				}
+				keys = append(keys, key.(string))
+				children = append(children, child)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return token.NewOrderedMap(keys, children), nil
+		}
+	case json.Number:
+		d, err := decimal.NewFromString(v.String())
+		if err != nil {
+			return nil, err
+		}
+		return token.NewDecimal(d), nil
+	case string:
+		return token.NewString(v), nil
+	case bool:
+		return token.NewBool(v), nil
+	case nil:
+		return token.NewNull(), nil
+	}
+	return nil, errors.Errorf("Unexpected JSON token %v", t)
+}
@@ -6,7 +6,16 @@ func AllOperations() []interpreter.TaFunction {
 	return []interpreter.TaFunction{
 		Noop,
 		ToString,
+		ToDecimal,
+		ToBoolean,
+		ToTime,
+		ToList,
+		ToJSON,
+		FromJSON,
 		Not,
+		Coalesce,
+		IsNull,
+		IsSet,
 		Catch,
 		Do,
 		DoLegacy,
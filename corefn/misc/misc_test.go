@@ -1,8 +1,13 @@
 package misc_test
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/require"
+	"github.com/talon-one/decimal"
+	"github.com/talon-one/talang"
 	helpers "github.com/talon-one/talang/testhelpers"
 	"github.com/talon-one/talang/token"
 )
@@ -43,6 +48,52 @@ func TestNot(t *testing.T) {
 	)
 }
 
+func TestCoalesce(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`coalesce (.? Profile Name) "Guest"`,
+			nil,
+			token.NewString("Guest"),
+		},
+		helpers.Test{
+			`coalesce (.? Profile Name) "Guest"`,
+			token.NewMap(map[string]*token.TaToken{
+				"Profile": token.NewMap(map[string]*token.TaToken{
+					"Name": token.NewString("Joe"),
+				}),
+			}),
+			token.NewString("Joe"),
+		},
+		helpers.Test{
+			`coalesce (noop) (noop)`,
+			nil,
+			token.NewNull(),
+		},
+		// arguments after the first one that is not null are not evaluated
+		helpers.Test{
+			`coalesce 1 (panic)`,
+			nil,
+			token.NewDecimalFromInt(1),
+		},
+		helpers.Test{
+			`coalesce (noop) (panic)`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestIsNull(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{`isNull (.? Profile Name)`, nil, token.NewBool(true)},
+		helpers.Test{`isNull ""`, nil, token.NewBool(false)},
+		helpers.Test{`isNull (list)`, nil, token.NewBool(false)},
+		helpers.Test{`isSet (.? Profile Name)`, nil, token.NewBool(false)},
+		helpers.Test{`isSet 0`, nil, token.NewBool(true)},
+		helpers.Test{`isSet (head (list))`, nil, token.NewBool(false)},
+	)
+}
+
 func TestCatch(t *testing.T) {
 	helpers.RunTests(t,
 		helpers.Test{
@@ -169,6 +220,16 @@ func TestSafeRead(t *testing.T) {
 				}),
 			}),
 			helpers.Error{},
+		}, helpers.Test{
+			".| (noop) (. List)",
+			token.NewMap(map[string]*token.TaToken{
+				"List": token.NewString("XJK_992"),
+			}),
+			token.NewString("XJK_992"),
+		}, helpers.Test{
+			".| (noop) (. Meh)",
+			nil,
+			token.NewNull(),
 		},
 		// This test evaluates to 1, bug?
 		// , helpers.Test{
@@ -182,3 +243,137 @@ func TestSafeRead(t *testing.T) {
 		// }
 	)
 }
+
+// TestConversionMatrix documents which kinds can be converted to which kind,
+// strict conversions fail with an error, lenient conversions (second argument true) return null instead.
+//
+//	from \ to | Decimal        | Boolean             | Time               | List            | String
+//	----------+----------------+---------------------+--------------------+-----------------+--------------
+//	Decimal   | itself         | lenient: not 0      | lenient: unix secs | lenient: [value]| yes
+//	String    | literal        | literal             | literal (RFC3339   | lenient: [value]| itself
+//	          | lenient: 1,000 | lenient: yes/no/1/0 | or date), lenient: |                 |
+//	          |                |                     | parseTime formats  |                 |
+//	Boolean   | lenient: 1/0   | itself              | no                 | lenient: [value]| yes
+//	Time      | lenient: unix  | no                  | itself             | lenient: [value]| yes
+//	Duration  | lenient: secs  | no                  | no                 | lenient: [value]| yes
+//	Money     | amount         | no                  | no                 | lenient: [value]| yes
+//	Null      | no             | no                  | no                 | no              | lenient: null
+//	List      | no             | no                  | no                 | itself          | printed
+//	Map       | no             | no                  | no                 | values          | printed
+func TestConversionMatrix(t *testing.T) {
+	binding := token.NewMap(map[string]*token.TaToken{
+		"Duration": token.NewDuration(90 * time.Second),
+		"Money":    token.NewMoney(decimal.NewFromInt64(5), "EUR"),
+		"Time":     token.NewTime(time.Date(2018, 1, 2, 19, 4, 5, 0, time.UTC)),
+		"Map":      token.NewOrderedMap([]string{"B", "A"}, []*token.TaToken{token.NewDecimalFromInt(2), token.NewDecimalFromInt(1)}),
+	})
+	null := token.NewNull()
+	tests := []struct {
+		Function string
+		Value    string
+		Strict   interface{}
+		Lenient  interface{}
+	}{
+		{"toDecimal", `1`, token.NewDecimalFromInt(1), token.NewDecimalFromInt(1)},
+		{"toDecimal", `"42"`, token.NewDecimalFromInt(42), token.NewDecimalFromInt(42)},
+		{"toDecimal", `"-1.5"`, token.NewDecimalFromFloat(-1.5), token.NewDecimalFromFloat(-1.5)},
+		{"toDecimal", `" 1,000.5 "`, helpers.Error{}, token.NewDecimalFromFloat(1000.5)},
+		{"toDecimal", `"-12,345,678"`, helpers.Error{}, token.NewDecimalFromInt(-12345678)},
+		{"toDecimal", `"12,50"`, helpers.Error{}, null},
+		{"toDecimal", `"1,5"`, helpers.Error{}, null},
+		{"toDecimal", `"1234,567"`, helpers.Error{}, null},
+		{"toDecimal", `"1,000.5,0"`, helpers.Error{}, null},
+		{"toDecimal", `",100"`, helpers.Error{}, null},
+		{"toDecimal", `"abc"`, helpers.Error{}, null},
+		{"toDecimal", `true`, helpers.Error{}, token.NewDecimalFromInt(1)},
+		{"toDecimal", `false`, helpers.Error{}, token.NewDecimalFromInt(0)},
+		{"toDecimal", `(. Time)`, helpers.Error{}, token.NewDecimalFromInt(1514919845)},
+		{"toDecimal", `(. Duration)`, helpers.Error{}, token.NewDecimalFromInt(90)},
+		{"toDecimal", `(. Money)`, token.NewDecimalFromInt(5), token.NewDecimalFromInt(5)},
+		{"toDecimal", `(list 1)`, helpers.Error{}, null},
+
+		{"toBoolean", `true`, token.NewBool(true), token.NewBool(true)},
+		{"toBoolean", `"false"`, token.NewBool(false), token.NewBool(false)},
+		{"toBoolean", `"yes"`, helpers.Error{}, token.NewBool(true)},
+		{"toBoolean", `" Off "`, helpers.Error{}, token.NewBool(false)},
+		{"toBoolean", `"maybe"`, helpers.Error{}, null},
+		{"toBoolean", `0`, helpers.Error{}, token.NewBool(false)},
+		{"toBoolean", `2.5`, helpers.Error{}, token.NewBool(true)},
+		{"toBoolean", `(. Time)`, helpers.Error{}, null},
+
+		{"toTime", `(. Time)`, binding.MapItem("Time"), binding.MapItem("Time")},
+		{"toTime", `"2018-01-02T19:04:05Z"`, binding.MapItem("Time"), binding.MapItem("Time")},
+		{"toTime", `"2018-01-02"`, token.NewDate(time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)), token.NewDate(time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC))},
+		{"toTime", `"01/02/2018 19:04:05"`, helpers.Error{}, binding.MapItem("Time")},
+		{"toTime", `"someday"`, helpers.Error{}, null},
+		{"toTime", `1514919845`, helpers.Error{}, binding.MapItem("Time")},
+		{"toTime", `true`, helpers.Error{}, null},
+
+		{"toList", `(list 1 2)`, token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2)), token.NewList(token.NewDecimalFromInt(1), token.NewDecimalFromInt(2))},
+		{"toList", `(. Map)`, token.NewList(token.NewDecimalFromInt(2), token.NewDecimalFromInt(1)), token.NewList(token.NewDecimalFromInt(2), token.NewDecimalFromInt(1))},
+		{"toList", `"Hello"`, helpers.Error{}, token.NewList(token.NewString("Hello"))},
+		{"toList", `1`, helpers.Error{}, token.NewList(token.NewDecimalFromInt(1))},
+
+		{"toString", `1`, token.NewString("1"), token.NewString("1")},
+		{"toString", `true`, token.NewString("true"), token.NewString("true")},
+		{"toString", `(. Duration)`, token.NewString("1m30s"), token.NewString("1m30s")},
+		{"toString", `(list 1 "A")`, token.NewString(`[1, "A"]`), token.NewString(`[1, "A"]`)},
+		{"toString", `(. Map)`, token.NewString("{B:2, A:1}"), token.NewString("{B:2, A:1}")},
+	}
+
+	for _, test := range tests {
+		helpers.RunTestsWithInterpreter(t, interpreterWithBinding(binding),
+			helpers.Test{fmt.Sprintf("%s %s", test.Function, test.Value), nil, test.Strict},
+			helpers.Test{fmt.Sprintf("%s %s true", test.Function, test.Value), nil, test.Lenient},
+		)
+	}
+
+	// null can only be converted to a string by a lenient conversion
+	for _, fn := range []string{"toDecimal", "toBoolean", "toTime", "toList", "toString"} {
+		helpers.RunTests(t,
+			helpers.Test{fmt.Sprintf("%s (noop)", fn), nil, helpers.Error{}},
+			helpers.Test{fmt.Sprintf("%s (noop) true", fn), nil, null},
+		)
+	}
+}
+
+func TestConversionError(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	_, err := interp.LexAndEvaluate(`toDecimal "abc"`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Cannot convert String "abc" to Decimal`)
+	_, err = interp.LexAndEvaluate(`toTime (list 1 2)`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Cannot convert List [1, 2] to Time`)
+	_, err = interp.LexAndEvaluate(`fromJSON "{"`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Cannot convert String "{" to JSON`)
+}
+
+func TestJSON(t *testing.T) {
+	helpers.RunTestsWithInterpreter(t, interpreterWithBinding(token.NewMap(map[string]*token.TaToken{
+		"Money": token.NewMoney(decimal.NewFromInt64(5), "EUR"),
+		"Time":  token.NewTime(time.Date(2018, 1, 2, 19, 4, 5, 0, time.UTC)),
+	})),
+		helpers.Test{`toJSON (kv (B 1) (A (list true "x" (noop))))`, nil, token.NewString(`{"B":1,"A":[true,"x",null]}`)},
+		helpers.Test{`toJSON (. Money)`, nil, token.NewString(`{"Amount":5,"Currency":"EUR"}`)},
+		helpers.Test{`toJSON (. Time)`, nil, token.NewString(`"2018-01-02T19:04:05Z"`)},
+		helpers.Test{`toJSON "say \"hi\""`, nil, token.NewString(`"say \"hi\""`)},
+		helpers.Test{`fromJSON "[1.5, \"a\", true, null]"`, nil, token.NewList(token.NewDecimalFromFloat(1.5), token.NewString("a"), token.NewBool(true), token.NewNull())},
+		helpers.Test{`fromJSON "{\"B\": {\"C\": 1}, \"A\": []}"`, nil, token.NewOrderedMap([]string{"B", "A"}, []*token.TaToken{
+			token.NewMap(map[string]*token.TaToken{"C": token.NewDecimalFromInt(1)}),
+			token.NewList(),
+		})},
+		helpers.Test{`fromJSON "\"2018-01-02\""`, nil, token.NewString("2018-01-02")},
+		helpers.Test{`fromJSON "{"`, nil, helpers.Error{}},
+		helpers.Test{`fromJSON "1 2"`, nil, helpers.Error{}},
+		helpers.Test{`fromJSON "{" true`, nil, token.NewNull()},
+		helpers.Test{`toJSON (fromJSON "{\"B\":[1,{\"A\":null}],\"A\":\"x\"}")`, nil, token.NewString(`{"B":[1,{"A":null}],"A":"x"}`)},
+	)
+}
+
+func interpreterWithBinding(binding *token.TaToken) *talang.Interpreter {
+	interp := helpers.MustNewInterpreter()
+	interp.Binding = binding
+	return interp
+}
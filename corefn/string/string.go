@@ -2,11 +2,20 @@
 package string
 
 import (
-	"regexp"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/talon-one/decimal"
 	"github.com/talon-one/talang/interpreter"
 	"github.com/talon-one/talang/token"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 func init() {
@@ -183,7 +192,7 @@ var Regexp = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		re, err := regexp.Compile(args[0].String)
+		re, err := interp.CompileRegexp(args[0].String)
 		if err != nil {
 			return token.NewBool(false), err
 		}
@@ -205,14 +214,18 @@ var LastName = interpreter.TaFunction{
 			token.String,
 		},
 		Returns:     token.String,
-		Description: "Extract the last word (space-separated) from a string",
+		Description: "Extract the last word (separated by any whitespace) from a string",
 		Example: `
 (lastName "Alex Unger")                                          ; returns "Unger"
 (lastName "Mr Foo Bar")                                          ; returns "Bar"
+(lastName " José  María ")                                       ; returns "María"
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		words := strings.Split(args[0].String, " ")
+		words := strings.Fields(args[0].String)
+		if len(words) == 0 {
+			return token.NewString(""), nil
+		}
 		return token.NewString(words[len(words)-1]), nil
 	},
 }
@@ -225,14 +238,1187 @@ var FirstName = interpreter.TaFunction{
 			token.String,
 		},
 		Returns:     token.String,
-		Description: "Extract all but the last word (space-separated) from a string",
+		Description: "Extract the first word (separated by any whitespace) from a string",
 		Example: `
 (firstName "Alex Unger")                                         ; returns "Alex"
 (firstName "Mr Foo Bar")                                         ; returns "Mr"
+(firstName " José  María ")                                      ; returns "José"
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		words := strings.Split(args[0].String, " ")
+		words := strings.Fields(args[0].String)
+		if len(words) == 0 {
+			return token.NewString(""), nil
+		}
 		return token.NewString(words[0]), nil
 	},
 }
+
+var Upper = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "upper",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Converts all letters of a string to upper case",
+		Example: `
+(upper "Hello World")                                            ; returns "HELLO WORLD"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.ToUpper(args[0].String)), nil
+	},
+}
+
+var Lower = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "lower",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Converts all letters of a string to lower case",
+		Example: `
+(lower "Hello World")                                            ; returns "hello world"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.ToLower(args[0].String)), nil
+	},
+}
+
+var Trim = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trim",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes whitespace from the start and the end of a string",
+		Example: `
+(trim "  Hello World ")                                          ; returns "Hello World"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.TrimSpace(args[0].String)), nil
+	},
+}
+
+var TrimChars = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trim",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes the characters of the second argument from the start and the end of a string",
+		Example: `
+(trim "--SUMMER-2018--" "-")                                     ; returns "SUMMER-2018"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.Trim(args[0].String, args[1].String)), nil
+	},
+}
+
+var TrimLeft = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trimLeft",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes whitespace from the start of a string",
+		Example: `
+(trimLeft "  Hello World ")                                      ; returns "Hello World "
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.TrimLeftFunc(args[0].String, unicode.IsSpace)), nil
+	},
+}
+
+var TrimLeftChars = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trimLeft",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes the characters of the second argument from the start of a string",
+		Example: `
+(trimLeft "00042" "0")                                           ; returns "42"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.TrimLeft(args[0].String, args[1].String)), nil
+	},
+}
+
+var TrimRight = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trimRight",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes whitespace from the end of a string",
+		Example: `
+(trimRight "  Hello World ")                                     ; returns "  Hello World"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.TrimRightFunc(args[0].String, unicode.IsSpace)), nil
+	},
+}
+
+var TrimRightChars = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trimRight",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes the characters of the second argument from the end of a string",
+		Example: `
+(trimRight "Hello World!!!" "!")                                 ; returns "Hello World"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.TrimRight(args[0].String, args[1].String)), nil
+	},
+}
+
+var TrimPrefix = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trimPrefix",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes the prefix from the start of a string, the string is returned unchanged if it does not start with the prefix",
+		Example: `
+(trimPrefix "SUMMER-2018" "SUMMER-")                             ; returns "2018"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.TrimPrefix(args[0].String, args[1].String)), nil
+	},
+}
+
+var TrimSuffix = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "trimSuffix",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes the suffix from the end of a string, the string is returned unchanged if it does not end with the suffix",
+		Example: `
+(trimSuffix "SUMMER-2018" "-2018")                               ; returns "SUMMER"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.TrimSuffix(args[0].String, args[1].String)), nil
+	},
+}
+
+var Replace = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "replace",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Replaces the first occurrence of the second argument in a string with the third argument",
+		Example: `
+(replace "Hello World World" "World" "Universe")                 ; returns "Hello Universe World"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.Replace(args[0].String, args[1].String, args[2].String, 1)), nil
+	},
+}
+
+var ReplaceAll = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "replaceAll",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Replaces all occurrences of the second argument in a string with the third argument",
+		Example: `
+(replaceAll "Hello World World" "World" "Universe")              ; returns "Hello Universe Universe"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewString(strings.Replace(args[0].String, args[1].String, args[2].String, -1)), nil
+	},
+}
+
+var Substring = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "substring",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.Decimal,
+			token.Decimal,
+		},
+		Returns:     token.String,
+		Description: "Returns the characters from the start index up to (but not including) the end index, negative indices count from the end of the string",
+		Example: `
+(substring "Hello World" 0 5)                                    ; returns "Hello"
+(substring "Hello World" -5 -1)                                  ; returns "Worl"
+(substring "Grüße" 2 4)                                          ; returns "üß"
+(substring "Hello" 2 6)                                          ; fails
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		runes := []rune(args[0].String)
+		start, err := runeIndex(args[1], len(runes))
+		if err != nil {
+			return nil, err
+		}
+		end, err := runeIndex(args[2], len(runes))
+		if err != nil {
+			return nil, err
+		}
+		if start > end {
+			return nil, errors.New("Out of bounds")
+		}
+		return token.NewString(string(runes[start:end])), nil
+	},
+}
+
+var SubstringFrom = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "substring",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.Decimal,
+		},
+		Returns:     token.String,
+		Description: "Returns the characters from the start index up to the end of the string, a negative index counts from the end of the string",
+		Example: `
+(substring "Hello World" 6)                                      ; returns "World"
+(substring "SUMMER-2018" -4)                                     ; returns "2018"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		runes := []rune(args[0].String)
+		start, err := runeIndex(args[1], len(runes))
+		if err != nil {
+			return nil, err
+		}
+		return token.NewString(string(runes[start:])), nil
+	},
+}
+
+var Length = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "length",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.Decimal,
+		Description: "Returns the number of characters in a string",
+		Example: `
+(length "Hello")                                                 ; returns 5
+(length "Grüße")                                                 ; returns 5
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDecimalFromInt(int64(utf8.RuneCountInString(args[0].String))), nil
+	},
+}
+
+var IndexOf = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "indexOf",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.Decimal,
+		Description: "Returns the character index of the first occurrence of the second argument in a string or -1 if the string does not contain it",
+		Example: `
+(indexOf "Hello World" "World")                                  ; returns 6
+(indexOf "Grüße" "e")                                            ; returns 4
+(indexOf "Hello World" "Universe")                               ; returns -1
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		i := strings.Index(args[0].String, args[1].String)
+		if i < 0 {
+			return token.NewDecimalFromInt(-1), nil
+		}
+		return token.NewDecimalFromInt(int64(utf8.RuneCountInString(args[0].String[:i]))), nil
+	},
+}
+
+var PadLeft = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "padLeft",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.Decimal,
+			token.String,
+		},
+		ArgumentNames:    []string{"value", "length", "padding"},
+		ArgumentDefaults: []*token.TaToken{nil, nil, token.NewString(" ")},
+		Returns:          token.String,
+		Description:      "Adds the padding to the start of a string until it has the given number of characters, longer strings are returned unchanged",
+		Example: `
+(padLeft "42" 5 "0")                                             ; returns "00042"
+(padLeft "42" 5)                                                 ; returns "   42"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		padding, err := pad(args[0].String, args[1], args[2].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewString(padding + args[0].String), nil
+	},
+}
+
+var PadRight = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "padRight",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.Decimal,
+			token.String,
+		},
+		ArgumentNames:    []string{"value", "length", "padding"},
+		ArgumentDefaults: []*token.TaToken{nil, nil, token.NewString(" ")},
+		Returns:          token.String,
+		Description:      "Adds the padding to the end of a string until it has the given number of characters, longer strings are returned unchanged",
+		Example: `
+(padRight "42" 5 "-")                                            ; returns "42---"
+(padRight "42" 5)                                                ; returns "42   "
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		padding, err := pad(args[0].String, args[1], args[2].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewString(args[0].String + padding), nil
+	},
+}
+
+var Repeat = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "repeat",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.Decimal,
+		},
+		Returns:     token.String,
+		Description: "Repeats a string the given number of times",
+		Example: `
+(repeat "ab" 3)                                                  ; returns "ababab"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		n, err := args[1].Decimal.Int64()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errors.New("Cannot repeat a string a negative number of times")
+		}
+		if n > 0 && int64(len(args[0].String)) > maxStringLength/n {
+			return nil, fmt.Errorf("A string cannot be longer than %d bytes", maxStringLength)
+		}
+		return token.NewString(strings.Repeat(args[0].String, int(n))), nil
+	},
+}
+
+var EqualFold = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "equalFold",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if two strings are equal ignoring the case of the letters",
+		Example: `
+(equalFold "SUMMER2018" "summer2018")                            ; returns true
+(equalFold "Straße" "STRASSE")                                   ; returns false
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewBool(strings.EqualFold(args[0].String, args[1].String)), nil
+	},
+}
+
+var Format = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "format",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.String,
+			token.Atom | token.Collection,
+		},
+		Returns:     token.String,
+		Description: "Formats the values like printf, decimals can be used with %d (integers only), %f, %e and %g and are formatted with all their digits, ties are rounded to even. Other values are written like they are printed with %s and %v, the number of directives must match the number of values",
+		Example: `
+(format "%s has %d items" "Alex" 3)                              ; returns "Alex has 3 items"
+(format "Total: %.2f" 12.5)                                      ; returns "Total: 12.50"
+(format "%05d" 42)                                               ; returns "00042"
+(format "%d" 1.5)                                                ; fails
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		verbs, err := formatVerbs(args[0].String)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid format `%s': %s", args[0].String, err)
+		}
+		if len(verbs) != len(args)-1 {
+			return nil, fmt.Errorf("Invalid format `%s': expected %d values, got %d", args[0].String, len(verbs), len(args)-1)
+		}
+		values := make([]interface{}, len(args)-1)
+		for i, arg := range args[1:] {
+			if !formatVerbAccepts(verbs[i], arg) {
+				return nil, fmt.Errorf("Invalid format `%s': %%%c cannot format %s", args[0].String, verbs[i], arg.Stringify())
+			}
+			switch arg.Kind {
+			case token.String:
+				values[i] = arg.String
+			case token.Boolean:
+				values[i] = arg.Bool
+			case token.Decimal:
+				values[i] = formatDecimal{arg}
+			default:
+				values[i] = arg.Stringify()
+			}
+		}
+		return token.NewString(fmt.Sprintf(args[0].String, values...)), nil
+	},
+}
+
+var RegexFind = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "regexFind",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.Optional(token.String),
+		Description: "Returns the first match of the regular expression (first argument) in a string or null if the regular expression does not match",
+		Example: `
+(regexFind "\d+" "SUMMER-2018-10")                              ; returns "2018"
+(regexFind "\d+" "SUMMER")                                      ; returns null
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		re, err := interp.CompileRegexp(args[0].String)
+		if err != nil {
+			return nil, err
+		}
+		loc := re.FindStringIndex(args[1].String)
+		if loc == nil {
+			return token.NewNull(), nil
+		}
+		return token.NewString(args[1].String[loc[0]:loc[1]]), nil
+	},
+}
+
+var RegexFindAll = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "regexFindAll",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.ListOf(token.String),
+		Description: "Returns all matches of the regular expression (first argument) in a string",
+		Example: `
+(regexFindAll "\d+" "SUMMER-2018-10")                           ; returns a list containing "2018" and "10"
+(regexFindAll "\d+" "SUMMER")                                   ; returns an empty list
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		re, err := interp.CompileRegexp(args[0].String)
+		if err != nil {
+			return nil, err
+		}
+		matches := re.FindAllString(args[1].String, -1)
+		items := make([]*token.TaToken, len(matches))
+		for i, match := range matches {
+			items[i] = token.NewString(match)
+		}
+		return token.NewList(items...), nil
+	},
+}
+
+var RegexGroups = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "regexGroups",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.Optional(token.MapOf(token.Optional(token.String))),
+		Description: "Returns the named groups of the first match of the regular expression (first argument) in a string as a map or null if the regular expression does not match, groups that did not match are null",
+		Example: `
+(regexGroups "(?P<Season>[A-Z]+)-(?P<Year>\d+)" "SUMMER-2018")  ; returns {Season:"SUMMER", Year:"2018"}
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		re, err := interp.CompileRegexp(args[0].String)
+		if err != nil {
+			return nil, err
+		}
+		loc := re.FindStringSubmatchIndex(args[1].String)
+		if loc == nil {
+			return token.NewNull(), nil
+		}
+		var keys []string
+		var values []*token.TaToken
+		for i, name := range re.SubexpNames() {
+			if i == 0 || len(name) == 0 {
+				continue
+			}
+			keys = append(keys, name)
+			if loc[2*i] < 0 {
+				values = append(values, token.NewNull())
+			} else {
+				values = append(values, token.NewString(args[1].String[loc[2*i]:loc[2*i+1]]))
+			}
+		}
+		return token.NewOrderedMap(keys, values), nil
+	},
+}
+
+var RegexReplace = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "regexReplace",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Replaces all matches of the regular expression (first argument) in a string with the replacement, $1 or ${Name} in the replacement are replaced with the groups of the match",
+		Example: `
+(regexReplace "\s+" "Hello   World" " ")                        ; returns "Hello World"
+(regexReplace "(\w+)-(\d+)" "SUMMER-2018" "${2}-${1}")         ; returns "2018-SUMMER"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		re, err := interp.CompileRegexp(args[0].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewString(re.ReplaceAllString(args[1].String, args[2].String)), nil
+	},
+}
+
+var Normalize = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "normalize",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		ArgumentNames:    []string{"value", "form"},
+		ArgumentDefaults: []*token.TaToken{nil, token.NewString("NFC")},
+		Returns:          token.String,
+		Description:      "Applies the unicode normalization form NFC, NFD, NFKC or NFKD to a string, NFC composes characters like e and a combining accent to é, NFKC also replaces compatibility characters like ﬁ with fi",
+		Example: `
+(normalize "Café")                                               ; returns "Café" with a single character for é
+(normalize "ﬁle" "NFKC")                                         ; returns "file"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		form, err := normalizationForm(args[1].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewString(form.String(args[0].String)), nil
+	},
+}
+
+var RemoveAccents = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "removeAccents",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.String,
+		Description: "Removes accents and other combining marks from the letters of a string, letters like ø or ß that do not contain a mark are not changed",
+		Example: `
+(removeAccents "Crème Brûlée")                                   ; returns "Creme Brulee"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		s, err := removeAccents(args[0].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewString(s), nil
+	},
+}
+
+var EqualIgnoreAccents = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "equalIgnoreAccents",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+			token.String,
+		},
+		Returns:     token.Boolean,
+		Description: "Tests if two strings are equal ignoring accents and the unicode normalization form, the case of the letters is not ignored",
+		Example: `
+(equalIgnoreAccents "Muller" "Müller")                           ; returns true
+(equalIgnoreAccents "muller" "Müller")                           ; returns false
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		a, err := removeAccents(args[0].String)
+		if err != nil {
+			return nil, err
+		}
+		b, err := removeAccents(args[1].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewBool(a == b), nil
+	},
+}
+
+var GraphemeLength = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "graphemeLength",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.Decimal,
+		Description: "Returns the number of user-perceived characters (grapheme clusters) in a string, a letter with combining accents, an emoji with skin tone or a flag count as one. This is an approximation of the Unicode rules: emoji are recognized by the symbol category instead of the Extended_Pictographic property, so symbols like ‼ are not joined by zero width joiners, and prepended characters like the Arabic number sign are counted separately",
+		Example: `
+(graphemeLength "Grüße")                                         ; returns 5
+(graphemeLength "👍🏽🇩🇪")                                          ; returns 2
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDecimalFromInt(int64(graphemeCount(args[0].String))), nil
+	},
+}
+
+var Words = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "words",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.ListOf(token.String),
+		Description: "Splits a string at any whitespace into words, punctuation at the start and the end of a word is removed",
+		Example: `
+(words "Hello, World!")                                          ; returns ["Hello", "World"]
+(words " Jean-Luc  O'Brien ")                                    ; returns ["Jean-Luc", "O'Brien"]
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		words := []*token.TaToken{}
+		for _, field := range strings.Fields(args[0].String) {
+			if word := strings.TrimFunc(field, unicode.IsPunct); word != "" {
+				words = append(words, token.NewString(word))
+			}
+		}
+		return token.NewList(words...), nil
+	},
+}
+
+// maxStringLength is the maximum length in bytes of strings created by repeat and the pad functions
+const maxStringLength = 1 << 20
+
+// runeIndex converts the decimal to an index into a string with the given number of characters,
+// negative indices count from the end, the size itself is a valid index for the end of a substring
+func runeIndex(arg *token.TaToken, size int) (int, error) {
+	i, err := arg.Decimal.Int64()
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		i += int64(size)
+	}
+	if i < 0 || i > int64(size) {
+		return 0, errors.New("Out of bounds")
+	}
+	return int(i), nil
+}
+
+// pad returns the padding that is needed to extend s to length characters, the padding is repeated and cut as needed
+func pad(s string, length *token.TaToken, padding string) (string, error) {
+	n, err := length.Decimal.Int64()
+	if err != nil {
+		return "", err
+	}
+	if n > maxStringLength {
+		return "", fmt.Errorf("A string cannot be longer than %d bytes", maxStringLength)
+	}
+	missing := int(n) - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return "", nil
+	}
+	runes := []rune(padding)
+	if len(runes) == 0 {
+		return "", errors.New("The padding must not be empty")
+	}
+	result := make([]rune, missing)
+	for i := range result {
+		result[i] = runes[i%len(runes)]
+	}
+	return string(result), nil
+}
+
+// formatVerbs returns the verbs of the directives in a printf format, %% is not a directive
+func formatVerbs(format string) ([]rune, error) {
+	var verbs []rune
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		for i++; i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0; i++ {
+		}
+		for ; i < len(format) && (format[i] >= '0' && format[i] <= '9' || format[i] == '.'); i++ {
+		}
+		if i >= len(format) {
+			return nil, errors.New("missing verb at the end")
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		switch verb {
+		case '%':
+			continue
+		case '*', '[':
+			return nil, errors.New("argument widths and indexes are not supported")
+		}
+		verbs = append(verbs, verb)
+		i += size - 1
+	}
+	return verbs, nil
+}
+
+// formatVerbAccepts returns true if the verb can format the value, decimals must be integers for the integer verbs
+func formatVerbAccepts(verb rune, value *token.TaToken) bool {
+	switch value.Kind {
+	case token.String:
+		return strings.ContainsRune("svqxX", verb)
+	case token.Boolean:
+		return verb == 't' || verb == 'v'
+	case token.Decimal:
+		switch verb {
+		case 'd', 'x', 'X', 'o', 'b':
+			return value.Decimal.Equals(decimal.NewFromDecimal(value.Decimal).Floor())
+		}
+		return strings.ContainsRune("fFeEgGsv", verb)
+	}
+	return strings.ContainsRune("svq", verb)
+}
+
+// formatDecimal formats a decimal as an integer (%d, %x, %o, %b), float (%f, %e, %g) or its string representation.
+// The digits of the decimal are formatted directly, so there is no precision lost by converting to float64 or int64
+type formatDecimal struct {
+	*token.TaToken
+}
+
+func (d formatDecimal) Format(state fmt.State, verb rune) {
+	negative, digits, point := decimalDigits(d.Decimal)
+	precision, hasPrecision := state.Precision()
+	switch verb {
+	case 'd', 'x', 'X', 'o', 'b':
+		var i big.Int
+		i.SetString(formatFixed(digits, point, 0), 10)
+		if negative {
+			i.Neg(&i)
+		}
+		i.Format(state, verb)
+	case 'f', 'F':
+		if !hasPrecision {
+			precision = 6
+		}
+		digits, point = roundDigits(digits, point, point+precision)
+		writePadded(state, negative, formatFixed(digits, point, precision))
+	case 'e', 'E':
+		if !hasPrecision {
+			precision = 6
+		}
+		digits, point = roundDigits(digits, point, precision+1)
+		writePadded(state, negative, formatExponent(digits, point, precision, verb))
+	case 'g', 'G':
+		writePadded(state, negative, formatGeneral(digits, point, precision, hasPrecision, verb))
+	default:
+		fmt.Fprintf(state, formatDirective(state, verb), d.Decimal.String())
+	}
+}
+
+// decimalDigits returns the significant digits of the absolute value of a decimal and the position of the decimal point,
+// the value is 0.digits * 10^point, e.g. 12.5 returns "125" and 2. Zero has no digits
+func decimalDigits(d decimal.Decimal) (negative bool, digits string, point int) {
+	s := d.String()
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		point, _ = strconv.Atoi(s[i+1:])
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		point += i
+		s = s[:i] + s[i+1:]
+	} else {
+		point += len(s)
+	}
+	for len(s) > 0 && s[0] == '0' {
+		s = s[1:]
+		point--
+	}
+	digits = strings.TrimRight(s, "0")
+	if len(digits) == 0 {
+		point = 0
+	}
+	return negative, digits, point
+}
+
+// roundDigits rounds the digits to n significant digits, ties are rounded to the even neighbour like strconv does.
+// Trailing zeros are removed from the result
+func roundDigits(digits string, point, n int) (string, int) {
+	if n >= len(digits) {
+		return digits, point
+	}
+	if n < 0 {
+		return "", 0
+	}
+	up := digits[n] > '5' || digits[n] == '5' && (len(strings.TrimRight(digits[n+1:], "0")) > 0 || n > 0 && (digits[n-1]-'0')%2 == 1)
+	rounded := []byte(digits[:n])
+	if up {
+		i := n - 1
+		for ; i >= 0 && rounded[i] == '9'; i-- {
+			rounded[i] = '0'
+		}
+		if i >= 0 {
+			rounded[i]++
+		} else {
+			rounded = append([]byte{'1'}, rounded...)
+			point++
+		}
+	}
+	result := strings.TrimRight(string(rounded), "0")
+	if len(result) == 0 {
+		point = 0
+	}
+	return result, point
+}
+
+// formatFixed writes the digits like %f with the given number of decimal places, the digits must already be rounded
+func formatFixed(digits string, point, precision int) string {
+	var builder strings.Builder
+	if point <= 0 {
+		builder.WriteByte('0')
+	}
+	for i := 0; i < point; i++ {
+		builder.WriteByte(digitAt(digits, i))
+	}
+	if precision > 0 {
+		builder.WriteByte('.')
+		for i := 0; i < precision; i++ {
+			builder.WriteByte(digitAt(digits, point+i))
+		}
+	}
+	return builder.String()
+}
+
+// formatExponent writes the digits like %e with the given number of decimal places, the digits must already be rounded
+func formatExponent(digits string, point, precision int, verb rune) string {
+	var builder strings.Builder
+	builder.WriteByte(digitAt(digits, 0))
+	if precision > 0 {
+		builder.WriteByte('.')
+		for i := 1; i <= precision; i++ {
+			builder.WriteByte(digitAt(digits, i))
+		}
+	}
+	exponent := 0
+	if len(digits) > 0 {
+		exponent = point - 1
+	}
+	if verb == 'E' || verb == 'G' {
+		builder.WriteByte('E')
+	} else {
+		builder.WriteByte('e')
+	}
+	if exponent < 0 {
+		builder.WriteByte('-')
+		exponent = -exponent
+	} else {
+		builder.WriteByte('+')
+	}
+	if exponent < 10 {
+		builder.WriteByte('0')
+	}
+	builder.WriteString(strconv.Itoa(exponent))
+	return builder.String()
+}
+
+// formatGeneral writes the digits like %g: %e for large and small exponents, %f otherwise and without trailing zeros.
+// Without a precision all digits are written
+func formatGeneral(digits string, point, precision int, hasPrecision bool, verb rune) string {
+	eprecision := 6
+	if hasPrecision {
+		if precision == 0 {
+			precision = 1
+		}
+		digits, point = roundDigits(digits, point, precision)
+		eprecision = precision
+		if eprecision > len(digits) && len(digits) >= point {
+			eprecision = len(digits)
+		}
+	} else {
+		precision = len(digits)
+	}
+	if exponent := point - 1; len(digits) > 0 && (exponent < -4 || exponent >= eprecision) {
+		if precision > len(digits) {
+			precision = len(digits)
+		}
+		return formatExponent(digits, point, precision-1, verb)
+	}
+	if precision > point {
+		precision = len(digits)
+	}
+	if precision -= point; precision < 0 {
+		precision = 0
+	}
+	return formatFixed(digits, point, precision)
+}
+
+// digitAt returns the digit at the index or 0 if the index is outside of the digits
+func digitAt(digits string, i int) byte {
+	if i < 0 || i >= len(digits) {
+		return '0'
+	}
+	return digits[i]
+}
+
+// writePadded writes the formatted number with its sign and pads it to the width of the directive
+func writePadded(state fmt.State, negative bool, number string) {
+	sign := ""
+	if negative {
+		sign = "-"
+	} else if state.Flag('+') {
+		sign = "+"
+	} else if state.Flag(' ') {
+		sign = " "
+	}
+	width, _ := state.Width()
+	padding := width - len(sign) - len(number)
+	switch {
+	case padding <= 0:
+		fmt.Fprint(state, sign, number)
+	case state.Flag('-'):
+		fmt.Fprint(state, sign, number, strings.Repeat(" ", padding))
+	case state.Flag('0'):
+		fmt.Fprint(state, sign, strings.Repeat("0", padding), number)
+	default:
+		fmt.Fprint(state, strings.Repeat(" ", padding), sign, number)
+	}
+}
+
+// formatDirective rebuilds the directive (like %-5.2f) the state was created for
+func formatDirective(state fmt.State, verb rune) string {
+	var builder strings.Builder
+	builder.WriteRune('%')
+	for _, flag := range "+-# 0" {
+		if state.Flag(int(flag)) {
+			builder.WriteRune(flag)
+		}
+	}
+	if width, ok := state.Width(); ok {
+		builder.WriteString(strconv.Itoa(width))
+	}
+	if precision, ok := state.Precision(); ok {
+		builder.WriteRune('.')
+		builder.WriteString(strconv.Itoa(precision))
+	}
+	builder.WriteRune(verb)
+	return builder.String()
+}
+
+// normalizationForm returns the unicode normalization form with the given name
+func normalizationForm(name string) (norm.Form, error) {
+	switch strings.ToUpper(name) {
+	case "NFC":
+		return norm.NFC, nil
+	case "NFD":
+		return norm.NFD, nil
+	case "NFKC":
+		return norm.NFKC, nil
+	case "NFKD":
+		return norm.NFKD, nil
+	}
+	return 0, fmt.Errorf("Unknown normalization form %s", name)
+}
+
+// removeAccents decomposes the string, removes the combining marks and composes it again
+func removeAccents(s string) (string, error) {
+	// transformers are not safe for concurrent use, so the chain is created for every call
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, s)
+	return result, err
+}
+
+type graphemeClass int
+
+const (
+	graphemeOther graphemeClass = iota
+	graphemeCR
+	graphemeLF
+	graphemeControl
+	graphemeExtend
+	graphemeZWJ
+	graphemeSpacingMark
+	graphemeRegionalIndicator
+	graphemePictographic
+	graphemeL
+	graphemeV
+	graphemeT
+	graphemeLV
+	graphemeLVT
+)
+
+// classifyGrapheme returns the grapheme cluster break property of a rune.
+// It is an approximation: emoji are approximated by the symbol category (So) because the unicode package has no
+// Extended_Pictographic table, and Prepend is not supported, prepended characters are controls or other characters
+func classifyGrapheme(r rune) graphemeClass {
+	switch {
+	case r == '\r':
+		return graphemeCR
+	case r == '\n':
+		return graphemeLF
+	case r == 0x200D:
+		return graphemeZWJ
+	case r == 0x200C, r >= 0x1F3FB && r <= 0x1F3FF, unicode.In(r, unicode.Mn, unicode.Me, unicode.Other_Grapheme_Extend):
+		return graphemeExtend
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return graphemeControl
+	case unicode.Is(unicode.Mc, r):
+		return graphemeSpacingMark
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return graphemeRegionalIndicator
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return graphemeL
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6:
+		return graphemeV
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return graphemeT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return graphemeLV
+		}
+		return graphemeLVT
+	case unicode.Is(unicode.So, r):
+		return graphemePictographic
+	}
+	return graphemeOther
+}
+
+// graphemeCount counts the grapheme clusters in a string following the rules of
+// https://unicode.org/reports/tr29/#Grapheme_Cluster_Boundary_Rules without prepend characters
+func graphemeCount(s string) int {
+	count := 0
+	prev := graphemeControl
+	regionalIndicators := 0 // regional indicators directly before the current rune
+	emoji := false          // the previous runes are an emoji followed by extending runes
+	for i, r := range s {
+		class := classifyGrapheme(r)
+		if i == 0 || graphemeBreak(prev, class, regionalIndicators, emoji) {
+			count++
+		}
+		switch class {
+		case graphemeRegionalIndicator:
+			regionalIndicators++
+		default:
+			regionalIndicators = 0
+		}
+		switch class {
+		case graphemePictographic:
+			emoji = true
+		case graphemeExtend:
+		case graphemeZWJ:
+			emoji = emoji && prev != graphemeZWJ
+		default:
+			emoji = false
+		}
+		prev = class
+	}
+	return count
+}
+
+// graphemeBreak reports whether there is a grapheme cluster boundary between two runes
+func graphemeBreak(prev, next graphemeClass, regionalIndicators int, emoji bool) bool {
+	switch {
+	case prev == graphemeCR && next == graphemeLF:
+		return false
+	case prev == graphemeCR, prev == graphemeLF, prev == graphemeControl:
+		return true
+	case next == graphemeCR, next == graphemeLF, next == graphemeControl:
+		return true
+	case prev == graphemeL && (next == graphemeL || next == graphemeV || next == graphemeLV || next == graphemeLVT):
+		return false
+	case (prev == graphemeLV || prev == graphemeV) && (next == graphemeV || next == graphemeT):
+		return false
+	case (prev == graphemeLVT || prev == graphemeT) && next == graphemeT:
+		return false
+	case next == graphemeExtend, next == graphemeZWJ, next == graphemeSpacingMark:
+		return false
+	case prev == graphemeZWJ && next == graphemePictographic && emoji:
+		return false
+	case prev == graphemeRegionalIndicator && next == graphemeRegionalIndicator:
+		return regionalIndicators%2 == 0
+	}
+	return true
+}
@@ -13,5 +13,35 @@ func AllOperations() []interpreter.TaFunction {
 		Regexp,
 		LastName,
 		FirstName,
+		Upper,
+		Lower,
+		Trim,
+		TrimChars,
+		TrimLeft,
+		TrimLeftChars,
+		TrimRight,
+		TrimRightChars,
+		TrimPrefix,
+		TrimSuffix,
+		Replace,
+		ReplaceAll,
+		Substring,
+		SubstringFrom,
+		Length,
+		IndexOf,
+		PadLeft,
+		PadRight,
+		Repeat,
+		EqualFold,
+		Format,
+		RegexFind,
+		RegexFindAll,
+		RegexGroups,
+		RegexReplace,
+		Normalize,
+		RemoveAccents,
+		EqualIgnoreAccents,
+		GraphemeLength,
+		Words,
 	}
 }
@@ -156,6 +156,14 @@ func TestLastName(t *testing.T) {
 			`lastName "Bond"`,
 			nil,
 			token.NewString("Bond"),
+		}, helpers.Test{
+			"lastName \" Jos\u00e9\tMar\u00eda\u00a0L\u00f3pez \"",
+			nil,
+			token.NewString("L\u00f3pez"),
+		}, helpers.Test{
+			`lastName " "`,
+			nil,
+			token.NewString(""),
 		},
 	)
 }
@@ -170,6 +178,546 @@ func TestFirstName(t *testing.T) {
 			`firstName "Bond"`,
 			nil,
 			token.NewString("Bond"),
+		}, helpers.Test{
+			"firstName \" Jos\u00e9\tMar\u00eda\u00a0L\u00f3pez \"",
+			nil,
+			token.NewString("Jos\u00e9"),
+		}, helpers.Test{
+			`firstName ""`,
+			nil,
+			token.NewString(""),
+		},
+	)
+}
+
+func TestUpperLower(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`upper "Hello World"`,
+			nil,
+			token.NewString("HELLO WORLD"),
+		}, helpers.Test{
+			`upper "grüße"`,
+			nil,
+			token.NewString("GRÜßE"),
+		}, helpers.Test{
+			`lower "Hello World"`,
+			nil,
+			token.NewString("hello world"),
+		}, helpers.Test{
+			`= (lower "SUMMER2018") (lower "Summer2018")`,
+			nil,
+			token.NewBool(true),
+		},
+	)
+}
+
+func TestTrim(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`trim "  Hello World  "`,
+			nil,
+			token.NewString("Hello World"),
+		}, helpers.Test{
+			`trim "--SUMMER-2018--" "-"`,
+			nil,
+			token.NewString("SUMMER-2018"),
+		}, helpers.Test{
+			`trimLeft "  Hello World  "`,
+			nil,
+			token.NewString("Hello World  "),
+		}, helpers.Test{
+			`trimLeft "00042" "0"`,
+			nil,
+			token.NewString("42"),
+		}, helpers.Test{
+			`trimRight "  Hello World  "`,
+			nil,
+			token.NewString("  Hello World"),
+		}, helpers.Test{
+			`trimRight "Hello World!?!" "!?"`,
+			nil,
+			token.NewString("Hello World"),
+		}, helpers.Test{
+			`trimPrefix "SUMMER-2018" "SUMMER-"`,
+			nil,
+			token.NewString("2018"),
+		}, helpers.Test{
+			`trimPrefix "SUMMER-2018" "WINTER-"`,
+			nil,
+			token.NewString("SUMMER-2018"),
+		}, helpers.Test{
+			`trimSuffix "SUMMER-2018" "-2018"`,
+			nil,
+			token.NewString("SUMMER"),
+		},
+	)
+}
+
+func TestReplace(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`replace "Hello World World" "World" "Universe"`,
+			nil,
+			token.NewString("Hello Universe World"),
+		}, helpers.Test{
+			`replaceAll "Hello World World" "World" "Universe"`,
+			nil,
+			token.NewString("Hello Universe Universe"),
+		}, helpers.Test{
+			`replaceAll "SUMMER 2018" " " ""`,
+			nil,
+			token.NewString("SUMMER2018"),
+		}, helpers.Test{
+			`replace "Hello" "World" "Universe"`,
+			nil,
+			token.NewString("Hello"),
+		},
+	)
+}
+
+func TestSubstring(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`substring "Hello World" 0 5`,
+			nil,
+			token.NewString("Hello"),
+		}, helpers.Test{
+			`substring "Hello World" -5 -1`,
+			nil,
+			token.NewString("Worl"),
+		}, helpers.Test{
+			`substring "Grüße" 2 4`,
+			nil,
+			token.NewString("üß"),
+		}, helpers.Test{
+			`substring "Hello World" 6`,
+			nil,
+			token.NewString("World"),
+		}, helpers.Test{
+			`substring "SUMMER-2018" -4`,
+			nil,
+			token.NewString("2018"),
+		}, helpers.Test{
+			`substring "Hello" 5`,
+			nil,
+			token.NewString(""),
+		}, helpers.Test{
+			`substring "Hello" 2 6`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`substring "Hello" 3 2`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`substring "Hello" -6`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestLengthIndexOf(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`length "Hello"`,
+			nil,
+			token.NewDecimalFromInt(5),
+		}, helpers.Test{
+			`length "Grüße"`,
+			nil,
+			token.NewDecimalFromInt(5),
+		}, helpers.Test{
+			`length ""`,
+			nil,
+			token.NewDecimalFromInt(0),
+		}, helpers.Test{
+			`indexOf "Hello World" "World"`,
+			nil,
+			token.NewDecimalFromInt(6),
+		}, helpers.Test{
+			`indexOf "Grüße" "e"`,
+			nil,
+			token.NewDecimalFromInt(4),
+		}, helpers.Test{
+			`indexOf "Hello World" "Universe"`,
+			nil,
+			token.NewDecimalFromInt(-1),
+		},
+	)
+}
+
+func TestPad(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`padLeft "42" 5 "0"`,
+			nil,
+			token.NewString("00042"),
+		}, helpers.Test{
+			`padLeft "42" 5`,
+			nil,
+			token.NewString("   42"),
+		}, helpers.Test{
+			`padLeft "42" 7 "ab"`,
+			nil,
+			token.NewString("ababa42"),
+		}, helpers.Test{
+			`padLeft "Grüße" 6 "·"`,
+			nil,
+			token.NewString("·Grüße"),
+		}, helpers.Test{
+			`padLeft "Hello" 2 "0"`,
+			nil,
+			token.NewString("Hello"),
+		}, helpers.Test{
+			`padLeft "42" 5 ""`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`padRight "42" 5 "-"`,
+			nil,
+			token.NewString("42---"),
+		}, helpers.Test{
+			`padRight "42" 5`,
+			nil,
+			token.NewString("42   "),
+		},
+	)
+}
+
+func TestRepeat(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`repeat "ab" 3`,
+			nil,
+			token.NewString("ababab"),
+		}, helpers.Test{
+			`repeat "ab" 0`,
+			nil,
+			token.NewString(""),
+		}, helpers.Test{
+			`repeat "ab" -1`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`repeat "ab" 1000000000`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestEqualFold(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`equalFold "SUMMER2018" "summer2018"`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`equalFold "Grüße" "GRÜßE"`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`equalFold "SUMMER2018" "summer2019"`,
+			nil,
+			token.NewBool(false),
+		},
+	)
+}
+
+func TestFormat(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`format "%s has %d items" "Alex" 3`,
+			nil,
+			token.NewString("Alex has 3 items"),
+		}, helpers.Test{
+			`format "Total: %.2f" 12.5`,
+			nil,
+			token.NewString("Total: 12.50"),
+		}, helpers.Test{
+			`format "%05d|%-4d|%x" 42 7 255`,
+			nil,
+			token.NewString("00042|7   |ff"),
+		}, helpers.Test{
+			`format "%s %v %t" 1.25 (list 1 2) true`,
+			nil,
+			token.NewString("1.25 [1, 2] true"),
+		}, helpers.Test{
+			`format "100%%"`,
+			nil,
+			token.NewString("100%"),
+		}, helpers.Test{
+			`format "%d" 1.5`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`format "%s %s" "Alex"`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`format "%s" "Alex" "Unger"`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`format "%s" "100%!"`,
+			nil,
+			token.NewString("100%!"),
+		}, helpers.Test{
+			`format "Save 20%%!"`,
+			nil,
+			token.NewString("Save 20%!"),
+		}, helpers.Test{
+			// decimals are formatted without converting them to float64 or int64
+			`format "%.2f|%e|%g" 12345678901234567.25 12345678901234567.25 0.1`,
+			nil,
+			token.NewString("12345678901234567.25|1.234568e+16|0.1"),
+		}, helpers.Test{
+			`format "%d|%x" 123456789012345678901234 -255`,
+			nil,
+			token.NewString("123456789012345678901234|-ff"),
+		}, helpers.Test{
+			// ties are rounded to the even neighbour
+			`format "%.1f %.1f %08.2f %+.0e" 0.25 0.35 -3.14159 1500`,
+			nil,
+			token.NewString("0.2 0.4 -0003.14 +2e+03"),
+		}, helpers.Test{
+			`format "%t" 1`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`format "Total: %"`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestRegexFind(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`regexFind "\d+" "SUMMER-2018-10"`,
+			nil,
+			token.NewString("2018"),
+		}, helpers.Test{
+			`regexFind "\d+" "SUMMER"`,
+			nil,
+			token.NewNull(),
+		}, helpers.Test{
+			`regexFindAll "\d+" "SUMMER-2018-10"`,
+			nil,
+			token.NewList(token.NewString("2018"), token.NewString("10")),
+		}, helpers.Test{
+			`regexFindAll "\d+" "SUMMER"`,
+			nil,
+			token.NewList(),
+		}, helpers.Test{
+			`regexFind "(" "SUMMER"`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestRegexGroups(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`regexGroups "(?P<Season>[A-Z]+)-(?P<Year>\d+)" "Code: SUMMER-2018"`,
+			nil,
+			token.NewOrderedMap([]string{"Season", "Year"}, []*token.TaToken{
+				token.NewString("SUMMER"),
+				token.NewString("2018"),
+			}),
+		}, helpers.Test{
+			`regexGroups "(?P<Season>[A-Z]+)(-(?P<Year>\d+))?" "WINTER"`,
+			nil,
+			token.NewOrderedMap([]string{"Season", "Year"}, []*token.TaToken{
+				token.NewString("WINTER"),
+				token.NewNull(),
+			}),
+		}, helpers.Test{
+			`regexGroups "(?P<Year>\d+)" "SUMMER"`,
+			nil,
+			token.NewNull(),
+		}, helpers.Test{
+			`regexGroups "(\d+)" "2018"`,
+			nil,
+			token.NewMap(map[string]*token.TaToken{}),
+		},
+	)
+}
+
+func TestRegexReplace(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`regexReplace "\s+" "Hello   World  !" " "`,
+			nil,
+			token.NewString("Hello World !"),
+		}, helpers.Test{
+			`regexReplace "(\w+)-(\d+)" "SUMMER-2018" "${2}-${1}"`,
+			nil,
+			token.NewString("2018-SUMMER"),
+		}, helpers.Test{
+			`regexReplace "(?P<Year>\d+)" "SUMMER-2018" "<${Year}>"`,
+			nil,
+			token.NewString("SUMMER-<2018>"),
+		},
+	)
+}
+
+func TestRegexLimits(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	interp.MaxRegexpLength = 10
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			`regexFind "\d+" "SUMMER-2018"`,
+			nil,
+			token.NewString("2018"),
+		}, helpers.Test{
+			`regexFind "[A-Z]+-\d{4}" "SUMMER-2018"`,
+			nil,
+			helpers.Error{},
+		}, helpers.Test{
+			`~ "[A-Z]+-\d{4}" "SUMMER-2018"`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestNormalize(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			"normalize \"Cafe\u0301\"",
+			nil,
+			token.NewString("Caf\u00e9"),
+		}, helpers.Test{
+			"normalize \"Caf\u00e9\" \"nfd\"",
+			nil,
+			token.NewString("Cafe\u0301"),
+		}, helpers.Test{
+			"normalize \"\ufb01le \u00bd\" \"NFKC\"",
+			nil,
+			token.NewString("file 1\u20442"),
+		}, helpers.Test{
+			"normalize \"\ufb01le\" \"NFC\"",
+			nil,
+			token.NewString("\ufb01le"),
+		}, helpers.Test{
+			`normalize "Hello" "NFX"`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
+
+func TestRemoveAccents(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`removeAccents "Crème Brûlée"`,
+			nil,
+			token.NewString("Creme Brulee"),
+		}, helpers.Test{
+			"removeAccents \"Cre\u0300me\"",
+			nil,
+			token.NewString("Creme"),
+		}, helpers.Test{
+			`removeAccents "Øre Straße"`,
+			nil,
+			token.NewString("Øre Straße"),
+		}, helpers.Test{
+			`equalIgnoreAccents "Muller" "Müller"`,
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			"equalIgnoreAccents \"M\u00fcller\" \"Mu\u0308ller\"",
+			nil,
+			token.NewBool(true),
+		}, helpers.Test{
+			`equalIgnoreAccents "muller" "Müller"`,
+			nil,
+			token.NewBool(false),
+		}, helpers.Test{
+			`equalIgnoreAccents "Müller" "Miller"`,
+			nil,
+			token.NewBool(false),
+		},
+	)
+}
+
+func TestGraphemeLength(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`graphemeLength "Grüße"`,
+			nil,
+			token.NewDecimalFromInt(5),
+		}, helpers.Test{
+			"graphemeLength \"Gru\u0308\u00dfe\"",
+			nil,
+			token.NewDecimalFromInt(5),
+		}, helpers.Test{
+			`graphemeLength ""`,
+			nil,
+			token.NewDecimalFromInt(0),
+		}, helpers.Test{
+			// thumbs up with skin tone, flags of Germany and France
+			"graphemeLength \"\U0001F44D\U0001F3FD\U0001F1E9\U0001F1EA\U0001F1EB\U0001F1F7\"",
+			nil,
+			token.NewDecimalFromInt(3),
+		}, helpers.Test{
+			// family emoji joined with zero width joiners
+			"graphemeLength \"\U0001F468\u200d\U0001F469\u200d\U0001F467!\"",
+			nil,
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			// hangul syllable from conjoining jamo
+			"graphemeLength \"\u1112\u1161\u11ab\uac00\"",
+			nil,
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			// devanagari with a spacing mark
+			"graphemeLength \"\u0915\u093f\"",
+			nil,
+			token.NewDecimalFromInt(1),
+		}, helpers.Test{
+			"graphemeLength \"a\r\nb\"",
+			nil,
+			token.NewDecimalFromInt(3),
+		}, helpers.Test{
+			// limits of the approximation: Unicode counts a double exclamation mark joined with a zero width joiner as one,
+			// but it is punctuation and not a symbol, so the approximation counts two
+			"graphemeLength \"\u203c\u200d\u203c\"",
+			nil,
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			// the arabic number sign is prepended to the following digit in Unicode, the approximation counts two
+			"graphemeLength \"\u0600\u0661\"",
+			nil,
+			token.NewDecimalFromInt(2),
+		}, helpers.Test{
+			// the malayalam dot reph is prepended as well
+			"graphemeLength \"\u0d4e\u0d15\"",
+			nil,
+			token.NewDecimalFromInt(2),
+		},
+	)
+}
+
+func TestWords(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`words "Hello, World!"`,
+			nil,
+			token.NewList(token.NewString("Hello"), token.NewString("World")),
+		}, helpers.Test{
+			"words \" Jean-Luc\u00a0O'Brien\t- \u00bfQu\u00e9? \"",
+			nil,
+			token.NewList(token.NewString("Jean-Luc"), token.NewString("O'Brien"), token.NewString("Qu\u00e9")),
+		}, helpers.Test{
+			`words " "`,
+			nil,
+			token.NewList(),
 		},
 	)
 }
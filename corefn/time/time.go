@@ -2,11 +2,16 @@
 package time
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"time"
 
+	_ "time/tzdata" // zone data for inZone, so it does not depend on the system
+
 	"github.com/araddon/dateparse"
+	"github.com/talon-one/decimal"
 	"github.com/talon-one/talang/interpreter"
 	"github.com/talon-one/talang/token"
 	"github.com/vjeantet/jodaTime"
@@ -107,7 +112,7 @@ var ParseTime = interpreter.TaFunction{
 				return nil, err
 			}
 		} else {
-			date, err = dateparse.ParseAny(args[0].String)
+			date, err = dateparse.ParseIn(args[0].String, interp.CurrentLocation())
 			if err != nil {
 				return nil, err
 			}
@@ -130,7 +135,7 @@ var Hour = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		hour := strconv.Itoa(args[0].Time.Hour())
+		hour := strconv.Itoa(localTime(interp, args[0]).Hour())
 		return token.NewString(hour), nil
 	},
 }
@@ -149,7 +154,7 @@ var Minute = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		minute := strconv.Itoa(args[0].Time.Minute())
+		minute := strconv.Itoa(localTime(interp, args[0]).Minute())
 		return token.NewString(minute), nil
 	},
 }
@@ -168,7 +173,7 @@ var Date = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		return token.NewString(jodaTime.Format("yyyy-MM-dd", args[0].Time)), nil
+		return token.NewString(jodaTime.Format("yyyy-MM-dd", localTime(interp, args[0]))), nil
 	},
 }
 
@@ -186,7 +191,7 @@ var Month = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		month := strconv.Itoa(int(args[0].Time.Month()))
+		month := strconv.Itoa(int(localTime(interp, args[0]).Month()))
 		return token.NewString(month), nil
 	},
 }
@@ -205,7 +210,7 @@ var MonthDay = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		monthDay := strconv.Itoa(int(args[0].Time.Day()))
+		monthDay := strconv.Itoa(int(localTime(interp, args[0]).Day()))
 		return token.NewString(monthDay), nil
 	},
 }
@@ -225,7 +230,7 @@ var WeekDay = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		return token.NewString(jodaTime.Format("e", args[0].Time)), nil
+		return token.NewString(jodaTime.Format("e", localTime(interp, args[0]))), nil
 	},
 }
 
@@ -243,7 +248,7 @@ var Year = interpreter.TaFunction{
 `,
 	},
 	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
-		year := strconv.Itoa(int(args[0].Time.Year()))
+		year := strconv.Itoa(int(localTime(interp, args[0]).Year()))
 		return token.NewString(year), nil
 	},
 }
@@ -379,19 +384,590 @@ daysBetween 2006-01-02T19:04:05Z 2006-01-02T22:19:05Z            ; returns "0.13
 	},
 }
 
-func makeDuration(n *token.TaToken, unit string) (time.Duration, error) {
-	var multiplier int64
+var AddCalendar = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "addCalendar",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,    // since
+			token.Decimal, // amount
+			token.String,  // units
+		},
+		Returns: token.Time,
+		Description: "Add calendar units (years, months, weeks or days) to a time, the time of the day is kept even across daylight saving time changes. " +
+			"If the day does not exist in the resulting month it is clamped to the last day of the month",
+		Example: `
+(addCalendar 2018-01-15T10:00:00Z 1 months)                      ; returns "2018-02-15T10:00:00Z"
+(addCalendar 2018-01-31T10:00:00Z 1 months)                      ; returns "2018-02-28T10:00:00Z"
+(addCalendar 2016-02-29 1 years)                                 ; returns "2017-02-28"
+(addCalendar 2018-03-18T00:04:05Z 2 weeks)                       ; returns "2018-04-01T00:04:05Z"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return addCalendar(interp, args[0], args[1], args[2].String, 1)
+	},
+}
+
+var SubCalendar = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "subCalendar",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,    // since
+			token.Decimal, // amount
+			token.String,  // units
+		},
+		Returns:     token.Time,
+		Description: "Subtract calendar units (years, months, weeks or days) from a time, see addCalendar for the clamping rules",
+		Example: `
+(subCalendar 2018-03-31T10:00:00Z 1 months)                      ; returns "2018-02-28T10:00:00Z"
+(subCalendar 2018-03-18 3 days)                                  ; returns "2018-03-15"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return addCalendar(interp, args[0], args[1], args[2].String, -1)
+	},
+}
+
+var MonthsBetween = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "monthsBetween",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+			token.Time,
+		},
+		Returns:     token.Decimal,
+		Description: "Calculates the number of whole months between 2 times, negative if the second time is before the first one",
+		Example: `
+(monthsBetween 2018-01-15 2018-03-14)                            ; returns "1"
+(monthsBetween 2018-01-31 2018-02-28)                            ; returns "1"
+(monthsBetween 2018-03-15 2018-01-15)                            ; returns "-2"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDecimalFromInt(int64(monthsBetween(localTime(interp, args[0]), localTime(interp, args[1])))), nil
+	},
+}
+
+var YearsBetween = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "yearsBetween",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+			token.Time,
+		},
+		Returns:     token.Decimal,
+		Description: "Calculates the number of whole years between 2 times, negative if the second time is before the first one",
+		Example: `
+(yearsBetween 1990-05-20 2018-05-19)                             ; returns "27"
+(yearsBetween 2016-02-29 2017-02-28)                             ; returns "1"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDecimalFromInt(int64(monthsBetween(localTime(interp, args[0]), localTime(interp, args[1])) / 12)), nil
+	},
+}
+
+var Duration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "duration",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Decimal, // amount
+			token.String,  // units
+		},
+		Returns:     token.Duration,
+		Description: "Create a duration from an amount and a unit (weeks, days, hours, minutes or seconds)",
+		Example: `
+(duration 3 hours)                                               ; returns 3h0m0s
+(duration 1.5 days)                                              ; returns 36h0m0s
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		unit, err := durationUnit(args[1].String)
+		if err != nil {
+			return nil, err
+		}
+		f, err := args[0].Decimal.Float64()
+		if err != nil {
+			return nil, err
+		}
+		d, err := floatDuration(f * float64(unit))
+		if err != nil {
+			return nil, err
+		}
+		return token.NewDuration(d), nil
+	},
+}
+
+var ParseDuration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "parseDuration",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.String,
+		},
+		Returns:     token.Duration,
+		Description: "Parse a go (3h30m) or ISO 8601 (P1DT2H) duration",
+		Example: `
+(parseDuration "1h30m")                                          ; returns 1h30m0s
+(parseDuration "P1DT2H")                                         ; returns 26h0m0s
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		d, ok := token.ParseDuration(args[0].String)
+		if !ok {
+			return nil, fmt.Errorf("invalid duration %q", args[0].String)
+		}
+		return token.NewDuration(d), nil
+	},
+}
+
+var DurationIn = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "durationIn",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.String, // units
+		},
+		Returns:     token.Decimal,
+		Description: "Convert a duration to a decimal in the unit (weeks, days, hours, minutes or seconds)",
+		Example: `
+(durationIn 90m hours)                                           ; returns 1.5
+(durationIn P2D hours)                                           ; returns 48
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		unit, err := durationUnit(args[1].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewDecimal(decimal.Div(decimal.NewFromInt64(int64(args[0].Duration)), decimal.NewFromInt64(int64(unit)))), nil
+	},
+}
+
+var AddDurationToTime = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "+",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Time,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Time,
+		Description: "Adds the durations to the time",
+		Example: `
+(+ 2018-03-18T00:04:05Z 3m)                                      ; returns 2018-03-18T00:07:05Z
+(+ 2018-03-18T00:04:05Z P1D 2h)                                  ; returns 2018-03-19T02:04:05Z
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		t := args[0].Time
+		for i := 1; i < len(args); i++ {
+			t = t.Add(args[i].Duration)
+		}
+		return token.NewTime(t), nil
+	},
+}
+
+var SubDurationFromTime = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "-",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Time,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Time,
+		Description: "Subtracts the durations from the time",
+		Example: `
+(- 2018-03-18T00:04:05Z 12m)                                     ; returns 2018-03-17T23:52:05Z
+(- 2018-03-18T00:04:05Z P1D 2h)                                  ; returns 2018-03-16T22:04:05Z
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		t := args[0].Time
+		for i := 1; i < len(args); i++ {
+			t = t.Add(-args[i].Duration)
+		}
+		return token.NewTime(t), nil
+	},
+}
+
+var SubTimes = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "-",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+			token.Time,
+		},
+		Returns:     token.Duration,
+		Description: "Calculates the duration between the second and the first time",
+		Example: `
+(- 2018-03-18T02:04:05Z 2018-03-18T00:04:05Z)                    ; returns 2h0m0s
+(- 2018-03-18T00:04:05Z 2018-03-19T00:04:05Z)                    ; returns -24h0m0s
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDuration(args[0].Time.Sub(args[1].Time)), nil
+	},
+}
+
+var AddDurations = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "+",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Duration,
+		Description: "Adds the durations",
+		Example: `
+(+ 1h 30m)                                                       ; returns 1h30m0s
+(+ P1D 2h 30s)                                                   ; returns 26h0m30s
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		d := args[0].Duration
+		for i := 1; i < len(args); i++ {
+			var err error
+			if d, err = addDurations(d, args[i].Duration); err != nil {
+				return nil, err
+			}
+		}
+		return token.NewDuration(d), nil
+	},
+}
+
+var SubDurations = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "-",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Duration,
+			token.Duration,
+		},
+		Returns:     token.Duration,
+		Description: "Subtracts the durations",
+		Example: `
+(- 1h 30m)                                                       ; returns 30m0s
+(- P1D 2h 30m)                                                   ; returns 21h30m0s
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		d := args[0].Duration
+		for i := 1; i < len(args); i++ {
+			if args[i].Duration == math.MinInt64 {
+				return nil, errors.New(durationRangeError)
+			}
+			var err error
+			if d, err = addDurations(d, -args[i].Duration); err != nil {
+				return nil, err
+			}
+		}
+		return token.NewDuration(d), nil
+	},
+}
+
+var MulDuration = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "*",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Duration,
+			token.Decimal,
+		},
+		Returns:     token.Duration,
+		Description: "Multiplies the duration",
+		Example: `
+(* 1h 3)                                                         ; returns 3h0m0s
+(* 1h 0.5)                                                       ; returns 30m0s
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		f, err := args[1].Decimal.Float64()
+		if err != nil {
+			return nil, err
+		}
+		d, err := floatDuration(float64(args[0].Duration) * f)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewDuration(d), nil
+	},
+}
+
+var InZone = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "inZone",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+			token.String, // IANA time zone
+		},
+		Returns:     token.Time,
+		Description: "Convert a time to the time zone, calendar functions (hour, weekday, startOfDay, ...) use the zone of the time",
+		Example: `
+(inZone 2018-03-16T23:30:00Z Europe/Berlin)                      ; returns 2018-03-17T00:30:00+01:00
+(weekday (inZone 2018-03-16T23:30:00Z Europe/Berlin))            ; returns "6"
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		loc, err := time.LoadLocation(args[1].String)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewTime(localTime(interp, args[0]).In(loc)), nil
+	},
+}
+
+var StartOfDay = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "startOfDay",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+		},
+		Returns:     token.Time,
+		Description: "Returns the first instant of the day of the time",
+		Example: `
+(startOfDay 2018-03-16T19:04:05Z)                                ; returns 2018-03-16T00:00:00Z
+(startOfDay (inZone 2018-03-16T23:30:00Z Europe/Berlin))         ; returns 2018-03-17T00:00:00+01:00
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewTime(startOfDay(localTime(interp, args[0]))), nil
+	},
+}
+
+var EndOfDay = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "endOfDay",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+		},
+		Returns:     token.Time,
+		Description: "Returns the last instant (nanosecond) of the day of the time",
+		Example: `
+(endOfDay 2018-03-16T19:04:05Z)                                  ; returns 2018-03-16T23:59:59.999999999Z
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		t := startOfDay(localTime(interp, args[0]))
+		return token.NewTime(t.AddDate(0, 0, 1).Add(-time.Nanosecond)), nil
+	},
+}
+
+var StartOfWeek = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "startOfWeek",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+		},
+		Returns:     token.Time,
+		Description: "Returns the first instant of the (ISO 8601) week of the time, weeks start on monday",
+		Example: `
+(startOfWeek 2018-03-16T19:04:05Z)                               ; returns 2018-03-12T00:00:00Z
+(startOfWeek 2018-03-18T19:04:05Z)                               ; returns 2018-03-12T00:00:00Z
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		t := startOfDay(localTime(interp, args[0]))
+		// time.Weekday starts on sunday
+		offset := (int(t.Weekday()) + 6) % 7
+		return token.NewTime(t.AddDate(0, 0, -offset)), nil
+	},
+}
+
+var StartOfMonth = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "startOfMonth",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+		},
+		Returns:     token.Time,
+		Description: "Returns the first instant of the month of the time",
+		Example: `
+(startOfMonth 2018-03-16T19:04:05Z)                              ; returns 2018-03-01T00:00:00Z
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewTime(startOfMonth(localTime(interp, args[0]))), nil
+	},
+}
+
+var EndOfMonth = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "endOfMonth",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+		},
+		Returns:     token.Time,
+		Description: "Returns the last instant (nanosecond) of the month of the time",
+		Example: `
+(endOfMonth 2018-02-16T19:04:05Z)                                ; returns 2018-02-28T23:59:59.999999999Z
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		t := startOfMonth(localTime(interp, args[0]))
+		return token.NewTime(t.AddDate(0, 1, 0).Add(-time.Nanosecond)), nil
+	},
+}
+
+var ToDate = interpreter.TaFunction{
+	CommonSignature: interpreter.CommonSignature{
+		Name:       "toDate",
+		IsVariadic: false,
+		Arguments: []token.Kind{
+			token.Time,
+		},
+		Returns:     token.Time,
+		Description: "Returns the calendar date (without a time component) of the time",
+		Example: `
+(toDate 2018-03-16T19:04:05Z)                                    ; returns 2018-03-16
+(toDate (inZone 2018-03-16T23:30:00Z Europe/Berlin))             ; returns 2018-03-17
+`,
+	},
+	Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		return token.NewDate(localTime(interp, args[0])), nil
+	},
+}
+
+// localTime returns the time calendar functions should work with:
+// dates are placed in the default location of the interpreter, times in UTC are converted to the default location
+// and times with any other zone (e.g. set by inZone) are kept as they are
+func localTime(interp *interpreter.Interpreter, tkn *token.TaToken) time.Time {
+	loc := interp.CurrentLocation()
+	if tkn.DateOnly {
+		return time.Date(tkn.Time.Year(), tkn.Time.Month(), tkn.Time.Day(), 0, 0, 0, 0, loc)
+	}
+	if tkn.Time.Location() == time.UTC {
+		return tkn.Time.In(loc)
+	}
+	return tkn.Time
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// addCalendar adds sign * amount calendar units to the time
+func addCalendar(interp *interpreter.Interpreter, tkn *token.TaToken, amount *token.TaToken, unit string, sign int) (*token.TaToken, error) {
+	if !amount.Decimal.Equals(decimal.Floor(amount.Decimal)) {
+		return nil, fmt.Errorf("amount %s is not a whole number", amount.String)
+	}
+	n, err := amount.Decimal.Int()
+	if err != nil {
+		return nil, err
+	}
+	n *= sign
+
+	t := localTime(interp, tkn)
 	switch unit {
+	case "years":
+		t = addMonths(t, n*12)
+	case "months":
+		t = addMonths(t, n)
+	case "weeks":
+		t = t.AddDate(0, 0, n*7)
 	case "days":
-		multiplier = int64(time.Hour) * 24
+		t = t.AddDate(0, 0, n)
+	default:
+		return nil, fmt.Errorf("invalid calendar unit %q", unit)
+	}
+
+	if tkn.DateOnly {
+		return token.NewDate(t), nil
+	}
+	return token.NewTime(t), nil
+}
+
+// addMonths adds n months to t, unlike time.AddDate the day is clamped to the last day of the resulting month
+// (2018-01-31 + 1 month = 2018-02-28 instead of 2018-03-03)
+func addMonths(t time.Time, n int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	if last := daysIn(first.Year(), first.Month()); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// monthsBetween returns the whole months between from and to, so that addMonths(from, n) does not pass to
+func monthsBetween(from, to time.Time) int {
+	n := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if n > 0 && addMonths(from, n).After(to) {
+		n--
+	} else if n < 0 && addMonths(from, n).Before(to) {
+		n++
+	}
+	return n
+}
+
+const durationRangeError = "Duration is out of range, durations cannot be longer than about 292 years"
+
+func durationUnit(unit string) (time.Duration, error) {
+	switch unit {
+	case "weeks":
+		return time.Hour * 24 * 7, nil
+	case "days":
+		return time.Hour * 24, nil
 	case "hours":
-		multiplier = int64(time.Hour)
+		return time.Hour, nil
 	case "minutes":
-		multiplier = int64(time.Minute)
-	default:
-		return 0, fmt.Errorf("invalid duration unit %q", unit)
+		return time.Minute, nil
+	case "seconds":
+		return time.Second, nil
+	}
+	return 0, fmt.Errorf("invalid duration unit %q", unit)
+}
+
+func makeDuration(n *token.TaToken, unit string) (time.Duration, error) {
+	multiplier, err := durationUnit(unit)
+	if err != nil {
+		return 0, err
 	}
 	trg, _ := n.Decimal.Int64()
-	result := multiplier * trg
-	return time.Duration(result), nil
+	if trg > math.MaxInt64/int64(multiplier) || trg < math.MinInt64/int64(multiplier) {
+		return 0, errors.New(durationRangeError)
+	}
+	return multiplier * time.Duration(trg), nil
+}
+
+// addDurations adds two durations, durations cannot be longer than about 292 years
+func addDurations(a, b time.Duration) (time.Duration, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, errors.New(durationRangeError)
+	}
+	return sum, nil
+}
+
+// floatDuration converts nanoseconds to a duration, durations cannot be longer than about 292 years
+func floatDuration(nanoseconds float64) (time.Duration, error) {
+	if math.IsNaN(nanoseconds) || nanoseconds >= math.MaxInt64 || nanoseconds < math.MinInt64 {
+		return 0, errors.New(durationRangeError)
+	}
+	return time.Duration(nanoseconds), nil
 }
@@ -21,5 +21,25 @@ func AllOperations() []interpreter.TaFunction {
 		AddDuration,
 		SubDuration,
 		DaysBetween,
+		AddCalendar,
+		SubCalendar,
+		MonthsBetween,
+		YearsBetween,
+		Duration,
+		ParseDuration,
+		DurationIn,
+		AddDurationToTime,
+		SubDurationFromTime,
+		SubTimes,
+		AddDurations,
+		SubDurations,
+		MulDuration,
+		InZone,
+		StartOfDay,
+		EndOfDay,
+		StartOfWeek,
+		StartOfMonth,
+		EndOfMonth,
+		ToDate,
 	}
 }
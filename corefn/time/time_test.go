@@ -210,3 +210,331 @@ func TestDaysBetween(t *testing.T) {
 		token.NewDecimalFromFloat(0.13541666666666666),
 	})
 }
+
+func TestDuration(t *testing.T) {
+	helpers.RunTests(t, helpers.Test{
+		`duration 3 "hours"`,
+		nil,
+		token.NewDuration(3 * time.Hour),
+	}, helpers.Test{
+		`duration 1.5 "days"`,
+		nil,
+		token.NewDuration(36 * time.Hour),
+	}, helpers.Test{
+		`duration 1 "eons"`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		// durations cannot be longer than about 292 years
+		`duration 1000000 "days"`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`duration -1000000 "days"`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`* P1000D 1000`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`+ P50000D P50000D P50000D`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`addDuration 2018-01-01T00:00:00Z 1000000 days`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`parseDuration "P1DT2H"`,
+		nil,
+		token.NewDuration(26 * time.Hour),
+	}, helpers.Test{
+		`parseDuration "1h30m"`,
+		nil,
+		token.NewDuration(90 * time.Minute),
+	}, helpers.Test{
+		`parseDuration "P1M"`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`durationIn 90m "hours"`,
+		nil,
+		token.NewDecimalFromString("1.5"),
+	}, helpers.Test{
+		`durationIn P2W "days"`,
+		nil,
+		token.NewDecimalFromInt(14),
+	})
+}
+
+func TestDurationArithmetic(t *testing.T) {
+	_time1, _ := time.Parse(time.RFC3339, "2018-03-18T00:07:05Z")
+	_time2, _ := time.Parse(time.RFC3339, "2018-03-19T02:04:05Z")
+	_time3, _ := time.Parse(time.RFC3339, "2018-03-16T22:04:05Z")
+	helpers.RunTests(t, helpers.Test{
+		`+ 2018-03-18T00:04:05Z 3m`,
+		nil,
+		token.NewTime(_time1),
+	}, helpers.Test{
+		`+ 2018-03-18T00:04:05Z P1D 2h`,
+		nil,
+		token.NewTime(_time2),
+	}, helpers.Test{
+		`- 2018-03-18T00:04:05Z P1D 2h`,
+		nil,
+		token.NewTime(_time3),
+	}, helpers.Test{
+		`- 2018-03-18T02:04:05Z 2018-03-18T00:04:05Z`,
+		nil,
+		token.NewDuration(2 * time.Hour),
+	}, helpers.Test{
+		`+ P1D 2h 30s`,
+		nil,
+		token.NewDuration(26*time.Hour + 30*time.Second),
+	}, helpers.Test{
+		`- 1h 30m`,
+		nil,
+		token.NewDuration(30 * time.Minute),
+	}, helpers.Test{
+		`* 1h 0.5`,
+		nil,
+		token.NewDuration(30 * time.Minute),
+	}, helpers.Test{
+		`+ 1h 1`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		// durations can be stored in bindings
+		`+ (. Start) (. Length)`,
+		token.NewMap(map[string]*token.TaToken{
+			"Start":  token.NewTime(_time1),
+			"Length": token.NewDuration(-3 * time.Minute),
+		}),
+		token.NewTime(_time1.Add(-3 * time.Minute)),
+	})
+}
+
+func TestZones(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	helpers.RunTests(t, helpers.Test{
+		`inZone 2018-03-16T23:30:00Z Europe/Berlin`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 17, 0, 30, 0, 0, berlin)),
+	}, helpers.Test{
+		`weekday (inZone 2018-03-16T23:30:00Z Europe/Berlin)`,
+		nil,
+		token.NewString("6"),
+	}, helpers.Test{
+		`weekday 2018-03-16T23:30:00Z`,
+		nil,
+		token.NewString("5"),
+	}, helpers.Test{
+		`inZone 2018-03-16T23:30:00Z Unknown/Zone`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`startOfDay (inZone 2018-03-16T23:30:00Z Europe/Berlin)`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 17, 0, 0, 0, 0, berlin)),
+	}, helpers.Test{
+		`endOfDay 2018-03-16T19:04:05Z`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 16, 23, 59, 59, 999999999, time.UTC)),
+	}, helpers.Test{
+		`startOfWeek 2018-03-18T19:04:05Z`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 12, 0, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		`startOfWeek 2018-03-12T00:00:00Z`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 12, 0, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		`startOfMonth 2018-03-16T19:04:05Z`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		`endOfMonth 2016-02-16T19:04:05Z`,
+		nil,
+		token.NewTime(time.Date(2016, 2, 29, 23, 59, 59, 999999999, time.UTC)),
+	}, helpers.Test{
+		// daylight saving time starts on 2018-03-25 in berlin
+		`endOfDay (inZone 2018-03-25T12:00:00Z Europe/Berlin)`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 25, 23, 59, 59, 999999999, berlin)),
+	})
+}
+
+func TestDates(t *testing.T) {
+	helpers.RunTests(t, helpers.Test{
+		`toDate 2018-03-16T19:04:05Z`,
+		nil,
+		token.NewDate(time.Date(2018, 3, 16, 0, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		`toDate (inZone 2018-03-16T23:30:00Z Europe/Berlin)`,
+		nil,
+		token.NewDate(time.Date(2018, 3, 17, 0, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		`weekday 2018-03-17`,
+		nil,
+		token.NewString("6"),
+	}, helpers.Test{
+		`date 2018-03-17`,
+		nil,
+		token.NewString("2018-03-17"),
+	})
+}
+
+func TestInterpreterLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp := helpers.MustNewInterpreter()
+	interp.Location = berlin
+
+	// times in UTC are shown in the default location
+	if result := interp.MustLexAndEvaluate(`weekday 2018-03-16T23:30:00Z`); result.String != "6" {
+		t.Fatalf("expected weekday 6, got %s", result.String)
+	}
+	if result := interp.MustLexAndEvaluate(`hour 2018-03-16T23:30:00Z`); result.String != "0" {
+		t.Fatalf("expected hour 0, got %s", result.String)
+	}
+	// dates start at midnight of the default location
+	if result := interp.MustLexAndEvaluate(`startOfDay 2018-03-17`); !result.Time.Equal(time.Date(2018, 3, 17, 0, 0, 0, 0, berlin)) {
+		t.Fatalf("unexpected start of day %s", result.String)
+	}
+	// zone-less strings are parsed in the default location
+	if result := interp.MustLexAndEvaluate(`parseTime "2018-03-16 12:00:00"`); !result.Time.Equal(time.Date(2018, 3, 16, 12, 0, 0, 0, berlin)) {
+		t.Fatalf("unexpected parsed time %s", result.String)
+	}
+	// scopes use the location of their parent
+	if scope := interp.NewScope(); scope.CurrentLocation() != berlin {
+		t.Fatalf("expected scope to inherit the location")
+	}
+}
+
+func TestCalendarArithmetic(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(year int, month time.Month, day int) *token.TaToken {
+		return token.NewDate(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+	}
+	helpers.RunTests(t, helpers.Test{
+		`addCalendar 2018-01-15T10:00:00Z 1 months`,
+		nil,
+		token.NewTime(time.Date(2018, 2, 15, 10, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		// the day gets clamped to the end of the month
+		`addCalendar 2018-01-31T10:00:00Z 1 months`,
+		nil,
+		token.NewTime(time.Date(2018, 2, 28, 10, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		`addCalendar 2016-01-31 1 months`,
+		nil,
+		date(2016, 2, 29),
+	}, helpers.Test{
+		`addCalendar 2018-01-31 3 months`,
+		nil,
+		date(2018, 4, 30),
+	}, helpers.Test{
+		`addCalendar 2018-11-30 3 months`,
+		nil,
+		date(2019, 2, 28),
+	}, helpers.Test{
+		`addCalendar 2016-02-29 1 years`,
+		nil,
+		date(2017, 2, 28),
+	}, helpers.Test{
+		`addCalendar 2016-02-29 4 years`,
+		nil,
+		date(2020, 2, 29),
+	}, helpers.Test{
+		`addCalendar 2018-03-18 2 weeks`,
+		nil,
+		date(2018, 4, 1),
+	}, helpers.Test{
+		`addCalendar 2018-03-31 1 days`,
+		nil,
+		date(2018, 4, 1),
+	}, helpers.Test{
+		`addCalendar 2018-01-31 -1 months`,
+		nil,
+		date(2017, 12, 31),
+	}, helpers.Test{
+		// the wall clock time is kept across daylight saving time changes
+		`addCalendar (inZone 2018-03-24T11:00:00Z Europe/Berlin) 1 days`,
+		nil,
+		token.NewTime(time.Date(2018, 3, 25, 12, 0, 0, 0, berlin)),
+	}, helpers.Test{
+		`addCalendar 2018-01-31 1.5 months`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`addCalendar 2018-01-31 1 hours`,
+		nil,
+		helpers.Error{},
+	}, helpers.Test{
+		`subCalendar 2018-03-31T10:00:00Z 1 months`,
+		nil,
+		token.NewTime(time.Date(2018, 2, 28, 10, 0, 0, 0, time.UTC)),
+	}, helpers.Test{
+		`subCalendar 2017-02-28 1 years`,
+		nil,
+		date(2016, 2, 28),
+	}, helpers.Test{
+		`subCalendar 2018-03-18 3 days`,
+		nil,
+		date(2018, 3, 15),
+	})
+}
+
+func TestCalendarBetween(t *testing.T) {
+	helpers.RunTests(t, helpers.Test{
+		`monthsBetween 2018-01-15 2018-03-14`,
+		nil,
+		token.NewDecimalFromInt(1),
+	}, helpers.Test{
+		`monthsBetween 2018-01-15 2018-03-15`,
+		nil,
+		token.NewDecimalFromInt(2),
+	}, helpers.Test{
+		// clamped end of month counts as a whole month
+		`monthsBetween 2018-01-31 2018-02-28`,
+		nil,
+		token.NewDecimalFromInt(1),
+	}, helpers.Test{
+		`monthsBetween 2018-01-15T10:00:00Z 2018-02-15T09:59:59Z`,
+		nil,
+		token.NewDecimalFromInt(0),
+	}, helpers.Test{
+		`monthsBetween 2018-03-15 2018-01-15`,
+		nil,
+		token.NewDecimalFromInt(-2),
+	}, helpers.Test{
+		`monthsBetween 2018-03-15 2018-01-16`,
+		nil,
+		token.NewDecimalFromInt(-1),
+	}, helpers.Test{
+		`yearsBetween 1990-05-20 2018-05-19`,
+		nil,
+		token.NewDecimalFromInt(27),
+	}, helpers.Test{
+		`yearsBetween 1990-05-20 2018-05-20`,
+		nil,
+		token.NewDecimalFromInt(28),
+	}, helpers.Test{
+		`yearsBetween 2016-02-29 2017-02-28`,
+		nil,
+		token.NewDecimalFromInt(1),
+	}, helpers.Test{
+		`yearsBetween 2018-05-20 1990-05-21`,
+		nil,
+		token.NewDecimalFromInt(-27),
+	})
+}
@@ -11,6 +11,7 @@ require (
 	github.com/talon-one/decimal v0.0.0-20181115143752-07129d6a48dc
 	github.com/talon-one/runes v0.0.0-20180307151450-47145e1bb254
 	github.com/vjeantet/jodaTime v0.0.0-20170816150230-be924ce213fb
+	golang.org/x/text v0.16.0
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 )
 
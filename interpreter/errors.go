@@ -69,3 +69,39 @@ type FunctionNotRanError struct {
 func (err FunctionNotRanError) Error() string {
 	return fmt.Sprintf("Not Running Function `%s': %s", err.function.CommonSignature.String(), err.Reason.Error())
 }
+
+type MacroError struct {
+	macro *TaMacro
+	error
+}
+
+func (err MacroError) Error() string {
+	return fmt.Sprintf("Error in macro `%s': %s", err.macro.CommonSignature.String(), err.error.Error())
+}
+
+type TemplateError struct {
+	template *TaTemplate
+	error
+}
+
+func (err TemplateError) Error() string {
+	return fmt.Sprintf("Error in template `%s': %s", err.template.String(), err.error.Error())
+}
+
+type TemplateConflictError struct {
+	Namespace string
+	Templates []TaTemplate
+}
+
+func (err TemplateConflictError) Error() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "Conflicting templates in library `%s':", err.Namespace)
+
+	for i := 0; i < len(err.Templates); i++ {
+		builder.WriteRune('\n')
+		builder.WriteString("  ")
+		builder.WriteString(err.Templates[i].String())
+	}
+	return builder.String()
+}
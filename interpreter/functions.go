@@ -29,6 +29,9 @@ func (interp *Interpreter) RegisterFunction(signatures ...TaFunction) error {
 		if interp.GetFunction(&signature) != nil {
 			return errors.Errorf("Function `%s' is already registered", signature.Name)
 		}
+		if err := interp.checkRecordTypes(&signature.CommonSignature); err != nil {
+			return err
+		}
 		interp.Functions = append(interp.Functions, signature)
 	}
 	return nil
@@ -43,6 +46,9 @@ func (interp *Interpreter) MustRegisterFunction(signatures ...TaFunction) {
 func (interp *Interpreter) UpdateFunction(signature TaFunction) error {
 	signature.sanitize()
 	if s := interp.GetFunction(&signature); s != nil {
+		if err := interp.checkRecordTypes(&signature.CommonSignature); err != nil {
+			return err
+		}
 		*s = signature
 		return nil
 	}
@@ -75,19 +81,38 @@ func (interp *Interpreter) GetFunction(signature *TaFunction) *TaFunction {
 	return getFunction(interp.Functions, signature)
 }
 
+// HasFunction returns true if a function with this name is registered in the interpreter or its parents,
+// like function calls the name is case insensitive
+func (interp *Interpreter) HasFunction(name string) bool {
+	lowerName := strings.ToLower(name)
+	walker := funcWalker{interp: interp}
+	for fn := walker.Next(); fn != nil; fn = walker.Next() {
+		if fn.lowerName == lowerName {
+			return true
+		}
+	}
+	return false
+}
+
 func (interp *Interpreter) registerCoreFunctions() error {
 	bindingSignature.sanitize()
+	safeBindingSignature.sanitize()
 	setBindingSignature.sanitize()
 	templateSignature.sanitize()
+	defmacroSignature.sanitize()
 
 	// binding
 	interp.Functions = append(interp.Functions, bindingSignature)
+	interp.Functions = append(interp.Functions, safeBindingSignature)
 	interp.Functions = append(interp.Functions, setBindingSignature)
 
 	// template
 	interp.Functions = append(interp.Functions, setTemplateSignature)
 	interp.Functions = append(interp.Functions, templateSignature)
 
+	// macro
+	interp.Functions = append(interp.Functions, defmacroSignature)
+
 	interp.Functions = append(interp.Functions, coreFunctions...)
 
 	// sanitize name
@@ -154,6 +179,49 @@ func bindingFunc(interp *Interpreter, args ...*token.TaToken) (*token.TaToken, e
 	return nil, errors.Errorf("Unable to find `%s'", strings.Join(qualifiers, "."))
 }
 
+var safeBindingSignature = TaFunction{
+	CommonSignature: CommonSignature{
+		Name:       ".?",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.Atom,
+			token.Atom,
+		},
+		Returns:     token.Any,
+		Description: "Access a variable in the binding, returns null if the variable or any of its parents does not exist",
+		Example: `
+(.? Key1)                                                        ; returns the data assigned to Key1
+(.? Key2 SubKey1)                                                ; returns the data assigned to SubKey1 in the Map Key2
+(.? Key3 SubKey1)                                                ; returns null (assuming Key3 does not exist)
+`,
+	},
+	SharesArguments: true,
+	Func:            safeBindingFunc,
+}
+
+func safeBindingFunc(interp *Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+	if interp.Binding != nil {
+		value := interp.Binding
+		for i := 0; i < len(args) && !value.IsNull(); i++ {
+			if !value.IsMap() {
+				// the path continues, but there is nothing to look into
+				value = token.NewNull()
+				break
+			}
+			value = value.MapItem(args[i].String)
+		}
+		if !value.IsNull() {
+			return value, nil
+		}
+	}
+
+	// lookup in parent
+	if interp.Parent != nil {
+		return safeBindingFunc(interp.Parent, args...)
+	}
+	return token.NewNull(), nil
+}
+
 var setBindingSignature = TaFunction{
 	CommonSignature: CommonSignature{
 		Name:       "set",
@@ -188,6 +256,10 @@ func setBindingFunc(interp *Interpreter, args ...*token.TaToken) (*token.TaToken
 		if child.IsNull() {
 			child = token.NewMap(map[string]*token.TaToken{})
 			value.SetMapItem(args[i].String, child)
+		} else if child.Kind == token.Map {
+			// the map can be shared with copies of its parents, so change a copy of it
+			child = child.Freeze().Mutable()
+			value.SetMapItem(args[i].String, child)
 		}
 		value = child
 	}
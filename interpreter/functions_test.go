@@ -65,6 +65,24 @@ func TestRegisterFunction(t *testing.T) {
 	require.IsType(t, interpreter.FunctionNotFoundError{}, getError(interp.LexAndEvaluate("(myfn)")))
 }
 
+func TestHasFunction(t *testing.T) {
+	interp := helpers.MustNewInterpreter()
+	interp.MustRegisterFunction(interpreter.TaFunction{
+		CommonSignature: interpreter.CommonSignature{
+			Name:    "MyFN",
+			Returns: token.String,
+		},
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			return token.NewString("Hello World"), nil
+		},
+	})
+	scope := interp.NewScope()
+	require.True(t, scope.HasFunction("MyFN"))
+	require.True(t, scope.HasFunction("."))
+	require.True(t, scope.HasFunction("myfn"))
+	require.False(t, scope.HasFunction("Unknown"))
+}
+
 func TestVariadicFunctionWith0Parameters(t *testing.T) {
 	interp := helpers.MustNewInterpreterWithLogger()
 	interp.MustRegisterFunction(
@@ -135,8 +153,16 @@ func TestBinding(t *testing.T) {
 			}),
 		}),
 		"Root2": token.NewMap(map[string]*token.TaToken{}),
+		"Plans": token.NewMap(map[string]*token.TaToken{
+			"1m":  token.NewDecimalFromInt(10),
+			"P1D": token.NewDecimalFromInt(1),
+		}),
 	})
 
+	// keys that look like durations
+	require.Equal(t, "10", interp.MustLexAndEvaluate("(. Plans 1m)").String)
+	require.Equal(t, "1", interp.MustLexAndEvaluate("(. Plans P1D)").String)
+
 	b := interp.MustLexAndEvaluate("(+ (. Root1 Decimal) 2)")
 	require.Equal(t, true, b.IsDecimal())
 	require.Equal(t, "4", b.String)
@@ -201,6 +227,18 @@ func TestSetBinding(t *testing.T) {
 		interp.MustLexAndEvaluate("(set Root Key 2)")
 		require.Equal(t, "2", interp.MustLexAndEvaluate("(. Root Key)").String)
 	})
+	t.Run("SharedDeepLevel", func(t *testing.T) {
+		interp := helpers.MustNewInterpreterWithLogger()
+		interp.Binding = token.NewMap(map[string]*token.TaToken{
+			"Root": token.NewMap(map[string]*token.TaToken{
+				"Key": token.NewDecimalFromInt(1),
+			}),
+		})
+		old := interp.MustLexAndEvaluate("(. Root)")
+		interp.MustLexAndEvaluate("(set Root Key 2)")
+		require.Equal(t, "2", interp.MustLexAndEvaluate("(. Root Key)").String)
+		require.Equal(t, "{Key:1}", old.Stringify())
+	})
 	t.Run("NotExistingRootLevel", func(t *testing.T) {
 		interp := helpers.MustNewInterpreterWithLogger()
 		require.Error(t, helpers.MustError(interp.LexAndEvaluate("(. Root Key)")))
@@ -394,3 +432,61 @@ func TestFunctionMatch(t *testing.T) {
 	require.Equal(t, true, expected.Equal(&result))
 	// }
 }
+
+func TestFunctionDefaultArguments(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustRegisterFunction(interpreter.TaFunction{
+		CommonSignature: interpreter.MustNewCommonSignature(`greet(name String, greeting String = "Hello")String`),
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			return token.NewString(args[1].String + " " + args[0].String), nil
+		},
+	})
+
+	require.Equal(t, "Hello World", interp.MustLexAndEvaluate("(greet World)").String)
+	require.Equal(t, "Bye World", interp.MustLexAndEvaluate("(greet World Bye)").String)
+	require.Error(t, getError(interp.LexAndEvaluate("(greet)")))
+}
+
+func TestFunctionOptionalArguments(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustRegisterFunction(interpreter.TaFunction{
+		CommonSignature: interpreter.MustNewCommonSignature(`greet(name String?, greeting String?)String`),
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			name := args[0].String
+			if args[0].IsNull() {
+				name = "Guest"
+			}
+			if args[1].IsNull() {
+				return token.NewString("Hello " + name), nil
+			}
+			return token.NewString(args[1].String + " " + name), nil
+		},
+	})
+
+	require.Equal(t, "Hello World", interp.MustLexAndEvaluate("(greet World)").String)
+	require.Equal(t, "Bye World", interp.MustLexAndEvaluate("(greet World Bye)").String)
+	require.Equal(t, "Hello Guest", interp.MustLexAndEvaluate("(greet (.? Name))").String)
+	require.Equal(t, "Hello Guest", interp.MustLexAndEvaluate("(greet)").String)
+}
+
+func TestSafeBinding(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.Binding = token.NewMap(map[string]*token.TaToken{
+		"Root": token.NewMap(map[string]*token.TaToken{
+			"Key": token.NewDecimalFromInt(1),
+		}),
+		"Name": token.NewString("Joe"),
+	})
+	require.Equal(t, "1", interp.MustLexAndEvaluate("(.? Root Key)").String)
+	require.Equal(t, true, interp.MustLexAndEvaluate("(.? Root Missing)").IsNull())
+	require.Equal(t, true, interp.MustLexAndEvaluate("(.? Missing Key)").IsNull())
+	require.Equal(t, true, interp.MustLexAndEvaluate("(.? Name Key)").IsNull())
+	require.Error(t, getError(interp.LexAndEvaluate("(. Root Missing)")))
+
+	// scopes look into their parents
+	scope := interp.NewScope()
+	scope.Set("Local", token.NewBool(true))
+	require.Equal(t, true, scope.MustLexAndEvaluate("(.? Local)").Bool)
+	require.Equal(t, "1", scope.MustLexAndEvaluate("(.? Root Key)").String)
+	require.Equal(t, true, scope.MustLexAndEvaluate("(.? Root Missing)").IsNull())
+}
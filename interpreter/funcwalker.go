@@ -79,7 +79,7 @@ outerloop:
 
 		fnArgc := len(fn.Arguments)
 		if !fn.IsVariadic {
-			if fnArgc != f.argumentCount {
+			if fnArgc < f.argumentCount || fn.RequiredArgumentCount() > f.argumentCount {
 				continue
 			}
 		} else {
@@ -90,7 +90,7 @@ outerloop:
 
 		for i, j := 0, 0; i < f.argumentCount; i++ {
 			child := f.Token.Children[i]
-			childKinds := []token.Kind{child.Kind}
+			childKinds := []token.Kind{token.KindFor(child, fn.Arguments[j])}
 			// if the child is a block
 			if child.IsBlock() {
 				// but the fn does not accept a block
@@ -102,7 +102,7 @@ outerloop:
 
 			gotMatchingFunc := false
 			for k := 0; k < len(childKinds); k++ {
-				if fn.Arguments[j]&childKinds[k] != 0 {
+				if fn.Arguments[j].Accepts(childKinds[k]) {
 					gotMatchingFunc = true
 				}
 			}
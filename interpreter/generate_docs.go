@@ -78,8 +78,8 @@ func main() {
 	for i := 0; i < len(interp.Functions); i++ {
 		f := interp.Functions[i]
 		arguments := make([]string, len(f.Arguments))
-		for j, a := range f.Arguments {
-			arguments[j] = a.String()
+		for j := range f.Arguments {
+			arguments[j] = f.ArgumentString(j)
 		}
 
 		packageName := getPackageName(f.Func)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/ast"
 	"reflect"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/talon-one/decimal"
@@ -11,6 +12,9 @@ import (
 )
 
 var decimalType = reflect.TypeOf(decimal.Decimal{})
+var moneyType = reflect.TypeOf(token.MoneyValue{})
+var durationType = reflect.TypeOf(time.Duration(0))
+var timeType = reflect.TypeOf(time.Time{})
 
 type Unmarshaler interface {
 	UnmarshalTaToken(*token.TaToken) error
@@ -31,6 +35,15 @@ func genericSetConv(value interface{}) (*token.TaToken, error) {
 		return token.NewDecimal(v.Interface().(decimal.Decimal)), nil
 	}
 
+	if v.Type() == moneyType {
+		money := v.Interface().(token.MoneyValue)
+		return token.NewMoney(decimal.NewFromDecimal(money.Amount), money.Currency), nil
+	}
+
+	if v.Type() == durationType {
+		return token.NewDuration(v.Interface().(time.Duration)), nil
+	}
+
 	for {
 		if v.Kind() == reflect.Interface && !v.IsNil() {
 			e := v.Elem()
@@ -55,19 +68,28 @@ func genericSetConv(value interface{}) (*token.TaToken, error) {
 		v = v.Elem()
 	}
 
+	if v.Type() == timeType {
+		return token.NewTime(v.Interface().(time.Time)), nil
+	}
+
 	switch v.Kind() {
 	case reflect.Struct:
-		m := make(map[string]*token.TaToken)
+		// the fields keep the order of the struct
+		m := token.NewOrderedMap(nil, nil)
 		for i := 0; i < v.NumField(); i++ {
 			if fieldStruct := v.Type().Field(i); ast.IsExported(fieldStruct.Name) {
 				structValue, err := genericSetConv(v.Field(i).Interface())
 				if err != nil {
 					return nil, err
 				}
-				m[fieldStruct.Name] = structValue
+				m.SetMapItem(fieldStruct.Name, structValue)
 			}
 		}
-		return token.NewMap(m), nil
+		// named structs are attached to the record type generated by RecordTypeOf
+		if name := v.Type().Name(); isRecordName(name) {
+			m.Record = name
+		}
+		return m, nil
 	case reflect.Map:
 		m := make(map[string]*token.TaToken)
 		if v.Type().Key().Kind() != reflect.String {
@@ -130,6 +152,12 @@ func (interp *Interpreter) GenericSet(key string, value interface{}) error {
 		return err
 	}
 
+	if value != nil {
+		if err := interp.registerStructRecordTypes(reflect.TypeOf(value), make(map[reflect.Type]bool)); err != nil {
+			return err
+		}
+	}
+
 	if len(key) == 0 {
 		interp.Binding = block
 	} else {
@@ -138,6 +166,130 @@ func (interp *Interpreter) GenericSet(key string, value interface{}) error {
 	return nil
 }
 
+var marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+
+// RecordTypeOf generates a record type from a (named) struct, the fields get the kinds genericSetConv
+// would create for them
+func RecordTypeOf(value interface{}) (*token.RecordType, error) {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || !isRecordName(t.Name()) {
+		return nil, errors.Errorf("`%T' is not a named struct", value)
+	}
+	return recordTypeOf(t)
+}
+
+func recordTypeOf(t reflect.Type) (*token.RecordType, error) {
+	record := token.RecordType{
+		Name:   t.Name(),
+		Fields: make(map[string]token.Kind),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); ast.IsExported(field.Name) {
+			kind := kindOfType(field.Type)
+			if kind == 0 {
+				return nil, errors.Errorf("Unknown type `%s' of field `%s.%s'", field.Type.String(), t.Name(), field.Name)
+			}
+			record.Fields[field.Name] = kind
+		}
+	}
+	return &record, nil
+}
+
+// kindOfType returns the kind genericSetConv creates for values of the type, 0 if the type is not supported
+func kindOfType(t reflect.Type) token.Kind {
+	for {
+		if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+			// we can not know what the marshaler returns
+			return token.Atom | token.Collection
+		}
+		if t.Kind() != reflect.Ptr {
+			break
+		}
+		t = t.Elem()
+	}
+
+	switch t {
+	case decimalType:
+		return token.Decimal
+	case moneyType:
+		return token.Money
+	case durationType:
+		return token.Duration
+	case timeType:
+		return token.Time
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return token.Map
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return 0
+		}
+		return token.MapOf(kindOfType(t.Elem()))
+	case reflect.Slice:
+		return token.ListOf(kindOfType(t.Elem()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return token.Decimal
+	case reflect.String:
+		return token.String
+	case reflect.Bool:
+		return token.Boolean
+	case reflect.Interface:
+		return token.Atom | token.Collection
+	}
+	return 0
+}
+
+// registerStructRecordTypes registers the record types of all named structs used by the type
+// that are not registered yet, a record type with the same name must have the same fields
+func (interp *Interpreter) registerStructRecordTypes(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if seen[t] || t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+		return nil
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice:
+		return interp.registerStructRecordTypes(t.Elem(), seen)
+	case reflect.Struct:
+		if t == decimalType || t == moneyType || t == timeType {
+			return nil
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); ast.IsExported(field.Name) {
+				if err := interp.registerStructRecordTypes(field.Type, seen); err != nil {
+					return err
+				}
+			}
+		}
+		if !isRecordName(t.Name()) {
+			return nil
+		}
+		record, err := recordTypeOf(t)
+		if err != nil {
+			return err
+		}
+		if existing := interp.RecordType(t.Name()); existing != nil {
+			// the values are attached to the record type, so it must describe them
+			if !existing.Equal(record) {
+				return errors.Errorf("Record type `%s' is already registered with different fields: `%s' and `%s'", t.Name(), existing.String(), record.String())
+			}
+			return nil
+		}
+		return interp.RegisterRecordType(*record)
+	}
+	return nil
+}
+
 func genericGetConv(tkn *token.TaToken, v reflect.Value) (reflect.Value, error) {
 	// walk down until we can address something
 	if v.Kind() != reflect.Ptr && v.Type().Name() != "" && v.CanAddr() {
@@ -179,6 +331,30 @@ func genericGetConv(tkn *token.TaToken, v reflect.Value) (reflect.Value, error)
 		return reflect.ValueOf(d), nil
 	}
 
+	if v.Type() == moneyType {
+		if !tkn.IsMoney() {
+			return reflect.Value{}, errors.Errorf("%s is not money", tkn.String)
+		}
+		return reflect.ValueOf(token.MoneyValue{
+			Amount:   decimal.NewFromDecimal(tkn.Decimal),
+			Currency: tkn.Currency,
+		}), nil
+	}
+
+	if v.Type() == durationType {
+		if !tkn.IsDuration() {
+			return reflect.Value{}, errors.Errorf("%s is not a duration", tkn.String)
+		}
+		return reflect.ValueOf(tkn.Duration), nil
+	}
+
+	if v.Type() == timeType {
+		if !tkn.IsTime() {
+			return reflect.Value{}, errors.Errorf("%s is not a time", tkn.String)
+		}
+		return reflect.ValueOf(tkn.Time), nil
+	}
+
 	var result interface{}
 	var err error
 
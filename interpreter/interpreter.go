@@ -3,6 +3,7 @@ package interpreter
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/talon-one/talang/token"
@@ -16,9 +17,24 @@ type Interpreter struct {
 	Parent            *Interpreter
 	Functions         []TaFunction
 	Templates         []TaTemplate
+	Macros            []TaMacro
+	RecordTypes       []token.RecordType
 	Logger            *log.Logger
 	IsDryRun          bool
 	MaxRecursiveLevel *int
+	// Location is used by calendar functions for times in UTC and for dates, if nil the location of the parent (or UTC) is used
+	Location *time.Location
+	// LooseComparison enables the legacy comparison rules: = and != compare the string representations,
+	// sort sorts by the string representations and min and max only look at decimals. Scopes use the rules of their parents
+	LooseComparison bool
+	// MaxRegexpLength and MaxRegexpComplexity limit the length and the number of compiled instructions of regular expressions,
+	// if 0 the limit of the parent (or DefaultMaxRegexpLength and DefaultMaxRegexpComplexity) is used
+	MaxRegexpLength     int
+	MaxRegexpComplexity int
+	// RegexpCacheSize is the number of compiled regular expressions the root interpreter keeps, if 0 DefaultRegexpCacheSize is used
+	RegexpCacheSize int
+
+	regexps *regexpCache
 }
 
 func NewInterpreter() (*Interpreter, error) {
@@ -58,6 +74,9 @@ func (interp *Interpreter) MustLexAndEvaluate(str string) *token.TaToken {
 }
 
 func (interp *Interpreter) Evaluate(b *token.TaToken) error {
+	if err := interp.Expand(b); err != nil {
+		return err
+	}
 	return interp.evaluate(b, 0)
 }
 func (interp *Interpreter) evaluate(b *token.TaToken, level int) error {
@@ -114,6 +133,7 @@ func (interp *Interpreter) callFunc(b *token.TaToken, level int) (bool, error) {
 	}
 
 	var collectedErrors []error
+	literals := literalWords(b)
 	walker := newFuncToRunWalker(interp, b, level+1)
 
 nextfunc:
@@ -159,8 +179,10 @@ nextfunc:
 				}
 			}
 
+			children = fn.CommonSignature.appendDefaults(children)
+
 			// the children do not match after evaluation => goto next function
-			if !fn.CommonSignature.MatchesArguments(token.Arguments(children)) {
+			if !fn.CommonSignature.matchesTokens(children) {
 
 				collectedErrors = append(collectedErrors, FunctionNotRanError{
 					Reason:   errors.New("Not matching signature - after evaluation"),
@@ -169,6 +191,18 @@ nextfunc:
 				continue nextfunc
 			}
 
+			if err := interp.matchRecords(&fn.CommonSignature, children); err != nil {
+				collectedErrors = append(collectedErrors, FunctionNotRanError{
+					Reason:   err,
+					function: fn,
+				})
+				continue nextfunc
+			}
+
+			if !fn.SharesArguments {
+				children = mutableArguments(children)
+			}
+
 			if interp.Logger != nil {
 				interp.Logger.Printf("Running function `%s' with `%v'\n", fn.String(), token.TokenArguments(children).ToHumanReadable())
 			}
@@ -198,12 +232,33 @@ nextfunc:
 		if interp.Logger != nil {
 			interp.Logger.Printf("Updating value to `%s' (%s)\n", result.Stringify(), result.Kind.String())
 		}
+		// results are values, so the copy is frozen and lists and maps can be shared by later copies,
+		// the result itself might be owned by a binding and is not changed
 		token.Copy(b, result)
+		b.Freeze()
 		if b.IsBlock() {
 			return true, interp.evaluate(b, level+1)
 		}
 		return true, nil
 	}
+	// bare words like 1m or 2018-01-01 were strings before they became durations and times,
+	// so they are passed as strings if no function accepts them as durations or times
+	if len(literals) > 0 {
+		var retry token.TaToken
+		token.Copy(&retry, b)
+		for i, text := range literals {
+			retry.Children[i] = token.NewString(text)
+		}
+		ok, err := interp.callFunc(&retry, level)
+		if ok {
+			token.Copy(b, &retry)
+			return true, err
+		}
+		if _, notFound := err.(FunctionNotFoundError); !notFound {
+			return false, err
+		}
+	}
+
 	// we found no matching function OR all functions failed
 	err := FunctionNotFoundError{CollectedErrors: collectedErrors, token: b}
 	if interp.Logger != nil {
@@ -212,6 +267,20 @@ nextfunc:
 	return false, err
 }
 
+// literalWords returns the text of the durations and times that are written as words in the block, by their index
+func literalWords(b *token.TaToken) map[int]string {
+	var literals map[int]string
+	for i, child := range b.Children {
+		if (child.IsDuration() || child.IsTime()) && len(child.String) > 0 {
+			if literals == nil {
+				literals = make(map[int]string)
+			}
+			literals[i] = child.String
+		}
+	}
+	return literals
+}
+
 func (interp *Interpreter) Get(key string) *token.TaToken {
 	if interp.Binding != nil {
 		return interp.Binding.MapItem(key)
@@ -232,7 +301,7 @@ func (interp *Interpreter) NewScope() *Interpreter {
 	i.Logger = interp.Logger
 	i.MaxRecursiveLevel = interp.MaxRecursiveLevel
 	// we need to register binding and template on this scope, because it uses its own scopes
-	i.Functions = []TaFunction{templateSignature, setTemplateSignature, bindingSignature, setBindingSignature}
+	i.Functions = []TaFunction{templateSignature, setTemplateSignature, bindingSignature, safeBindingSignature, setBindingSignature, defmacroSignature}
 	return &i
 }
 
@@ -256,6 +325,56 @@ func (interp *Interpreter) AllTemplates() (templates []TaTemplate) {
 	return templates
 }
 
+func (interp *Interpreter) AllMacros() (macros []TaMacro) {
+	if len(interp.Macros) > 0 {
+		macros = append(macros, interp.Macros...)
+	}
+	if interp.Parent != nil {
+		macros = append(macros, interp.Parent.AllMacros()...)
+	}
+	return macros
+}
+
+// CurrentLocation returns the default location of the interpreter or its parents, UTC if none is set
+func (interp *Interpreter) CurrentLocation() *time.Location {
+	for scope := interp; scope != nil; scope = scope.Parent {
+		if scope.Location != nil {
+			return scope.Location
+		}
+	}
+	return time.UTC
+}
+
+// UsesLooseComparison returns true if the interpreter or one of its parents enabled LooseComparison
+func (interp *Interpreter) UsesLooseComparison() bool {
+	for scope := interp; scope != nil; scope = scope.Parent {
+		if scope.LooseComparison {
+			return true
+		}
+	}
+	return false
+}
+
+// mutableArguments replaces immutable lists and maps with copies that own their children,
+// so functions can change their arguments without changing the values they share the children with (e.g. the binding)
+func mutableArguments(children []*token.TaToken) []*token.TaToken {
+	var args []*token.TaToken
+	for i, child := range children {
+		if !child.IsImmutable() {
+			continue
+		}
+		if args == nil {
+			args = make([]*token.TaToken, len(children))
+			copy(args, children)
+		}
+		args[i] = child.Mutable()
+	}
+	if args == nil {
+		return children
+	}
+	return args
+}
+
 func hasTokenBlock(tkn *token.TaToken) bool {
 	for _, child := range tkn.Children {
 		if child.IsBlock() {
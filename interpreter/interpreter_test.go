@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/talon-one/talang/lexer"
 
@@ -275,6 +276,14 @@ func TestGenericSet(t *testing.T) {
 	// decimal
 	require.NoError(t, interp.GenericSet("Key", decimal.NewFromInt(1)))
 	require.Equal(t, "1", interp.MustLexAndEvaluate("(. Key)").String)
+
+	// money
+	require.NoError(t, interp.GenericSet("Key", token.MoneyValue{Amount: decimal.NewFromInt(5), Currency: "EUR"}))
+	require.Equal(t, token.NewMoney(decimal.NewFromInt(5), "EUR"), interp.MustLexAndEvaluate("(. Key)"))
+
+	// duration
+	require.NoError(t, interp.GenericSet("Key", 90*time.Minute))
+	require.Equal(t, token.NewDuration(90*time.Minute), interp.MustLexAndEvaluate("(. Key)"))
 }
 
 func TestGenericGet(t *testing.T) {
@@ -449,6 +458,37 @@ func TestGenericGet(t *testing.T) {
 		require.Equal(t, "1", data.Custom.s)
 	})
 
+	t.Run("Money", func(t *testing.T) {
+		var data struct {
+			Price token.MoneyValue
+		}
+		interp := helpers.MustNewInterpreterWithLogger()
+		interp.MustLexAndEvaluate(`(set Price (money 12.5 EUR))`)
+
+		require.NoError(t, interp.GenericGet("", &data))
+
+		require.Equal(t, "EUR", data.Price.Currency)
+		require.Equal(t, "12.5", data.Price.Amount.String())
+
+		interp.MustLexAndEvaluate(`(set Price 12.5)`)
+		require.Error(t, interp.GenericGet("", &data))
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		var data struct {
+			Timeout time.Duration
+		}
+		interp := helpers.MustNewInterpreterWithLogger()
+		interp.MustLexAndEvaluate(`(set Timeout PT1H30M)`)
+
+		require.NoError(t, interp.GenericGet("", &data))
+
+		require.Equal(t, 90*time.Minute, data.Timeout)
+
+		interp.MustLexAndEvaluate(`(set Timeout 90)`)
+		require.Error(t, interp.GenericGet("", &data))
+	})
+
 	t.Run("CustomPTR", func(t *testing.T) {
 		var data struct {
 			Custom *customDataType
@@ -633,3 +673,56 @@ func TestTypeChecking(t *testing.T) {
 	require.Error(t, err)
 	// require.Equal(t, fmt.Sprintf("Found no eval function for (+ \"2\" 2)\n  Expression (+ \"2\" 2) doesn't match '+(Decimal, Decimal, Decimal...)Decimal'\n  Expression (+ \"2\" 2) doesn't match '+(String, String, String...)String'\n"), err.Error())
 }
+
+func TestEvaluateDoesNotModifyBinding(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.Set("X", token.NewDecimalFromInt(1))
+
+	require.Equal(t, "2", interp.MustLexAndEvaluate("(+ (. X) 1)").String)
+	require.Equal(t, "2", interp.MustLexAndEvaluate("(+ (. X) 1)").String)
+	require.Equal(t, "1", interp.Get("X").Decimal.String())
+}
+
+func TestEvaluateDoesNotFreezeResult(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	var list token.TaToken
+	list.Kind = token.List
+	list.Children = []*token.TaToken{token.NewDecimalFromInt(1)}
+	interp.MustRegisterFunction(interpreter.TaFunction{
+		CommonSignature: interpreter.MustNewCommonSignature("owned()List"),
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			return &list, nil
+		},
+	})
+
+	result := interp.MustLexAndEvaluate("(owned)")
+	require.True(t, result.IsImmutable())
+	require.False(t, list.IsImmutable())
+}
+
+func TestLiteralWordsAsStrings(t *testing.T) {
+	helpers.RunTests(t,
+		helpers.Test{
+			`(+ "size " 1m)`,
+			nil,
+			token.NewString("size 1m"),
+		},
+		helpers.Test{
+			`(concat "size " 2h " on " 2018-01-01)`,
+			nil,
+			token.NewString("size 2h on 2018-01-01"),
+		},
+		// functions that accept durations are preferred
+		helpers.Test{
+			`(+ 1m 2m)`,
+			nil,
+			token.NewDuration(3 * time.Minute),
+		},
+		// only words are passed as strings, not computed durations
+		helpers.Test{
+			`(+ "size " (duration 1 "hours"))`,
+			nil,
+			helpers.Error{},
+		},
+	)
+}
@@ -0,0 +1,231 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/talon-one/talang/interpreter"
+	"github.com/talon-one/talang/lexer"
+	helpers "github.com/talon-one/talang/testhelpers"
+	"github.com/talon-one/talang/token"
+)
+
+func TestMacro(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+
+	// unless swaps its arguments into a (not ...) condition, it inspects the raw tokens
+	require.NoError(t, interp.RegisterMacro(interpreter.TaMacro{
+		CommonSignature: interpreter.CommonSignature{
+			Name: "unless",
+			Arguments: []token.Kind{
+				token.Any,
+			},
+			Returns: token.Any,
+		},
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			return token.NewToken("not", args[0]), nil
+		},
+	}))
+
+	require.Equal(t, "(not (= 1 2))", interp.MustLexAndExpand("(unless (= 1 2))").Stringify())
+	require.Equal(t, "(and (not (= 1 2)) true)", interp.MustLexAndExpand("(and (unless (= 1 2)) true)").Stringify())
+
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			"(unless (= 1 2))",
+			nil,
+			token.NewBool(true),
+		},
+		helpers.Test{
+			"(unless (unless (= 1 2)))",
+			nil,
+			token.NewBool(false),
+		},
+	)
+}
+
+func TestMacroArgumentsAreNotEvaluated(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+
+	var received *token.TaToken
+	interp.MustRegisterMacro(interpreter.TaMacro{
+		CommonSignature: interpreter.CommonSignature{
+			Name: "quote",
+			Arguments: []token.Kind{
+				token.Any,
+			},
+			Returns: token.Any,
+		},
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			received = args[0]
+			return token.NewString(args[0].Stringify()), nil
+		},
+	})
+
+	result := interp.MustLexAndEvaluate("(quote (+ 1 (panic)))")
+	require.Equal(t, true, received.IsBlock())
+	require.Equal(t, true, result.IsString())
+	require.Equal(t, "(+ 1 (panic))", result.String)
+}
+
+func TestMacroError(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustRegisterMacro(interpreter.TaMacro{
+		CommonSignature: interpreter.CommonSignature{
+			Name:    "fail",
+			Returns: token.Any,
+		},
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			return nil, errors.New("SomeError")
+		},
+	})
+
+	require.IsType(t, interpreter.MacroError{}, getError(interp.LexAndEvaluate("(+ 1 (fail))")))
+	require.IsType(t, interpreter.MacroError{}, getError(interp.LexAndExpand("(fail)")))
+}
+
+func TestMacroRecursion(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	level := 16
+	interp.MaxRecursiveLevel = &level
+
+	require.IsType(t, &interpreter.MaxRecursiveLevelReachedError{}, getError(interp.LexAndEvaluate("((defmacro loop (x) (loop (# x))) (loop 1))")))
+}
+
+func TestDefmacro(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustLexAndEvaluate("(defmacro inc (x) (+ (# x) 1))")
+	interp.MustLexAndEvaluate("(defmacro atLeast (Path Min) (>= (catch 0 (# Path)) (# Min)))")
+	interp.MustLexAndEvaluate("(defmacro fortyTwo 42)")
+
+	require.Equal(t, "(+ 2 1)", interp.MustLexAndExpand("(inc 2)").Stringify())
+	require.Equal(t, "(+ (+ 2 1) 1)", interp.MustLexAndExpand("(inc (inc 2))").Stringify())
+	require.Equal(t, `(>= (catch 0 (. "Profile" "Age")) 18)`, interp.MustLexAndExpand("(atLeast (. Profile Age) 18)").Stringify())
+
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			"(inc (inc 2))",
+			nil,
+			token.NewDecimalFromInt(4),
+		},
+		helpers.Test{
+			"(fortyTwo)",
+			nil,
+			token.NewDecimalFromInt(42),
+		},
+		helpers.Test{
+			"(atLeast (. Profile Age) 18)",
+			nil,
+			token.NewBool(false),
+		},
+		helpers.Test{
+			"(atLeast (. Profile Age) 18)",
+			token.NewMap(map[string]*token.TaToken{
+				"Profile": token.NewMap(map[string]*token.TaToken{
+					"Age": token.NewDecimalFromInt(21),
+				}),
+			}),
+			token.NewBool(true),
+		},
+		// macros can be defined and used in the same expression
+		helpers.Test{
+			"((defmacro double (x) (* (# x) 2)) (double 4))",
+			nil,
+			token.NewDecimalFromInt(8),
+		},
+		// invalid parameter list
+		helpers.Test{
+			"(defmacro invalid x (# x))",
+			nil,
+			helpers.Error{},
+		},
+	)
+
+	// redefining replaces the macro
+	interp.MustLexAndEvaluate("(defmacro inc (x) (+ (# x) 2))")
+	require.Equal(t, "4", interp.MustLexAndEvaluate("(inc 2)").String)
+	require.Len(t, interp.Macros, 3)
+}
+
+func TestDefmacroHygiene(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustLexAndEvaluate("(defmacro swap (a b) (list (# b) (# a)))")
+
+	// placeholders inside the arguments are not replaced with the macro parameters
+	require.Equal(t, `(list (# "a") (# "b"))`, interp.MustLexAndExpand("(swap (# b) (# a))").Stringify())
+
+	// the binding x of the macro body must not capture the x used in the arguments
+	interp.MustLexAndEvaluate("(defmacro anyAbove (items min) (> (count (filter (# items) ((x) (> (. x Price) (# min))))) 0))")
+	expanded := interp.MustLexAndExpand("(anyAbove (. Items) (. x))").Stringify()
+	require.Regexp(t, `^\(> \(count \(filter \(\. "Items"\) \(\(x#\d+\) \(> \(\. "x#\d+" "Price"\) \(\. "x"\)\)\)\)\) 0\)$`, expanded)
+
+	items := token.NewList(
+		token.NewMap(map[string]*token.TaToken{"Price": token.NewDecimalFromInt(10)}),
+		token.NewMap(map[string]*token.TaToken{"Price": token.NewDecimalFromInt(30)}),
+	)
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			"(anyAbove (. Items) (. x))",
+			token.NewMap(map[string]*token.TaToken{"Items": items, "x": token.NewDecimalFromInt(20)}),
+			token.NewBool(true),
+		},
+		helpers.Test{
+			"(anyAbove (. Items) (. x))",
+			token.NewMap(map[string]*token.TaToken{"Items": items, "x": token.NewDecimalFromInt(40)}),
+			token.NewBool(false),
+		},
+	)
+
+	// an inner binding that shadows an outer one keeps its own name
+	interp.MustLexAndEvaluate("(defmacro nested (items) (map (# items) ((x) (map (. x Children) ((x) (. x Name))))))")
+	nested := interp.MustLexAndExpand("(nested (. Items))")
+	outer := nested.Children[1].Children[0].String
+	inner := nested.Children[1].Children[1].Children[1].Children[0].String
+	require.NotEqual(t, outer, inner)
+	require.Equal(t, outer, nested.Children[1].Children[1].Children[0].Children[0].String)
+	require.Equal(t, inner, nested.Children[1].Children[1].Children[1].Children[1].Children[0].String)
+
+	// names of functions are renamed as well, item is a list function
+	interp.MustLexAndEvaluate("(defmacro addTo (l v) (map (# l) ((Item) (+ (. Item) (# v)))))")
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			"(addTo (. Items) (. Item))",
+			token.NewMap(map[string]*token.TaToken{
+				"Items": token.NewList(token.NewDecimalFromInt(2), token.NewDecimalFromInt(3)),
+				"Item":  token.NewDecimalFromInt(100),
+			}),
+			token.NewList(token.NewDecimalFromInt(102), token.NewDecimalFromInt(103)),
+		},
+	)
+}
+
+func TestExpandHasNoSideEffects(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+
+	// the defmacro form is used by its siblings, but only registered when it is evaluated
+	require.Equal(t, "(+ 2 1)", interp.MustLexAndExpand("((defmacro inc (x) (+ (# x) 1)) (inc 2))").Children[1].Stringify())
+	require.Empty(t, interp.Macros)
+	require.Equal(t, "(inc 2)", interp.MustLexAndExpand("(inc 2)").Stringify())
+
+	interp.MustLexAndEvaluate("(defmacro inc (x) (+ (# x) 1))")
+	require.Len(t, interp.Macros, 1)
+}
+
+func TestMacroScope(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustLexAndEvaluate("(defmacro inc (x) (+ (# x) 1))")
+
+	scope := interp.NewScope()
+	scope.MustLexAndEvaluate("(defmacro dec (x) (- (# x) 1))")
+
+	require.Equal(t, "1", scope.MustLexAndEvaluate("(dec (inc 1))").String)
+	require.Error(t, getError(interp.LexAndEvaluate("(dec 1)")))
+	require.Len(t, scope.AllMacros(), 2)
+}
+
+func TestExpandWithoutMacros(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, interp.RemoveAllMacros())
+	require.Equal(t, lexer.MustLex("(+ 1 (. A))").Stringify(), interp.MustLexAndExpand("(+ 1 (. A))").Stringify())
+}
@@ -0,0 +1,366 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/talon-one/talang/lexer"
+	"github.com/talon-one/talang/token"
+)
+
+// maxMacroExpansionLevel is used when no MaxRecursiveLevel is set on the interpreter,
+// it protects against macros that expand to themselves
+const maxMacroExpansionLevel = 1024
+
+func (interp *Interpreter) RegisterMacro(signatures ...TaMacro) error {
+	for i := 0; i < len(signatures); i++ {
+		signature := signatures[i]
+		signature.sanitize()
+		if interp.GetMacro(&signature) != nil {
+			return errors.Errorf("Macro `%s' is already registered", signature.Name)
+		}
+		interp.Macros = append(interp.Macros, signature)
+	}
+	return nil
+}
+
+func (interp *Interpreter) MustRegisterMacro(signatures ...TaMacro) {
+	if err := interp.RegisterMacro(signatures...); err != nil {
+		panic(err)
+	}
+}
+
+func (interp *Interpreter) UpdateMacro(signature TaMacro) error {
+	signature.sanitize()
+	if s := interp.GetMacro(&signature); s != nil {
+		*s = signature
+		return nil
+	}
+	return errors.Errorf("Macro `%s' is not registered", signature.Name)
+}
+
+func (interp *Interpreter) RemoveMacro(signature TaMacro) error {
+	signature.sanitize()
+	for i := 0; i < len(interp.Macros); i++ {
+		if interp.Macros[i].Equal(&signature) {
+			fns := interp.Macros[:i]
+			interp.Macros = append(fns, interp.Macros[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("Macro `%s' is not registered", signature.Name)
+}
+
+func (interp *Interpreter) GetMacro(signature *TaMacro) *TaMacro {
+	signature.sanitize()
+	for i := 0; i < len(interp.Macros); i++ {
+		if interp.Macros[i].Equal(signature) {
+			return &interp.Macros[i]
+		}
+	}
+	return nil
+}
+
+func (interp *Interpreter) RemoveAllMacros() error {
+	interp.Macros = []TaMacro{}
+	return nil
+}
+
+// LexAndExpand lexes the string and expands all macros in it, without evaluating the result
+func (interp *Interpreter) LexAndExpand(str string) (*token.TaToken, error) {
+	t, err := lexer.Lex(str)
+	if err != nil {
+		return t, err
+	}
+	err = interp.Expand(t)
+	return t, err
+}
+
+func (interp *Interpreter) MustLexAndExpand(str string) *token.TaToken {
+	t, err := interp.LexAndExpand(str)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Expand replaces all macro calls in the token with their expansion.
+// Macros receive their arguments unevaluated, the returned token is expanded again
+// until no more macro calls are left. Macros defined by `defmacro' forms can be used by the following
+// siblings, expanding does not register them, this happens when the `defmacro' form is evaluated.
+func (interp *Interpreter) Expand(b *token.TaToken) error {
+	e := expansion{interp: interp, hasMacros: interp.hasMacros()}
+	return e.expand(b, 0)
+}
+
+// hasMacros returns true if a macro is registered in the interpreter or its parents
+func (interp *Interpreter) hasMacros() bool {
+	for scope := interp; scope != nil; scope = scope.Parent {
+		if len(scope.Macros) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// expansion holds the state of one Expand call
+type expansion struct {
+	interp *Interpreter
+	// macros defined by `defmacro' forms in the expanded token
+	macros []TaMacro
+	// hasMacros is false if no macro can be found, so the lookup can be skipped
+	hasMacros bool
+}
+
+func (e *expansion) expand(b *token.TaToken, level int) error {
+	if b == nil || !b.IsBlock() {
+		return nil
+	}
+
+	maxLevel := maxMacroExpansionLevel
+	if e.interp.MaxRecursiveLevel != nil {
+		maxLevel = *e.interp.MaxRecursiveLevel
+	}
+	if level > maxLevel {
+		return &MaxRecursiveLevelReachedError{maxLevel}
+	}
+
+	if strings.EqualFold(b.String, defmacroSignature.Name) {
+		// do not expand the body, it gets expanded on every use
+		macro, err := newMacro(b.Children...)
+		if err != nil {
+			return err
+		}
+		e.define(macro)
+		return nil
+	}
+
+	if macro := e.findMacro(b); macro != nil {
+		// macros are allowed to restructure their arguments, so hand over a copy
+		args := make([]*token.TaToken, len(b.Children))
+		for i, child := range b.Children {
+			args[i] = new(token.TaToken)
+			token.Copy(args[i], child)
+		}
+
+		if e.interp.Logger != nil {
+			e.interp.Logger.Printf("Expanding macro `%s' with `%v'\n", macro.String(), token.TokenArguments(args).ToHumanReadable())
+		}
+
+		result, err := macro.Func(e.interp, args...)
+		if err != nil {
+			return MacroError{error: err, macro: macro}
+		}
+		if result == nil {
+			return MacroError{error: errors.New("No token returned"), macro: macro}
+		}
+		token.Copy(b, result)
+
+		if e.interp.Logger != nil {
+			e.interp.Logger.Printf("Expanded to `%s'\n", b.Stringify())
+		}
+		return e.expand(b, level+1)
+	}
+
+	for i := 0; i < len(b.Children); i++ {
+		if err := e.expand(b.Children[i], level+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// define adds (or replaces) a macro that is only known during this expansion
+func (e *expansion) define(macro TaMacro) {
+	macro.sanitize()
+	e.hasMacros = true
+	for i := range e.macros {
+		if e.macros[i].Equal(&macro) {
+			e.macros[i] = macro
+			return
+		}
+	}
+	e.macros = append(e.macros, macro)
+}
+
+func (e *expansion) findMacro(b *token.TaToken) *TaMacro {
+	if !e.hasMacros || len(b.String) <= 0 {
+		return nil
+	}
+	lowerName := strings.ToLower(b.String)
+	for i := 0; i < len(e.macros); i++ {
+		if e.macros[i].lowerName == lowerName && e.macros[i].matchesTokens(b.Children) {
+			return &e.macros[i]
+		}
+	}
+	for scope := e.interp; scope != nil; scope = scope.Parent {
+		for i := 0; i < len(scope.Macros); i++ {
+			if scope.Macros[i].lowerName == lowerName && scope.Macros[i].matchesTokens(b.Children) {
+				return &scope.Macros[i]
+			}
+		}
+	}
+	return nil
+}
+
+// defineMacro registers (or updates) a macro from the arguments of a `defmacro' form
+func (interp *Interpreter) defineMacro(args ...*token.TaToken) error {
+	macro, err := newMacro(args...)
+	if err != nil {
+		return err
+	}
+	if err := interp.UpdateMacro(macro); err == nil {
+		return nil
+	}
+	return interp.RegisterMacro(macro)
+}
+
+// newMacro creates a macro from the arguments of a `defmacro' form
+func newMacro(args ...*token.TaToken) (TaMacro, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return TaMacro{}, errors.New("invalid or missing arguments")
+	}
+	if args[0].IsBlock() || len(args[0].String) <= 0 {
+		return TaMacro{}, errors.New("Invalid macro name")
+	}
+
+	var parameters []string
+	if len(args) == 3 {
+		if !args[1].IsBlock() {
+			return TaMacro{}, errors.New("Invalid parameter list")
+		}
+		parameters = append(parameters, args[1].String)
+		for _, child := range args[1].Children {
+			if child.IsBlock() || len(child.String) <= 0 {
+				return TaMacro{}, errors.New("Invalid parameter list")
+			}
+			parameters = append(parameters, child.String)
+		}
+	}
+
+	var body token.TaToken
+	token.Copy(&body, args[len(args)-1])
+
+	arguments := make([]token.Kind, len(parameters))
+	for i := range arguments {
+		arguments[i] = token.Any
+	}
+
+	return TaMacro{
+		CommonSignature: CommonSignature{
+			Name:      args[0].String,
+			Arguments: arguments,
+			Returns:   token.Any,
+		},
+		Func: func(interp *Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			var result token.TaToken
+			token.Copy(&result, &body)
+			renameBindings(&result)
+			replaceParameters(&result, parameters, args)
+			return &result, nil
+		},
+	}, nil
+}
+
+// gensymCounter makes the names created by renameBindings unique
+var gensymCounter uint64
+
+// renameBindings gives the names that binding blocks like ((Item) (. Item Price)) in the body of a macro introduce
+// a new unique name, so they cannot capture the bindings used by the arguments of the macro call.
+// Inner blocks are renamed first, so names they shadow keep referring to the inner binding.
+func renameBindings(source *token.TaToken) {
+	for i := 0; i < len(source.Children); i++ {
+		renameBindings(source.Children[i])
+	}
+	if !isBindingBlock(source) {
+		return
+	}
+
+	names := source.Children[0]
+	renamed := make(map[string]string, len(names.Children)+1)
+	for _, name := range append([]*token.TaToken{names}, names.Children...) {
+		renamed[name.String] = fmt.Sprintf("%s#%d", name.String, atomic.AddUint64(&gensymCounter, 1))
+	}
+	names.String = renamed[names.String]
+	for _, name := range names.Children {
+		name.String = renamed[name.String]
+	}
+	renameReferences(source.Children[1], renamed)
+}
+
+// isBindingBlock returns true if the token has the form ((Name...) body).
+// Functions are not looked up, an unnamed block starting with a block of names is always a binding block,
+// so names like Item are renamed even though there is an item function
+func isBindingBlock(b *token.TaToken) bool {
+	if len(b.String) > 0 || len(b.Children) != 2 {
+		return false
+	}
+	names := b.Children[0]
+	if !names.IsBlock() || len(names.String) <= 0 || names.String == "#" {
+		return false
+	}
+	for _, name := range names.Children {
+		if name.IsBlock() || !name.IsString() {
+			return false
+		}
+	}
+	return true
+}
+
+// renameReferences renames the first argument of binding accesses like (. Item Price) or (set Item ...)
+func renameReferences(source *token.TaToken, renamed map[string]string) {
+	if source.IsBlock() && len(source.Children) > 0 && !source.Children[0].IsBlock() {
+		switch source.String {
+		case bindingSignature.Name, safeBindingSignature.Name, setBindingSignature.Name:
+			if name, ok := renamed[source.Children[0].String]; ok {
+				source.Children[0].String = name
+			}
+		}
+	}
+	for i := 0; i < len(source.Children); i++ {
+		renameReferences(source.Children[i], renamed)
+	}
+}
+
+// replaceParameters replaces all (# name) placeholders in source with the matching argument.
+// Unlike templates the replacement is done in one pass, arguments are never searched for placeholders.
+func replaceParameters(source *token.TaToken, names []string, args []*token.TaToken) {
+	if source.IsBlock() && source.String == "#" && len(source.Children) == 1 {
+		for i, name := range names {
+			if strings.EqualFold(source.Children[0].String, name) {
+				token.Copy(source, args[i])
+				return
+			}
+		}
+	}
+
+	for i := 0; i < len(source.Children); i++ {
+		replaceParameters(source.Children[i], names, args)
+	}
+}
+
+var defmacroSignature = TaFunction{
+	CommonSignature: CommonSignature{
+		Name:       "defmacro",
+		IsVariadic: true,
+		Arguments: []token.Kind{
+			token.String,
+			token.Any,
+			token.Any,
+		},
+		Returns:     token.Null,
+		Description: "Define a macro, macros are expanded before evaluation and receive their arguments unevaluated, binding blocks in the body are renamed so they do not capture the bindings of the arguments",
+		Example: `
+(defmacro inc (x) (+ (# x) 1))                                   ; defines a macro, (inc 2) expands to (+ 2 1)
+(defmacro atLeast (path min) (>= (catch 0 (# path)) (# min)))    ; (atLeast (. Profile Age) 18) expands to (>= (catch 0 (. Profile Age)) 18)
+`,
+	},
+	Func: func(interp *Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+		if err := interp.defineMacro(args...); err != nil {
+			return nil, err
+		}
+		return token.NewNull(), nil
+	},
+}
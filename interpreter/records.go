@@ -0,0 +1,173 @@
+package interpreter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/talon-one/talang/token"
+)
+
+var recordName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RegisterRecordType registers record types, records can be used in signatures (e.g. `cartTotal(List<CartItem>)Decimal`)
+// and arguments are checked against the fields of the record when the function or template runs
+func (interp *Interpreter) RegisterRecordType(records ...token.RecordType) error {
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if !isRecordName(record.Name) {
+			return errors.Errorf("Invalid record type name `%s'", record.Name)
+		}
+		if interp.RecordType(record.Name) != nil {
+			return errors.Errorf("Record type `%s' is already registered", record.Name)
+		}
+		for name, kind := range record.Fields {
+			if kind == 0 {
+				return errors.Errorf("Field `%s' of record type `%s' has an invalid kind", name, record.Name)
+			}
+		}
+		interp.RecordTypes = append(interp.RecordTypes, record)
+	}
+	return nil
+}
+
+func (interp *Interpreter) MustRegisterRecordType(records ...token.RecordType) {
+	if err := interp.RegisterRecordType(records...); err != nil {
+		panic(err)
+	}
+}
+
+func (interp *Interpreter) RemoveRecordType(name string) error {
+	for i := 0; i < len(interp.RecordTypes); i++ {
+		if strings.EqualFold(interp.RecordTypes[i].Name, name) {
+			interp.RecordTypes = append(interp.RecordTypes[:i], interp.RecordTypes[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("Record type `%s' is not registered", name)
+}
+
+// RecordType returns the record type with the name registered on the interpreter or its parents, nil if there is none
+func (interp *Interpreter) RecordType(name string) *token.RecordType {
+	for scope := interp; scope != nil; scope = scope.Parent {
+		for i := 0; i < len(scope.RecordTypes); i++ {
+			if strings.EqualFold(scope.RecordTypes[i].Name, name) {
+				return &scope.RecordTypes[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (interp *Interpreter) AllRecordTypes() (records []token.RecordType) {
+	if len(interp.RecordTypes) > 0 {
+		records = append(records, interp.RecordTypes...)
+	}
+	if interp.Parent != nil {
+		records = append(records, interp.Parent.AllRecordTypes()...)
+	}
+	return records
+}
+
+// isRecordName returns true if the name can be used for a record type, it must be an identifier that is not a kind
+func isRecordName(name string) bool {
+	return recordName.MatchString(name) && token.KindFromString(name) == 0
+}
+
+// matchRecords checks the (evaluated) arguments against the record types of the signature
+func (interp *Interpreter) matchRecords(sig *CommonSignature, args []*token.TaToken) error {
+	if len(sig.ArgumentRecords) <= 0 {
+		return nil
+	}
+	argc := len(sig.Arguments)
+	for i, j := 0, 0; i < len(args); i++ {
+		if name := sig.ArgumentRecord(j); len(name) > 0 {
+			if err := interp.matchRecord(name, args[i]); err != nil {
+				return err
+			}
+		}
+		if j < argc-1 {
+			j++
+		}
+	}
+	return nil
+}
+
+// evaluateRecordArguments evaluates the template arguments that have a record type, so they can be checked.
+// The template gets the values instead of the blocks, so the arguments are not evaluated twice.
+// evaluated keeps the values for the other templates that are tried with the same arguments.
+func (interp *Interpreter) evaluateRecordArguments(sig *CommonSignature, args []*token.TaToken, evaluated []*token.TaToken) ([]*token.TaToken, error) {
+	if len(sig.ArgumentRecords) <= 0 {
+		return args, nil
+	}
+	result := make([]*token.TaToken, len(args))
+	copy(result, args)
+	argc := len(sig.Arguments)
+	for i, j := 0, 0; i < len(args); i++ {
+		if len(sig.ArgumentRecord(j)) > 0 && args[i].IsBlock() {
+			if evaluated[i] == nil {
+				value := new(token.TaToken)
+				token.Copy(value, args[i])
+				if err := interp.Evaluate(value); err != nil {
+					return nil, err
+				}
+				evaluated[i] = value
+			}
+			result[i] = evaluated[i]
+		}
+		if j < argc-1 {
+			j++
+		}
+	}
+	return result, nil
+}
+
+// checkRecordTypes returns an error if the signature uses a record type that is not registered,
+// a misspelled name like Decmial would otherwise be a record type that never matches
+func (interp *Interpreter) checkRecordTypes(sig *CommonSignature) error {
+	for _, name := range sig.ArgumentRecords {
+		if len(name) > 0 && interp.RecordType(name) == nil {
+			return errors.Errorf("Unknown record type `%s' in `%s'", name, sig.String())
+		}
+	}
+	if len(sig.ReturnsRecord) > 0 && interp.RecordType(sig.ReturnsRecord) == nil {
+		return errors.Errorf("Unknown record type `%s' in `%s'", sig.ReturnsRecord, sig.String())
+	}
+	return nil
+}
+
+func (interp *Interpreter) matchRecord(name string, arg *token.TaToken) error {
+	record := interp.RecordType(name)
+	if record == nil {
+		return errors.Errorf("Unknown record type `%s'", name)
+	}
+	switch arg.Kind {
+	case token.Map:
+		if !record.Matches(arg) {
+			return errors.Errorf("`%s' does not match record type `%s'", arg.Stringify(), record.String())
+		}
+	case token.List:
+		for _, item := range arg.Children {
+			if err := interp.matchRecord(name, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordKindFromString returns the kind and the record name of a record type (`CartItem`) or a list of records (`List<CartItem>`)
+func recordKindFromString(s string) (token.Kind, string) {
+	s = strings.TrimSpace(s)
+	if open := strings.IndexRune(s, '<'); open > 0 && strings.HasSuffix(s, ">") {
+		name := strings.TrimSpace(s[open+1 : len(s)-1])
+		if token.KindFromString(s[:open]) == token.List && recordName.MatchString(name) {
+			return token.ListOf(token.Map), name
+		}
+		return 0, ""
+	}
+	if recordName.MatchString(s) {
+		return token.Map, s
+	}
+	return 0, ""
+}
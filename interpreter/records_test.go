@@ -0,0 +1,219 @@
+package interpreter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/talon-one/decimal"
+	"github.com/talon-one/talang/interpreter"
+	helpers "github.com/talon-one/talang/testhelpers"
+	"github.com/talon-one/talang/token"
+)
+
+var cartItemRecord = token.RecordType{
+	Name: "CartItem",
+	Fields: map[string]token.Kind{
+		"Name":  token.String,
+		"Price": token.Decimal,
+	},
+}
+
+func cartItem(name string, price int64) *token.TaToken {
+	return token.NewMap(map[string]*token.TaToken{
+		"Name":  token.NewString(name),
+		"Price": token.NewDecimalFromInt(price),
+	})
+}
+
+func TestRegisterRecordType(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, interp.RegisterRecordType(cartItemRecord))
+	require.Error(t, interp.RegisterRecordType(cartItemRecord))
+	require.Error(t, interp.RegisterRecordType(token.RecordType{Name: "Decimal"}))
+	require.Error(t, interp.RegisterRecordType(token.RecordType{Name: "Cart Item"}))
+	require.Error(t, interp.RegisterRecordType(token.RecordType{Name: "Profile", Fields: map[string]token.Kind{"Age": 0}}))
+
+	require.Equal(t, "CartItem{Name String, Price Decimal}", interp.RecordType("cartitem").String())
+
+	// record types are visible in scopes
+	scope := interp.NewScope()
+	require.NotNil(t, scope.RecordType("CartItem"))
+	require.Len(t, scope.AllRecordTypes(), 1)
+
+	require.NoError(t, interp.RemoveRecordType("CartItem"))
+	require.Nil(t, interp.RecordType("CartItem"))
+	require.Error(t, interp.RemoveRecordType("CartItem"))
+}
+
+func TestRecordSignatures(t *testing.T) {
+	sig := interpreter.NewCommonSignature("cartTotal(items List<CartItem>, CartItem)CartItem")
+	require.NotNil(t, sig)
+	require.Equal(t, []token.Kind{token.ListOf(token.Map), token.Map}, sig.Arguments)
+	require.Equal(t, []string{"CartItem", "CartItem"}, sig.ArgumentRecords)
+	require.Equal(t, token.Map, sig.Returns)
+	require.Equal(t, "CartItem", sig.ReturnsRecord)
+	require.Equal(t, "cartTotal(items List<CartItem>, CartItem)CartItem", sig.String())
+
+	// signatures with different record types are not equal
+	require.False(t, sig.Equal(interpreter.NewCommonSignature("cartTotal(items List<Profile>, CartItem)CartItem")))
+}
+
+func TestRecordTemplates(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustRegisterRecordType(cartItemRecord)
+	interp.MustLexAndEvaluate(`(setTemplate "cartTotal(List<CartItem>)Decimal" (sum (# 0) Item (. Item Price)))`)
+
+	interp.Set("Items", token.NewList(cartItem("Shoe", 20), cartItem("Sock", 5)))
+	require.Equal(t, "25", interp.MustLexAndEvaluate("(! cartTotal (. Items))").String)
+
+	// the price is not a decimal
+	interp.Set("Items", token.NewList(cartItem("Shoe", 20), token.NewMap(map[string]*token.TaToken{
+		"Name":  token.NewString("Sock"),
+		"Price": token.NewString("cheap"),
+	})))
+	require.Error(t, getError(interp.LexAndEvaluate("(! cartTotal (. Items))")))
+
+	// a field is missing
+	interp.Set("Items", token.NewList(token.NewMap(map[string]*token.TaToken{
+		"Name": token.NewString("Shoe"),
+	})))
+	require.Error(t, getError(interp.LexAndEvaluate("(! cartTotal (. Items))")))
+
+	// record arguments are evaluated once, the template gets the value
+	calls := 0
+	interp.MustRegisterFunction(interpreter.TaFunction{
+		CommonSignature: interpreter.MustNewCommonSignature("items()List<CartItem>"),
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			calls++
+			return token.NewList(cartItem("Shoe", 20), cartItem("Sock", 5)), nil
+		},
+	})
+	require.Equal(t, "25", interp.MustLexAndEvaluate("(! cartTotal (items))").String)
+	require.Equal(t, 1, calls)
+}
+
+func TestRecordFunctions(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustRegisterRecordType(cartItemRecord)
+	interp.MustRegisterFunction(interpreter.TaFunction{
+		CommonSignature: interpreter.MustNewCommonSignature("price(CartItem)Decimal"),
+		Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+			return args[0].MapItem("Price"), nil
+		},
+	})
+
+	interp.Set("Item", cartItem("Shoe", 20))
+	require.Equal(t, "20", interp.MustLexAndEvaluate("(price (. Item))").String)
+
+	interp.Set("Item", token.NewMap(map[string]*token.TaToken{"Name": token.NewString("Shoe")}))
+	require.Error(t, getError(interp.LexAndEvaluate("(price (. Item))")))
+
+	// unknown record types, like misspelled kinds, cannot be registered
+	for _, signature := range []string{"weight(Parcel)Decimal", "f(Decmial)Decimal", "f(Decimal)Parcel", "f(List<Parcel>)Decimal"} {
+		require.Error(t, interp.RegisterFunction(interpreter.TaFunction{
+			CommonSignature: interpreter.MustNewCommonSignature(signature),
+			Func: func(interp *interpreter.Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
+				return token.NewDecimalFromInt(1), nil
+			},
+		}), signature)
+	}
+	require.Error(t, getError(interp.LexAndEvaluate(`(setTemplate "weight(Parcel)Decimal" 1)`)))
+}
+
+type Product struct {
+	Name  string
+	Price decimal.Decimal
+	Tags  []string
+	Stock map[string]int
+}
+
+type Cart struct {
+	Items    []Product
+	Customer *struct {
+		Name string
+	}
+	Express bool
+	note    string
+}
+
+func TestRecordTypeOf(t *testing.T) {
+	record, err := interpreter.RecordTypeOf(&Product{})
+	require.NoError(t, err)
+	require.Equal(t, &token.RecordType{
+		Name: "Product",
+		Fields: map[string]token.Kind{
+			"Name":  token.String,
+			"Price": token.Decimal,
+			"Tags":  token.ListOf(token.String),
+			"Stock": token.MapOf(token.Decimal),
+		},
+	}, record)
+
+	record, err = interpreter.RecordTypeOf(Cart{})
+	require.NoError(t, err)
+	require.Equal(t, "Cart{Customer Map, Express Boolean, Items List<Map>}", record.String())
+
+	_, err = interpreter.RecordTypeOf(struct{ Name string }{})
+	require.Error(t, err)
+	_, err = interpreter.RecordTypeOf(1)
+	require.Error(t, err)
+}
+
+type Order struct {
+	CreatedAt time.Time
+	ShippedAt *time.Time
+}
+
+func TestRecordTypeOfTime(t *testing.T) {
+	record, err := interpreter.RecordTypeOf(Order{})
+	require.NoError(t, err)
+	require.Equal(t, "Order{CreatedAt Time, ShippedAt Time}", record.String())
+
+	created := time.Date(2018, 1, 2, 19, 4, 5, 0, time.UTC)
+	interp := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, interp.GenericSet("Order", Order{CreatedAt: created, ShippedAt: &created}))
+	require.Len(t, interp.AllRecordTypes(), 1)
+	require.True(t, interp.MustLexAndEvaluate("(. Order CreatedAt)").Equal(token.NewTime(created)))
+	require.True(t, interp.MustLexAndEvaluate("(. Order ShippedAt)").Equal(token.NewTime(created)))
+
+	var order Order
+	require.NoError(t, interp.GenericGet("Order", &order))
+	require.True(t, created.Equal(order.CreatedAt))
+	require.True(t, created.Equal(*order.ShippedAt))
+}
+
+func TestGenericSetRecords(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, interp.GenericSet("Cart", Cart{
+		Items: []Product{
+			{Name: "Shoe", Price: decimal.NewFromInt(20)},
+		},
+		Customer: &struct{ Name string }{Name: "Alice"},
+	}))
+
+	// the record types of all named structs got registered
+	require.NotNil(t, interp.RecordType("Cart"))
+	require.NotNil(t, interp.RecordType("Product"))
+	require.Len(t, interp.AllRecordTypes(), 2)
+
+	cart := interp.Get("Cart")
+	require.Equal(t, "Cart", cart.Record)
+	require.Equal(t, "Product", cart.MapItem("Items").Children[0].Record)
+
+	// setting the value again does not register the record types twice
+	require.NoError(t, interp.GenericSet("Cart", Cart{Customer: &struct{ Name string }{}}))
+	require.Len(t, interp.AllRecordTypes(), 2)
+
+	interp.MustLexAndEvaluate(`(setTemplate "checkout(Cart)Map" (# 0))`)
+	require.Equal(t, "Cart", interp.MustLexAndEvaluate(`(! checkout (. Cart))`).Record)
+	require.Error(t, getError(interp.LexAndEvaluate(`(! checkout (kv (Express true)))`)))
+
+	// a struct with the same name but different fields is not attached to the registered record type
+	type Product struct {
+		Name string
+	}
+	require.Error(t, interp.GenericSet("Other", Product{Name: "Shoe"}))
+	require.True(t, interp.Get("Other").IsNull())
+	require.Equal(t, "Product{Name String, Price Decimal, Stock Map<String, Decimal>, Tags List<String>}", interp.RecordType("Product").String())
+}
@@ -0,0 +1,140 @@
+package interpreter
+
+import (
+	"container/list"
+	"regexp"
+	"regexp/syntax"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultMaxRegexpLength     = 1000
+	DefaultMaxRegexpComplexity = 1000
+	DefaultRegexpCacheSize     = 128
+)
+
+// regexpCacheInit guards the creation of the caches, the caches guard themselves
+var regexpCacheInit sync.Mutex
+
+// regexpCache is a least recently used cache of compiled regular expressions
+type regexpCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type regexpCacheEntry struct {
+	pattern    string
+	re         *regexp.Regexp
+	complexity int
+}
+
+func newRegexpCache(size int) *regexpCache {
+	return &regexpCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *regexpCache) get(pattern string) *regexpCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*regexpCacheEntry)
+	}
+	return nil
+}
+
+func (c *regexpCache) add(entry *regexpCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[entry.pattern]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[entry.pattern] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexpCacheEntry).pattern)
+	}
+}
+
+func (c *regexpCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// CompileRegexp compiles the pattern, compiled patterns are cached by the root interpreter.
+// Patterns that are longer than MaxRegexpLength or compile to more than MaxRegexpComplexity instructions are rejected
+func (interp *Interpreter) CompileRegexp(pattern string) (*regexp.Regexp, error) {
+	if max := interp.regexpLimit(func(scope *Interpreter) int { return scope.MaxRegexpLength }, DefaultMaxRegexpLength); len(pattern) > max {
+		return nil, errors.Errorf("The regular expression is longer than %d characters", max)
+	}
+
+	cache := interp.root().regexpCache()
+	entry := cache.get(pattern)
+	if entry == nil {
+		parsed, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid regular expression")
+		}
+		prog, err := syntax.Compile(parsed.Simplify())
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid regular expression")
+		}
+		entry = &regexpCacheEntry{pattern: pattern, complexity: len(prog.Inst)}
+		// the limits can differ between scopes, so the complexity is checked before the expression is compiled and for cached expressions
+		if entry.complexity <= interp.maxRegexpComplexity() {
+			if entry.re, err = regexp.Compile(pattern); err != nil {
+				return nil, errors.Wrap(err, "Invalid regular expression")
+			}
+			cache.add(entry)
+		}
+	}
+	if entry.complexity > interp.maxRegexpComplexity() {
+		return nil, errors.Errorf("The regular expression `%s' is too complex", pattern)
+	}
+	return entry.re, nil
+}
+
+func (interp *Interpreter) maxRegexpComplexity() int {
+	return interp.regexpLimit(func(scope *Interpreter) int { return scope.MaxRegexpComplexity }, DefaultMaxRegexpComplexity)
+}
+
+// regexpLimit returns the first limit that is set on the interpreter or its parents
+func (interp *Interpreter) regexpLimit(limit func(*Interpreter) int, fallback int) int {
+	for scope := interp; scope != nil; scope = scope.Parent {
+		if l := limit(scope); l > 0 {
+			return l
+		}
+	}
+	return fallback
+}
+
+func (interp *Interpreter) root() *Interpreter {
+	scope := interp
+	for scope.Parent != nil {
+		scope = scope.Parent
+	}
+	return scope
+}
+
+func (interp *Interpreter) regexpCache() *regexpCache {
+	regexpCacheInit.Lock()
+	defer regexpCacheInit.Unlock()
+	if interp.regexps == nil {
+		size := interp.RegexpCacheSize
+		if size <= 0 {
+			size = DefaultRegexpCacheSize
+		}
+		interp.regexps = newRegexpCache(size)
+	}
+	return interp.regexps
+}
@@ -0,0 +1,89 @@
+package interpreter
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	interp := MustNewInterpreter()
+	re, err := interp.CompileRegexp(`^\d+$`)
+	require.NoError(t, err)
+	require.True(t, re.MatchString("2018"))
+
+	// scopes use the cache of the root interpreter
+	cached, err := interp.NewScope().NewScope().CompileRegexp(`^\d+$`)
+	require.NoError(t, err)
+	require.True(t, re == cached)
+	require.Equal(t, 1, interp.regexps.len())
+
+	_, err = interp.CompileRegexp(`(`)
+	require.Error(t, err)
+	require.Equal(t, 1, interp.regexps.len())
+}
+
+func TestCompileRegexpCacheIsBounded(t *testing.T) {
+	interp := MustNewInterpreter()
+	interp.RegexpCacheSize = 2
+
+	a, err := interp.CompileRegexp("a")
+	require.NoError(t, err)
+	_, err = interp.CompileRegexp("b")
+	require.NoError(t, err)
+	// use a, so b is the least recently used expression
+	cached, err := interp.CompileRegexp("a")
+	require.NoError(t, err)
+	require.True(t, a == cached)
+	_, err = interp.CompileRegexp("c")
+	require.NoError(t, err)
+
+	require.Equal(t, 2, interp.regexps.len())
+	require.NotNil(t, interp.regexps.get("a"))
+	require.Nil(t, interp.regexps.get("b"))
+	require.NotNil(t, interp.regexps.get("c"))
+}
+
+func TestCompileRegexpLimits(t *testing.T) {
+	interp := MustNewInterpreter()
+	_, err := interp.CompileRegexp(strings.Repeat("a", DefaultMaxRegexpLength+1))
+	require.Error(t, err)
+	_, err = interp.CompileRegexp(`((a{100}){100}){100}`)
+	require.Error(t, err)
+
+	interp.MaxRegexpLength = 5
+	_, err = interp.CompileRegexp("abcdef")
+	require.EqualError(t, err, "The regular expression is longer than 5 characters")
+
+	// stricter limits of a scope are used for cached expressions as well
+	interp.MaxRegexpLength = 0
+	_, err = interp.CompileRegexp(`\w{1,20}`)
+	require.NoError(t, err)
+	scope := interp.NewScope()
+	scope.MaxRegexpComplexity = 10
+	_, err = scope.CompileRegexp(`\w{1,20}`)
+	require.EqualError(t, err, "The regular expression `\\w{1,20}' is too complex")
+	_, err = scope.CompileRegexp(`\w`)
+	require.NoError(t, err)
+}
+
+func TestCompileRegexpConcurrently(t *testing.T) {
+	interp := MustNewInterpreter()
+	interp.RegexpCacheSize = 4
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				re, err := interp.NewScope().CompileRegexp(strings.Repeat("a", (i+j)%6+1))
+				require.NoError(t, err)
+				require.NotNil(t, re)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 4, interp.regexps.len())
+}
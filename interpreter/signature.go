@@ -2,31 +2,139 @@ package interpreter
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 
+	"github.com/talon-one/talang/lexer"
 	"github.com/talon-one/talang/token"
 )
 
 type TaFunc func(*Interpreter, ...*token.TaToken) (*token.TaToken, error)
 
 type CommonSignature struct {
-	IsVariadic  bool
-	Arguments   []token.Kind
-	Name        string
-	lowerName   string
-	Returns     token.Kind
-	Description string
-	Example     string
+	IsVariadic bool
+	Arguments  []token.Kind
+	// ArgumentNames contains the (optional) names of the arguments, a name can be
+	// used to reference the argument in templates using (# name)
+	ArgumentNames []string
+	// ArgumentDefaults contains the (optional) default values of the arguments, only
+	// trailing arguments can have a default value
+	ArgumentDefaults []*token.TaToken
+	// ArgumentRecords contains the (optional) names of the record types of the arguments,
+	// an argument with a record type has the kind Map or List<Map>
+	ArgumentRecords []string
+	Name            string
+	lowerName       string
+	Returns         token.Kind
+	// ReturnsRecord contains the (optional) name of the record type of the result
+	ReturnsRecord string
+	Description   string
+	Example       string
 }
 
 type TaFunction struct {
 	CommonSignature
-	Func TaFunc `json:"-"`
+	// SharesArguments can be set if the function never changes its arguments,
+	// immutable lists and maps are then passed without copying them
+	SharesArguments bool   `json:"-"`
+	Func            TaFunc `json:"-"`
 }
 
 type TaTemplate struct {
 	CommonSignature
 	Template token.TaToken
+	// Namespace and Version are set if the template was loaded from a TemplateLibrary,
+	// templates with a namespace are called using their qualified name (namespace.name)
+	// or their versioned name (namespace@version.name)
+	Namespace string
+	Version   string
+
+	lowerVersionedName string
+}
+
+type TaMacro struct {
+	CommonSignature
+	Func TaFunc `json:"-"`
+}
+
+// ArgumentString returns the string representation of the argument at index i,
+// including its name and default value
+func (s *CommonSignature) ArgumentString(i int) string {
+	str := kindString(s.Arguments[i], s.ArgumentRecord(i))
+	if name := s.ArgumentName(i); len(name) > 0 {
+		str = name + " " + str
+	}
+	// optional kinds are null by default
+	if def := s.ArgumentDefault(i); def != nil && !(def.IsNull() && strings.HasSuffix(str, "?")) {
+		str += " = " + def.Stringify()
+	}
+	return str
+}
+
+// ArgumentName returns the name of the argument at index i, or an empty string if the argument has no name
+func (s *CommonSignature) ArgumentName(i int) string {
+	if i < len(s.ArgumentNames) {
+		return s.ArgumentNames[i]
+	}
+	return ""
+}
+
+// ArgumentRecord returns the name of the record type of the argument at index i, or an empty string if the argument has none
+func (s *CommonSignature) ArgumentRecord(i int) string {
+	if i < len(s.ArgumentRecords) {
+		return s.ArgumentRecords[i]
+	}
+	return ""
+}
+
+// kindString returns the string representation of a kind, or of the record type if there is one
+func kindString(kind token.Kind, record string) string {
+	if len(record) <= 0 {
+		return kind.String()
+	}
+	if kind.Base() == token.List {
+		return "List<" + record + ">"
+	}
+	return record
+}
+
+// ArgumentDefault returns the default value of the argument at index i, or nil if the argument has none
+func (s *CommonSignature) ArgumentDefault(i int) *token.TaToken {
+	if i < len(s.ArgumentDefaults) {
+		return s.ArgumentDefaults[i]
+	}
+	return nil
+}
+
+// RequiredArgumentCount returns the number of arguments that have no default value
+func (s *CommonSignature) RequiredArgumentCount() int {
+	argc := len(s.Arguments)
+	if s.IsVariadic {
+		return argc - 1
+	}
+	for argc > 0 && s.ArgumentDefault(argc-1) != nil {
+		argc--
+	}
+	return argc
+}
+
+// appendDefaults appends copies of the default values for all missing trailing arguments
+func (s *CommonSignature) appendDefaults(args []*token.TaToken) []*token.TaToken {
+	if s.IsVariadic || len(args) >= len(s.Arguments) {
+		return args
+	}
+	result := make([]*token.TaToken, len(args), len(s.Arguments))
+	copy(result, args)
+	for i := len(args); i < len(s.Arguments); i++ {
+		def := s.ArgumentDefault(i)
+		if def == nil {
+			break
+		}
+		result = append(result, new(token.TaToken))
+		token.Copy(result[i], def)
+	}
+	return result
 }
 
 func (s *CommonSignature) String() string {
@@ -34,7 +142,7 @@ func (s *CommonSignature) String() string {
 	if argc := len(s.Arguments); argc > 0 {
 		argv := make([]string, argc)
 		for i := 0; i < argc; i++ {
-			argv[i] = s.Arguments[i].String()
+			argv[i] = s.ArgumentString(i)
 		}
 		args = strings.Join(argv, ", ")
 	}
@@ -43,16 +151,25 @@ func (s *CommonSignature) String() string {
 	if s.IsVariadic {
 		variadic = "..."
 	}
-	return fmt.Sprintf("%s(%s%s)%s", s.Name, args, variadic, s.Returns.String())
+	var returns string
+	if s.Returns != 0 || len(s.ReturnsRecord) > 0 {
+		returns = kindString(s.Returns, s.ReturnsRecord)
+	}
+	return fmt.Sprintf("%s(%s%s)%s", s.Name, args, variadic, returns)
 }
 
+var kindSeparator = regexp.MustCompile(`\s*\|\s*`)
+
+// NewCommonSignature parses a signature in the form of `name(Kind, Kind...)Kind`,
+// arguments can be named and trailing arguments can have default values: `name(a Kind, b Kind = 0)Kind`.
+// Arguments with an optional kind (`Kind?`) accept null, trailing ones can be omitted: `name(a Kind, b Kind?)Kind`
 func NewCommonSignature(s string) *CommonSignature {
 	size := len(s)
 	if size <= 0 {
 		return nil
 	}
 	bracketOpen := strings.IndexRune(s, '(')
-	bracketClose := strings.IndexRune(s, ')')
+	bracketClose := strings.LastIndex(s, ")")
 	if bracketOpen <= 0 || bracketClose <= bracketOpen {
 		return nil
 	}
@@ -62,24 +179,155 @@ func NewCommonSignature(s string) *CommonSignature {
 	signature.Name = strings.TrimSpace(s[:bracketOpen])
 	signature.lowerName = strings.ToLower(signature.Name)
 
-	arguments := strings.Split(s[bracketOpen+1:bracketClose], ",")
+	var names []string
+	var defaults []*token.TaToken
+	var records []string
+	hasNames := false
+	hasDefaults := false
+	hasRecords := false
+
+	// optional contains true for the arguments that are optional kinds (`Kind?`) without a default value
+	var optional []bool
+
+	arguments := splitArguments(s[bracketOpen+1 : bracketClose])
 	for i, l := 0, len(arguments)-1; i <= l; i++ {
-		if part := strings.TrimSpace(arguments[i]); len(part) > 0 {
-			if i == l && strings.HasSuffix(part, "...") {
-				signature.IsVariadic = true
-				part = part[:len(part)-3]
+		part := strings.TrimSpace(arguments[i])
+		if len(part) <= 0 {
+			continue
+		}
+
+		var def *token.TaToken
+		if pos := strings.IndexRune(part, '='); pos >= 0 {
+			var err error
+			def, err = lexer.Lex(strings.TrimSpace(part[pos+1:]))
+			if err != nil || def.IsEmpty() || def.IsBlock() {
+				return nil
+			}
+			part = strings.TrimSpace(part[:pos])
+			hasDefaults = true
+		} else if hasDefaults && !strings.HasSuffix(part, "?") {
+			// only trailing arguments can have default values
+			return nil
+		}
+		optional = append(optional, def == nil && strings.HasSuffix(part, "?"))
+
+		if i == l && strings.HasSuffix(part, "...") {
+			if hasDefaults {
+				return nil
 			}
-			signature.Arguments = append(signature.Arguments, token.KindFromString(part))
+			signature.IsVariadic = true
+			part = part[:len(part)-3]
 		}
+
+		var name string
+		fields := strings.Fields(compactKind(kindSeparator.ReplaceAllString(part, "|")))
+		switch len(fields) {
+		case 1:
+			part = fields[0]
+		case 2:
+			name = fields[0]
+			part = fields[1]
+			for _, n := range names {
+				if strings.EqualFold(n, name) {
+					return nil
+				}
+			}
+			hasNames = true
+		default:
+			return nil
+		}
+
+		kind := token.KindFromString(part)
+		var record string
+		if kind == 0 {
+			if kind, record = recordKindFromString(part); len(record) > 0 {
+				hasRecords = true
+			}
+		}
+		if def != nil && !kind.Accepts(def.Kind) {
+			return nil
+		}
+
+		signature.Arguments = append(signature.Arguments, kind)
+		names = append(names, name)
+		defaults = append(defaults, def)
+		records = append(records, record)
+	}
+
+	// trailing optional arguments can be omitted, they are null then
+	for i := len(defaults) - 1; i >= 0 && (defaults[i] != nil || optional[i]); i-- {
+		if optional[i] && !signature.IsVariadic {
+			defaults[i] = token.NewNull()
+			hasDefaults = true
+		}
+	}
+
+	if hasNames {
+		signature.ArgumentNames = names
+	}
+	if hasDefaults {
+		signature.ArgumentDefaults = defaults
+	}
+	if hasRecords {
+		signature.ArgumentRecords = records
 	}
 
 	if size > bracketClose {
-		signature.Returns = token.KindFromString(s[bracketClose+1:])
+		returns := s[bracketClose+1:]
+		if signature.Returns = token.KindFromString(returns); signature.Returns == 0 {
+			signature.Returns, signature.ReturnsRecord = recordKindFromString(returns)
+		}
 	}
 
 	return &signature
 }
 
+// splitArguments splits the argument list at every comma that is not part of a quoted default value
+// or of a typed collection (Map<String, Kind>)
+func splitArguments(s string) []string {
+	var parts []string
+	var quote rune
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '<':
+			depth++
+		case r == '>':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// compactKind removes the whitespace inside the angle brackets of typed collections,
+// so `Map<String, Kind>` stays a single field
+func compactKind(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '<':
+			depth++
+		case r == '>':
+			depth--
+		case depth > 0 && unicode.IsSpace(r):
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 func MustNewCommonSignature(s string) CommonSignature {
 	if sig := NewCommonSignature(s); sig != nil {
 		return *sig
@@ -95,28 +343,75 @@ func (a *CommonSignature) Equal(b *CommonSignature) bool {
 		return false
 	}
 	for i, arg := range a.Arguments {
-		if b.Arguments[i] != arg {
+		if b.Arguments[i] != arg || !strings.EqualFold(a.ArgumentRecord(i), b.ArgumentRecord(i)) {
 			return false
 		}
 	}
 	return a.lowerName == b.lowerName
 }
 
+// overlaps returns true if a call can match both signatures: they have the same name, an argument count
+// both accept (arguments with default values and optional kinds can be left out) and argument kinds with a kind in common
+func (a *CommonSignature) overlaps(b *CommonSignature) bool {
+	if a.lowerName != b.lowerName {
+		return false
+	}
+	argc := a.RequiredArgumentCount()
+	if required := b.RequiredArgumentCount(); required > argc {
+		argc = required
+	}
+	if (!a.IsVariadic && argc > len(a.Arguments)) || (!b.IsVariadic && argc > len(b.Arguments)) {
+		return false
+	}
+	// more arguments only add more kinds that must match, so the smallest count both accept decides
+	for i := 0; i < argc; i++ {
+		if a.argumentKind(i).Base()&b.argumentKind(i).Base() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// argumentKind returns the kind of the argument at index i, the last kind is repeated for variadic signatures
+func (s *CommonSignature) argumentKind(i int) token.Kind {
+	if len(s.Arguments) <= 0 {
+		return token.Any
+	}
+	if i >= len(s.Arguments) {
+		return s.Arguments[len(s.Arguments)-1]
+	}
+	return s.Arguments[i]
+}
+
 func (sig *CommonSignature) MatchesArguments(args []token.Kind) bool {
+	return sig.matches(len(args), func(i int, expected token.Kind) token.Kind {
+		return args[i]
+	})
+}
+
+// matchesTokens is MatchesArguments for tokens, the element kinds of lists and maps
+// are only collected for the arguments that expect a typed collection
+func (sig *CommonSignature) matchesTokens(args []*token.TaToken) bool {
+	return sig.matches(len(args), func(i int, expected token.Kind) token.Kind {
+		return token.KindFor(args[i], expected)
+	})
+}
+
+func (sig *CommonSignature) matches(argc int, kindOf func(i int, expected token.Kind) token.Kind) bool {
 	if !sig.IsVariadic {
-		if len(args) != len(sig.Arguments) {
+		if argc < sig.RequiredArgumentCount() || argc > len(sig.Arguments) {
 			return false
 		}
-		for i, kind := range args {
-			if sig.Arguments[i]&kind == 0 {
+		for i := 0; i < argc; i++ {
+			if !sig.Arguments[i].Accepts(kindOf(i, sig.Arguments[i])) {
 				return false
 			}
 		}
 		return true
 	}
 	sigArgc := len(sig.Arguments) - 1
-	for i, j := 0, 0; i < len(args); i++ {
-		if sig.Arguments[j]&args[i] == 0 {
+	for i, j := 0, 0; i < argc; i++ {
+		if !sig.Arguments[j].Accepts(kindOf(i, sig.Arguments[j])) {
 			return false
 		}
 		if i < sigArgc {
@@ -142,14 +437,60 @@ func (s *TaFunction) MatchesArguments(args []token.Kind) bool {
 	return s.CommonSignature.MatchesArguments(args)
 }
 
+func (s *TaTemplate) sanitize() {
+	s.lowerName = strings.ToLower(s.QualifiedName())
+	s.lowerVersionedName = strings.ToLower(s.VersionedName())
+}
+
+// QualifiedName returns the name prefixed with the namespace
+func (s *TaTemplate) QualifiedName() string {
+	if len(s.Namespace) > 0 {
+		return s.Namespace + "." + s.Name
+	}
+	return s.Name
+}
+
+// VersionedName returns the name prefixed with the namespace and the version,
+// it is the qualified name if the template has no namespace or version
+func (s *TaTemplate) VersionedName() string {
+	if len(s.Namespace) > 0 && len(s.Version) > 0 {
+		return s.Namespace + "@" + s.Version + "." + s.Name
+	}
+	return s.QualifiedName()
+}
+
+// hasName returns true if the lower case name is the qualified or the versioned name of the template
+func (s *TaTemplate) hasName(lowerName string) bool {
+	return s.lowerName == lowerName || s.lowerVersionedName == lowerName
+}
+
 func (s *TaTemplate) String() string {
-	return s.CommonSignature.String()
+	sig := s.CommonSignature
+	sig.Name = s.QualifiedName()
+	return sig.String()
 }
 
 func (a *TaTemplate) Equal(b *TaTemplate) bool {
-	return a.CommonSignature.Equal(&b.CommonSignature)
+	return a.CommonSignature.Equal(&b.CommonSignature) && strings.EqualFold(a.Version, b.Version)
+}
+
+// overlaps returns true if a call can match both templates of the same version, see CommonSignature.overlaps
+func (a *TaTemplate) overlaps(b *TaTemplate) bool {
+	return strings.EqualFold(a.Version, b.Version) && a.CommonSignature.overlaps(&b.CommonSignature)
 }
 
 func (s *TaTemplate) MatchesArguments(args []token.Kind) bool {
 	return s.CommonSignature.MatchesArguments(args)
 }
+
+func (s *TaMacro) String() string {
+	return s.CommonSignature.String()
+}
+
+func (a *TaMacro) Equal(b *TaMacro) bool {
+	return a.CommonSignature.Equal(&b.CommonSignature)
+}
+
+func (s *TaMacro) MatchesArguments(args []token.Kind) bool {
+	return s.CommonSignature.MatchesArguments(args)
+}
@@ -218,9 +218,125 @@ func TestSignatureString(t *testing.T) {
 			"Plus()",
 			"Plus()",
 		},
+		{
+			"plus(a Decimal, b Decimal=0)Decimal",
+			"plus(a Decimal, b Decimal = 0)Decimal",
+		},
+		{
+			`greet(name String, greeting String = "Hello, World")String`,
+			`greet(name String, greeting String = "Hello, World")String`,
+		},
+		{
+			"plus(a Decimal, Decimal...)Decimal",
+			"plus(a Decimal, Decimal...)Decimal",
+		},
+		{
+			"sum(List<Decimal>)Decimal",
+			"sum(List<Decimal>)Decimal",
+		},
+		{
+			"filter(flags Map< String , Boolean >, keys List<String|Decimal>...)Map<String,Boolean>",
+			"filter(flags Map<String, Boolean>, keys List<Decimal|String>...)Map<String, Boolean>",
+		},
 	}
 
 	for i, test := range tests {
 		require.Equal(t, test.expected, NewCommonSignature(test.input).String(), "Test %d failed", i)
 	}
 }
+
+func TestSignatureParseNamedArguments(t *testing.T) {
+	require.EqualValues(t, &CommonSignature{
+		Name:      "plus",
+		lowerName: "plus",
+		Arguments: []token.Kind{
+			token.Decimal,
+			token.Decimal,
+		},
+		ArgumentNames: []string{"a", "b"},
+		Returns:       token.Decimal,
+	}, NewCommonSignature("plus(a Decimal, b Decimal)Decimal"))
+
+	require.EqualValues(t, &CommonSignature{
+		Name:      "plus",
+		lowerName: "plus",
+		Arguments: []token.Kind{
+			token.Decimal,
+			token.Decimal | token.String,
+		},
+		ArgumentNames: []string{"a", ""},
+		IsVariadic:    true,
+		Returns:       token.Decimal,
+	}, NewCommonSignature("plus(a Decimal, Decimal | String...)Decimal"))
+
+	sig := NewCommonSignature(`greet(name String, greeting String = "Hello, ", times Decimal = 1)String`)
+	require.NotNil(t, sig)
+	require.Equal(t, []string{"name", "greeting", "times"}, sig.ArgumentNames)
+	require.Nil(t, sig.ArgumentDefault(0))
+	require.Equal(t, true, token.NewString("Hello, ").Equal(sig.ArgumentDefault(1)))
+	require.Equal(t, true, token.NewDecimalFromInt(1).Equal(sig.ArgumentDefault(2)))
+	require.Equal(t, 1, sig.RequiredArgumentCount())
+
+	// defaults must be trailing
+	require.Nil(t, NewCommonSignature("plus(a Decimal = 1, b Decimal)Decimal"))
+	// defaults must match the kind
+	require.Nil(t, NewCommonSignature("plus(a Decimal, b Decimal = true)Decimal"))
+	// defaults can not be used with variadic arguments
+	require.Nil(t, NewCommonSignature("plus(a Decimal = 1, b Decimal...)Decimal"))
+	// names must be unique
+	require.Nil(t, NewCommonSignature("plus(a Decimal, A Decimal)Decimal"))
+	require.Nil(t, NewCommonSignature("plus(a b Decimal)Decimal"))
+}
+
+func TestSignatureMatchesDefaultArguments(t *testing.T) {
+	sig := MustNewCommonSignature("fn(a Decimal, b String = Hello, c Boolean = false)")
+
+	require.Equal(t, false, sig.MatchesArguments([]token.Kind{}))
+	require.Equal(t, true, sig.MatchesArguments([]token.Kind{token.Decimal}))
+	require.Equal(t, true, sig.MatchesArguments([]token.Kind{token.Decimal, token.String}))
+	require.Equal(t, true, sig.MatchesArguments([]token.Kind{token.Decimal, token.String, token.Boolean}))
+	require.Equal(t, false, sig.MatchesArguments([]token.Kind{token.Decimal, token.Boolean}))
+	require.Equal(t, false, sig.MatchesArguments([]token.Kind{token.Decimal, token.String, token.Boolean, token.Boolean}))
+}
+
+func TestSignatureParseTypedCollections(t *testing.T) {
+	sig := NewCommonSignature("filter(Map<String, Boolean>, List<Decimal|String>)List<Decimal>")
+	require.NotNil(t, sig)
+	require.Equal(t, []token.Kind{
+		token.MapOf(token.Boolean),
+		token.ListOf(token.Decimal | token.String),
+	}, sig.Arguments)
+	require.Equal(t, token.ListOf(token.Decimal), sig.Returns)
+
+	require.True(t, sig.MatchesArguments([]token.Kind{token.MapOf(token.Boolean), token.ListOf(token.Decimal)}))
+	require.True(t, sig.MatchesArguments([]token.Kind{token.Map, token.List}))
+	require.False(t, sig.MatchesArguments([]token.Kind{token.MapOf(token.Decimal), token.ListOf(token.Decimal)}))
+	require.False(t, sig.MatchesArguments([]token.Kind{token.MapOf(token.Boolean), token.ListOf(token.Decimal | token.Boolean)}))
+
+	// the keys of a map are always strings
+	require.Equal(t, token.Kind(0), NewCommonSignature("f(Map<Decimal, Boolean>)").Arguments[0])
+}
+
+func TestSignatureParseOptionalArguments(t *testing.T) {
+	sig := MustNewCommonSignature("fn(a Decimal?, b String, c Decimal = 1, d Boolean?)Decimal?")
+	require.Equal(t, []token.Kind{
+		token.Optional(token.Decimal),
+		token.String,
+		token.Decimal,
+		token.Optional(token.Boolean),
+	}, sig.Arguments)
+	require.Equal(t, token.Optional(token.Decimal), sig.Returns)
+	// only trailing optional arguments can be omitted
+	require.Nil(t, sig.ArgumentDefault(0))
+	require.Equal(t, true, sig.ArgumentDefault(3).IsNull())
+	require.Equal(t, 2, sig.RequiredArgumentCount())
+	require.Equal(t, "fn(a Decimal?, b String, c Decimal = 1, d Boolean?)Decimal?", sig.String())
+
+	require.Equal(t, true, sig.MatchesArguments([]token.Kind{token.Null, token.String}))
+	require.Equal(t, true, sig.MatchesArguments([]token.Kind{token.Decimal, token.String, token.Decimal, token.Null}))
+	require.Equal(t, false, sig.MatchesArguments([]token.Kind{token.Decimal, token.Null}))
+	require.Equal(t, false, sig.MatchesArguments([]token.Kind{token.Decimal}))
+
+	// required arguments can not follow default values
+	require.Nil(t, NewCommonSignature("fn(a Decimal = 1, b Decimal?, c Decimal)"))
+}
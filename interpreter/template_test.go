@@ -124,3 +124,107 @@ func TestSetTemplate(t *testing.T) {
 		},
 	)
 }
+
+func TestTemplateNamedArguments(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustLexAndEvaluate(`(setTemplate "discount(price Decimal, percent Decimal = 10, minimum Decimal = 0)Decimal" (+ (# minimum) (/ (* (# price) (# percent)) 100)))`)
+
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			"(! discount 200)",
+			nil,
+			token.NewDecimalFromInt(20),
+		},
+		helpers.Test{
+			"(! discount 200 50)",
+			nil,
+			token.NewDecimalFromInt(100),
+		},
+		helpers.Test{
+			"(! discount 200 50 5)",
+			nil,
+			token.NewDecimalFromInt(105),
+		},
+		// positional placeholders still work for named arguments
+		helpers.Test{
+			"(! discount (+ 100 100) (- 20 10))",
+			nil,
+			token.NewDecimalFromInt(20),
+		},
+		helpers.Test{
+			"(! discount)",
+			nil,
+			helpers.Error{},
+		},
+		helpers.Test{
+			"(! discount 200 50 5 1)",
+			nil,
+			helpers.Error{},
+		},
+	)
+
+	interp.MustLexAndEvaluate(`(setTemplate "twice(a Decimal)Decimal" (+ (# 0) (# a)))`)
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			"(! twice 2)",
+			nil,
+			token.NewDecimalFromInt(4),
+		},
+		// the template must not be changed by a previous run
+		helpers.Test{
+			"(! twice 3)",
+			nil,
+			token.NewDecimalFromInt(6),
+		},
+	)
+}
+
+func TestTemplateTypeCheck(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustLexAndEvaluate(`(setTemplate "double(Decimal)Decimal" (* (# 0) 2))`)
+
+	valid := []string{
+		`(setTemplate "greet(name String)String" (+ "Hello " (# name)))`,
+		`(setTemplate "quadruple(Decimal)Decimal" (! double (! double (# 0))))`,
+		`(setTemplate "age(String)Decimal" (. Profile Age))`,
+		`(setTemplate "positive(Decimal)Boolean" (> (# 0) 0))`,
+		`(setTemplate "sum(Decimal...)Decimal" (+ (# 0) (# 1) (# 2)))`,
+		// recursive templates reference themselves
+		`(setTemplate "countdown(Decimal)Decimal" (catch 0 (! countdown (- (# 0) 1))))`,
+	}
+	for _, input := range valid {
+		require.NoError(t, getError(interp.LexAndEvaluate(input)), input)
+	}
+
+	invalid := []string{
+		// return kind does not match
+		`(setTemplate "wrongReturn(Decimal)String" (* (# 0) 2))`,
+		// argument kind does not match the function
+		`(setTemplate "wrongArgument(String)Decimal" (* (# 0) 2))`,
+		// unknown function
+		`(setTemplate "unknownFunction(Decimal)Decimal" (unknown (# 0)))`,
+		// unknown template
+		`(setTemplate "unknownTemplate(Decimal)Decimal" (! unknown (# 0)))`,
+		// template called with a wrong kind
+		`(setTemplate "wrongTemplateArgument(String)Decimal" (! double (# 0)))`,
+		// unknown placeholder
+		`(setTemplate "unknownPlaceholder(Decimal)Decimal" (* (# 1) 2))`,
+		`(setTemplate "unknownNamedPlaceholder(a Decimal)Decimal" (* (# b) 2))`,
+	}
+	for _, input := range invalid {
+		err := getError(interp.LexAndEvaluate(input))
+		require.Error(t, err, input)
+		require.Contains(t, err.Error(), "Error in template", input)
+	}
+	require.Len(t, interp.Templates, len(valid)+1)
+
+	// RegisterTemplate is checked as well
+	err := interp.RegisterTemplate(interpreter.TaTemplate{
+		CommonSignature: interpreter.CommonSignature{
+			Name:    "invalid",
+			Returns: token.Boolean,
+		},
+		Template: *lexer.MustLex(`(+ 1 2)`),
+	})
+	require.IsType(t, interpreter.TemplateError{}, err)
+}
@@ -0,0 +1,201 @@
+package interpreter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/talon-one/talang/token"
+)
+
+// templateChecker infers the kinds a template body can produce without running it
+type templateChecker struct {
+	interp   *Interpreter
+	template *TaTemplate
+	// templates that are not registered yet but can be called by the template (e.g. from the same library)
+	pending []TaTemplate
+}
+
+// checkTemplate verifies that the body of the template only references known functions and templates,
+// that all calls match a signature when the placeholders have the declared argument kinds,
+// and that the body can produce the declared return kind
+func (interp *Interpreter) checkTemplate(tmpl *TaTemplate, pending ...TaTemplate) error {
+	var body token.TaToken
+	token.Copy(&body, &tmpl.Template)
+	if err := interp.Expand(&body); err != nil {
+		return TemplateError{error: err, template: tmpl}
+	}
+
+	checker := templateChecker{
+		interp:   interp,
+		template: tmpl,
+		pending:  pending,
+	}
+
+	kind, err := checker.infer(&body)
+	if err != nil {
+		return TemplateError{error: err, template: tmpl}
+	}
+	if kind&valueKind(tmpl.Returns) == 0 {
+		return TemplateError{error: errors.Errorf("Body returns `%s', expected `%s'", kind.String(), tmpl.Returns.String()), template: tmpl}
+	}
+	return nil
+}
+
+// valueKind returns the kinds a value of kind k can have after evaluation,
+// tokens get evaluated so they can result in any value
+func valueKind(k token.Kind) token.Kind {
+	if k&token.Token != 0 {
+		return k&^token.Token | token.Atom | token.Collection
+	}
+	return k
+}
+
+func (c *templateChecker) infer(b *token.TaToken) (token.Kind, error) {
+	if !b.IsBlock() {
+		return b.Kind, nil
+	}
+	if b.IsEmpty() {
+		return 0, errors.New("Empty term")
+	}
+
+	if len(b.String) <= 0 {
+		// a list of terms results in the last term
+		var kind token.Kind
+		for _, child := range b.Children {
+			var err error
+			if kind, err = c.infer(child); err != nil {
+				return 0, err
+			}
+		}
+		return kind, nil
+	}
+
+	if b.String == "#" && len(b.Children) == 1 {
+		return c.placeholder(b.Children[0].String)
+	}
+
+	if strings.EqualFold(b.String, templateSignature.Name) && len(b.Children) > 0 && !b.Children[0].IsBlock() {
+		name := b.Children[0].String
+		signatures := c.templates(name)
+		if len(signatures) <= 0 {
+			return 0, errors.Errorf("Unknown template `%s'", name)
+		}
+		return c.call(name, b.Children[1:], signatures)
+	}
+
+	signatures := c.functions(b.String)
+	if len(signatures) <= 0 {
+		return 0, errors.Errorf("Unknown function `%s'", b.String)
+	}
+	return c.call(b.String, b.Children, signatures)
+}
+
+func (c *templateChecker) placeholder(name string) (token.Kind, error) {
+	index := -1
+	for i := 0; i < len(c.template.Arguments); i++ {
+		if strings.EqualFold(c.template.ArgumentName(i), name) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 {
+			index = i
+		}
+	}
+
+	argc := len(c.template.Arguments)
+	if index >= argc && c.template.IsVariadic && argc > 0 {
+		index = argc - 1
+	}
+	if index < 0 || index >= argc {
+		return 0, errors.Errorf("Unknown placeholder `%s'", name)
+	}
+	return valueKind(c.template.Arguments[index]), nil
+}
+
+func (c *templateChecker) functions(name string) (signatures []*CommonSignature) {
+	lowerName := strings.ToLower(name)
+	walker := funcWalker{interp: c.interp}
+	for fn := walker.Next(); fn != nil; fn = walker.Next() {
+		if fn.lowerName == lowerName {
+			signatures = append(signatures, &fn.CommonSignature)
+		}
+	}
+	return signatures
+}
+
+func (c *templateChecker) templates(name string) (signatures []*CommonSignature) {
+	lowerName := strings.ToLower(name)
+	templates := append(c.interp.AllTemplates(), c.pending...)
+	templates = append(templates, *c.template)
+	for i := range templates {
+		if templates[i].hasName(lowerName) {
+			signatures = append(signatures, &templates[i].CommonSignature)
+		}
+	}
+	return signatures
+}
+
+// call returns the combined return kinds of all signatures that can be called with the arguments
+func (c *templateChecker) call(name string, args []*token.TaToken, signatures []*CommonSignature) (token.Kind, error) {
+	kinds := make([]token.Kind, len(args))
+	for i, arg := range args {
+		if !arg.IsBlock() {
+			kinds[i] = arg.Kind
+		}
+	}
+
+	var result token.Kind
+	matched := false
+nextsignature:
+	for _, sig := range signatures {
+		argc := len(sig.Arguments)
+		if !sig.IsVariadic {
+			if argc < len(args) || sig.RequiredArgumentCount() > len(args) {
+				continue
+			}
+		} else if argc-1 > len(args) {
+			continue
+		}
+
+		for i, j := 0, 0; i < len(args); i++ {
+			if !args[i].IsBlock() || sig.Arguments[j]&token.Token == 0 {
+				if kinds[i] == 0 {
+					var err error
+					if kinds[i], err = c.infer(args[i]); err != nil {
+						return 0, err
+					}
+				}
+				if !sig.Arguments[j].Accepts(kinds[i]) {
+					continue nextsignature
+				}
+			}
+			j++
+			if j >= argc {
+				j = argc - 1
+			}
+		}
+		matched = true
+		result |= valueKind(sig.Returns)
+	}
+
+	if !matched {
+		for i := range args {
+			if kinds[i] == 0 {
+				kinds[i], _ = c.infer(args[i])
+			}
+		}
+		return 0, errors.Errorf("No signature of `%s' matches %s", name, kindList(kinds))
+	}
+	return result, nil
+}
+
+func kindList(kinds []token.Kind) string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = kind.String()
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
@@ -0,0 +1,496 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/talon-one/talang/lexer"
+	"github.com/talon-one/talang/token"
+)
+
+type TemplateLibraryFormat int
+
+const (
+	// TemplateLibrarySource is a list of talang `setTemplate' forms,
+	// comments (starting with ;) right above a form are used as the description of the template,
+	// comments starting with ;; as its example
+	TemplateLibrarySource TemplateLibraryFormat = iota
+	// TemplateLibraryJSON is the json representation of a TemplateLibrary
+	TemplateLibraryJSON
+)
+
+// TemplateLibrary is a collection of templates sharing a namespace and a version.
+// Templates of a library with a namespace are called using the qualified name (! namespace.name)
+// or, to select one of several loaded versions, the versioned name (! namespace@version.name).
+// The qualified name calls the version that was loaded first.
+type TemplateLibrary struct {
+	Namespace string
+	Version   string
+	Templates []TaTemplate
+}
+
+type templateJSON struct {
+	Signature   string
+	Template    string
+	Description string `json:",omitempty"`
+	Example     string `json:",omitempty"`
+}
+
+type templateLibraryJSON struct {
+	Namespace string `json:",omitempty"`
+	Version   string `json:",omitempty"`
+	Templates []templateJSON
+}
+
+var coreTemplateLibraries []TemplateLibrary
+
+// RegisterCoreTemplateLibrary registers a library that will be loaded by every new interpreter
+func RegisterCoreTemplateLibrary(libraries ...TemplateLibrary) error {
+	for i := 0; i < len(libraries); i++ {
+		for j := 0; j < len(coreTemplateLibraries); j++ {
+			if coreTemplateLibraries[j].is(libraries[i].Namespace, libraries[i].Version) {
+				return errors.Errorf("Template library `%s' (%s) is already registered", libraries[i].Namespace, libraries[i].Version)
+			}
+		}
+		coreTemplateLibraries = append(coreTemplateLibraries, libraries[i])
+	}
+	return nil
+}
+
+// LoadTemplateLibrary registers all templates of the library, it fails without registering any template if
+// the library with the same namespace and version is already loaded or if any template conflicts with a registered template,
+// templates conflict if a call could match both of them.
+// Other versions of the library can be loaded at the same time, calls to templates of the same namespace
+// in the templates of the library are changed to call the templates of its own version.
+func (interp *Interpreter) LoadTemplateLibrary(library TemplateLibrary) error {
+	if library.Namespace != "" {
+		if loaded := interp.TemplateLibrary(library.Namespace, library.Version); loaded != nil {
+			return errors.Errorf("Template library `%s' (%s) is already loaded", loaded.Namespace, loaded.Version)
+		}
+	}
+
+	templates := make([]TaTemplate, len(library.Templates))
+	var conflicts []TaTemplate
+	for i := 0; i < len(library.Templates); i++ {
+		templates[i] = library.Templates[i]
+		templates[i].Namespace = library.Namespace
+		templates[i].Version = library.Version
+		templates[i].sanitize()
+		if library.Namespace != "" && library.Version != "" {
+			// the body is shared with the library, so change a copy
+			token.Copy(&templates[i].Template, &library.Templates[i].Template)
+			pinTemplateCalls(&templates[i].Template, library.Namespace, library.Version)
+		}
+
+		if interp.overlapsTemplate(&templates[i], templates[:i]) {
+			conflicts = append(conflicts, templates[i])
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return TemplateConflictError{Namespace: library.Namespace, Templates: conflicts}
+	}
+
+	// templates of a library can call each other
+	for i := 0; i < len(templates); i++ {
+		if err := interp.checkTemplate(&templates[i], templates...); err != nil {
+			return err
+		}
+	}
+
+	interp.Templates = append(interp.Templates, templates...)
+	return nil
+}
+
+// overlapsTemplate returns true if a call of the template could also call a registered template or one of the others,
+// e.g. fn(a Decimal) and fn(a Decimal, b Decimal = 0) conflict because (fn 1) would call either of them
+func (interp *Interpreter) overlapsTemplate(template *TaTemplate, others []TaTemplate) bool {
+	for i := 0; i < len(interp.Templates); i++ {
+		if interp.Templates[i].overlaps(template) {
+			return true
+		}
+	}
+	for i := 0; i < len(others); i++ {
+		if others[i].overlaps(template) {
+			return true
+		}
+	}
+	return false
+}
+
+func (interp *Interpreter) MustLoadTemplateLibrary(library TemplateLibrary) {
+	if err := interp.LoadTemplateLibrary(library); err != nil {
+		panic(err)
+	}
+}
+
+// LoadTemplateLibraryFile reads a library from a file (see ReadTemplateLibraryFile) and loads it
+func (interp *Interpreter) LoadTemplateLibraryFile(path string) error {
+	library, err := ReadTemplateLibraryFile(path)
+	if err != nil {
+		return err
+	}
+	return interp.LoadTemplateLibrary(*library)
+}
+
+// LoadTemplateLibraryDir loads every `.talang' and `.json' file in the directory as a library
+func (interp *Interpreter) LoadTemplateLibraryDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if _, ok := templateLibraryFormatFromPath(file.Name()); !ok {
+			continue
+		}
+		if err := interp.LoadTemplateLibraryFile(filepath.Join(dir, file.Name())); err != nil {
+			return errors.Wrapf(err, "Unable to load `%s'", file.Name())
+		}
+	}
+	return nil
+}
+
+// UnloadTemplateLibrary removes all templates of the library with the namespace and version
+func (interp *Interpreter) UnloadTemplateLibrary(namespace, version string) error {
+	templates := interp.Templates[:0]
+	found := false
+	for _, tmpl := range interp.Templates {
+		if strings.EqualFold(tmpl.Namespace, namespace) && strings.EqualFold(tmpl.Version, version) {
+			found = true
+			continue
+		}
+		templates = append(templates, tmpl)
+	}
+	interp.Templates = templates
+	if !found {
+		return errors.Errorf("Template library `%s' (%s) is not loaded", namespace, version)
+	}
+	return nil
+}
+
+// TemplateLibrary exports all templates (including the parent scopes) with the namespace and version as a library,
+// use an empty namespace and version to export the templates that do not belong to a namespace.
+// It returns nil if no template with the namespace and version is registered.
+func (interp *Interpreter) TemplateLibrary(namespace, version string) *TemplateLibrary {
+	var library *TemplateLibrary
+	for _, tmpl := range interp.AllTemplates() {
+		if !strings.EqualFold(tmpl.Namespace, namespace) || !strings.EqualFold(tmpl.Version, version) {
+			continue
+		}
+		if library == nil {
+			library = &TemplateLibrary{
+				Namespace: tmpl.Namespace,
+				Version:   tmpl.Version,
+			}
+		}
+		if tmpl.Version != "" {
+			// calls pinned to the version while loading are qualified again, so the library can be loaded with another version
+			var body token.TaToken
+			token.Copy(&body, &tmpl.Template)
+			unpinTemplateCalls(&body, tmpl.Namespace, tmpl.Version)
+			tmpl.Template = body
+		}
+		tmpl.Namespace = ""
+		tmpl.Version = ""
+		tmpl.sanitize()
+		library.Templates = append(library.Templates, tmpl)
+	}
+	return library
+}
+
+// TemplateLibraries exports all templates grouped by their namespace and version
+func (interp *Interpreter) TemplateLibraries() []TemplateLibrary {
+	var libraries []TemplateLibrary
+	for _, tmpl := range interp.AllTemplates() {
+		found := false
+		for i := range libraries {
+			if libraries[i].is(tmpl.Namespace, tmpl.Version) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			libraries = append(libraries, TemplateLibrary{Namespace: tmpl.Namespace, Version: tmpl.Version})
+		}
+	}
+	sort.Slice(libraries, func(i, j int) bool {
+		if libraries[i].Namespace != libraries[j].Namespace {
+			return libraries[i].Namespace < libraries[j].Namespace
+		}
+		return libraries[i].Version < libraries[j].Version
+	})
+
+	for i := range libraries {
+		libraries[i] = *interp.TemplateLibrary(libraries[i].Namespace, libraries[i].Version)
+	}
+	return libraries
+}
+
+func (library *TemplateLibrary) is(namespace, version string) bool {
+	return strings.EqualFold(library.Namespace, namespace) && strings.EqualFold(library.Version, version)
+}
+
+// pinTemplateCalls changes calls like (! namespace.name) in the body to (! namespace@version.name)
+func pinTemplateCalls(body *token.TaToken, namespace, version string) {
+	renameTemplateCalls(body, namespace+".", namespace+"@"+version+".")
+}
+
+// unpinTemplateCalls reverts pinTemplateCalls
+func unpinTemplateCalls(body *token.TaToken, namespace, version string) {
+	renameTemplateCalls(body, namespace+"@"+version+".", namespace+".")
+}
+
+func renameTemplateCalls(body *token.TaToken, prefix, replacement string) {
+	if body.IsBlock() && body.String == templateSignature.Name && len(body.Children) > 0 {
+		name := body.Children[0]
+		if !name.IsBlock() && len(name.String) > len(prefix) && strings.EqualFold(name.String[:len(prefix)], prefix) {
+			name.String = replacement + name.String[len(prefix):]
+		}
+	}
+	for i := 0; i < len(body.Children); i++ {
+		renameTemplateCalls(body.Children[i], prefix, replacement)
+	}
+}
+
+func templateLibraryFormatFromPath(path string) (TemplateLibraryFormat, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return TemplateLibraryJSON, true
+	case ".talang":
+		return TemplateLibrarySource, true
+	}
+	return 0, false
+}
+
+// ReadTemplateLibraryFile reads a library, the format is detected by the file extension (`.talang' or `.json')
+func ReadTemplateLibraryFile(path string) (*TemplateLibrary, error) {
+	format, ok := templateLibraryFormatFromPath(path)
+	if !ok {
+		return nil, errors.Errorf("Unknown template library format `%s'", filepath.Ext(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTemplateLibrary(f, format)
+}
+
+func ReadTemplateLibrary(r io.Reader, format TemplateLibraryFormat) (*TemplateLibrary, error) {
+	switch format {
+	case TemplateLibraryJSON:
+		var library TemplateLibrary
+		if err := json.NewDecoder(r).Decode(&library); err != nil {
+			return nil, err
+		}
+		return &library, nil
+	case TemplateLibrarySource:
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return parseTemplateLibrarySource(string(buf))
+	}
+	return nil, errors.New("Unknown template library format")
+}
+
+func (library *TemplateLibrary) Write(w io.Writer, format TemplateLibraryFormat) error {
+	switch format {
+	case TemplateLibraryJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(library)
+	case TemplateLibrarySource:
+		if library.Namespace != "" || library.Version != "" {
+			if _, err := fmt.Fprintf(w, "(library %q %q)\n", library.Namespace, library.Version); err != nil {
+				return err
+			}
+		}
+		for _, tmpl := range library.Templates {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+			if description := strings.TrimSpace(tmpl.Description); description != "" {
+				for _, line := range strings.Split(description, "\n") {
+					if _, err := fmt.Fprintf(w, "; %s\n", line); err != nil {
+						return err
+					}
+				}
+			}
+			if tmpl.Example != "" {
+				// the example is written unchanged, including empty lines at the start and the end
+				for _, line := range strings.Split(tmpl.Example, "\n") {
+					if _, err := fmt.Fprintf(w, ";; %s\n", line); err != nil {
+						return err
+					}
+				}
+			}
+			if _, err := fmt.Fprintf(w, "(setTemplate %q %s)\n", tmpl.CommonSignature.String(), tmpl.Template.Stringify()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return errors.New("Unknown template library format")
+}
+
+func (library *TemplateLibrary) MarshalJSON() ([]byte, error) {
+	data := templateLibraryJSON{
+		Namespace: library.Namespace,
+		Version:   library.Version,
+		Templates: make([]templateJSON, len(library.Templates)),
+	}
+	for i, tmpl := range library.Templates {
+		data.Templates[i] = templateJSON{
+			Signature:   tmpl.CommonSignature.String(),
+			Template:    tmpl.Template.Stringify(),
+			Description: tmpl.Description,
+			Example:     tmpl.Example,
+		}
+	}
+	return json.Marshal(data)
+}
+
+func (library *TemplateLibrary) UnmarshalJSON(b []byte) error {
+	var data templateLibraryJSON
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	library.Namespace = data.Namespace
+	library.Version = data.Version
+	library.Templates = make([]TaTemplate, len(data.Templates))
+	for i, tmpl := range data.Templates {
+		sig := NewCommonSignature(tmpl.Signature)
+		if sig == nil {
+			return errors.Errorf("Invalid signature `%s'", tmpl.Signature)
+		}
+		sig.Description = tmpl.Description
+		sig.Example = tmpl.Example
+		body, err := lexer.Lex(tmpl.Template)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid template `%s'", tmpl.Signature)
+		}
+		library.Templates[i] = TaTemplate{
+			CommonSignature: *sig,
+			Template:        *body,
+		}
+	}
+	return nil
+}
+
+// parseTemplateLibrarySource parses a list of top level forms
+func parseTemplateLibrarySource(source string) (*TemplateLibrary, error) {
+	var library TemplateLibrary
+	var comments, example []string
+
+	for pos := 0; pos < len(source); {
+		switch r := source[pos]; {
+		case r == ';':
+			end := strings.IndexByte(source[pos:], '\n')
+			if end < 0 {
+				end = len(source) - pos
+			}
+			if line := source[pos : pos+end]; strings.HasPrefix(line, ";;") {
+				example = append(example, strings.TrimPrefix(strings.TrimSuffix(line[2:], "\r"), " "))
+			} else {
+				comments = append(comments, strings.TrimSpace(strings.TrimLeft(line, ";")))
+			}
+			pos += end
+		case r == '(':
+			end := formEnd(source[pos:])
+			if end < 0 {
+				return nil, errors.Errorf("Unterminated form at offset %d", pos)
+			}
+			form, err := lexer.Lex(source[pos : pos+end])
+			if err != nil {
+				return nil, err
+			}
+			if err := library.addForm(form, strings.Join(comments, "\n"), strings.Join(example, "\n")); err != nil {
+				return nil, err
+			}
+			comments = nil
+			example = nil
+			pos += end
+		case r == '\n':
+			// an empty line separates comments from the following form
+			if pos+1 < len(source) && source[pos+1] == '\n' {
+				comments = nil
+				example = nil
+			}
+			pos++
+		case r == ' ' || r == '\t' || r == '\r':
+			pos++
+		default:
+			return nil, errors.Errorf("Unexpected `%c' at offset %d", r, pos)
+		}
+	}
+	return &library, nil
+}
+
+// formEnd returns the position after the closing bracket of the form that starts at the beginning of s
+func formEnd(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+func (library *TemplateLibrary) addForm(form *token.TaToken, description, example string) error {
+	switch strings.ToLower(form.String) {
+	case "library":
+		if len(form.Children) < 1 || len(form.Children) > 2 {
+			return errors.New("Invalid library form, expected (library namespace version)")
+		}
+		library.Namespace = form.Children[0].String
+		if len(form.Children) > 1 {
+			library.Version = form.Children[1].String
+		}
+		return nil
+	case strings.ToLower(setTemplateSignature.Name):
+		if len(form.Children) != 2 {
+			return errors.New("Invalid setTemplate form, expected (setTemplate signature template)")
+		}
+		sig := NewCommonSignature(form.Children[0].String)
+		if sig == nil {
+			return errors.Errorf("Invalid signature `%s'", form.Children[0].String)
+		}
+		sig.Description = description
+		sig.Example = example
+		library.Templates = append(library.Templates, TaTemplate{
+			CommonSignature: *sig,
+			Template:        *form.Children[1],
+		})
+		return nil
+	}
+	return errors.Errorf("Unexpected form `%s'", form.Stringify())
+}
@@ -0,0 +1,286 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/talon-one/talang/interpreter"
+	"github.com/talon-one/talang/lexer"
+	helpers "github.com/talon-one/talang/testhelpers"
+	"github.com/talon-one/talang/token"
+)
+
+const discountLibrary = `(library "acme" "1.2.0")
+
+; doubles the value
+(setTemplate "double(Decimal)Decimal" (* (# 0) 2))
+
+; adds a fixed bonus
+; the bonus defaults to 10
+;; (! acme.bonus 2)                                               ; returns 12
+;;   (! acme.bonus 2 3)                                           ; returns 5
+(setTemplate "bonus(value Decimal, amount Decimal = 10)Decimal" (+ (# value) (# amount)))
+`
+
+func TestReadTemplateLibrarySource(t *testing.T) {
+	library, err := interpreter.ReadTemplateLibrary(strings.NewReader(discountLibrary), interpreter.TemplateLibrarySource)
+	require.NoError(t, err)
+	require.Equal(t, "acme", library.Namespace)
+	require.Equal(t, "1.2.0", library.Version)
+	require.Len(t, library.Templates, 2)
+	require.Equal(t, "double(Decimal)Decimal", library.Templates[0].String())
+	require.Equal(t, "doubles the value", library.Templates[0].Description)
+	require.Equal(t, "adds a fixed bonus\nthe bonus defaults to 10", library.Templates[1].Description)
+	require.Equal(t, "", library.Templates[0].Example)
+	require.Equal(t, "(! acme.bonus 2)                                               ; returns 12\n  (! acme.bonus 2 3)                                           ; returns 5", library.Templates[1].Example)
+
+	interp := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, interp.LoadTemplateLibrary(*library))
+	require.Equal(t, "4", interp.MustLexAndEvaluate("(! acme.double 2)").String)
+	require.Equal(t, "12", interp.MustLexAndEvaluate("(! acme.bonus 2)").String)
+	require.Equal(t, "5", interp.MustLexAndEvaluate("(! acme.bonus 2 3)").String)
+	// templates of a namespace are not available without the namespace
+	require.Error(t, getError(interp.LexAndEvaluate("(! double 2)")))
+}
+
+func TestReadTemplateLibraryInvalid(t *testing.T) {
+	for _, source := range []string{
+		`(setTemplate "double(Decimal)Decimal" (* (# 0) 2)`,
+		`(setTemplate "double(Decimal" (* (# 0) 2))`,
+		`(+ 1 2)`,
+		`foo`,
+	} {
+		_, err := interpreter.ReadTemplateLibrary(strings.NewReader(source), interpreter.TemplateLibrarySource)
+		require.Error(t, err, source)
+	}
+}
+
+func TestTemplateLibraryRoundTrip(t *testing.T) {
+	library, err := interpreter.ReadTemplateLibrary(strings.NewReader(discountLibrary), interpreter.TemplateLibrarySource)
+	require.NoError(t, err)
+	// examples are usually surrounded by new lines, they have to survive the round trip
+	library.Templates[0].Example = "\n(! acme.double 2)                                               ; returns 4\n"
+
+	for _, format := range []interpreter.TemplateLibraryFormat{interpreter.TemplateLibrarySource, interpreter.TemplateLibraryJSON} {
+		var buf bytes.Buffer
+		require.NoError(t, library.Write(&buf, format))
+
+		read, err := interpreter.ReadTemplateLibrary(&buf, format)
+		require.NoError(t, err)
+		require.Equal(t, library.Namespace, read.Namespace)
+		require.Equal(t, library.Version, read.Version)
+		require.Len(t, read.Templates, len(library.Templates))
+		for i := range library.Templates {
+			require.Equal(t, library.Templates[i].String(), read.Templates[i].String())
+			require.Equal(t, library.Templates[i].Description, read.Templates[i].Description)
+			require.Equal(t, library.Templates[i].Example, read.Templates[i].Example)
+			require.Equal(t, library.Templates[i].Template.Stringify(), read.Templates[i].Template.Stringify())
+		}
+	}
+}
+
+func TestExportTemplateLibrary(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	interp.MustLexAndEvaluate(`(setTemplate "triple(Decimal)Decimal" (* (# 0) 3))`)
+	interp.MustLoadTemplateLibrary(interpreter.TemplateLibrary{
+		Namespace: "acme",
+		Version:   "1.0.0",
+		Templates: []interpreter.TaTemplate{
+			{
+				CommonSignature: *interpreter.NewCommonSignature("double(Decimal)Decimal"),
+				Template:        *lexer.MustLex("(* (# 0) 2)"),
+			},
+		},
+	})
+
+	libraries := interp.TemplateLibraries()
+	require.Len(t, libraries, 2)
+	require.Equal(t, "", libraries[0].Namespace)
+	require.Equal(t, "triple(Decimal)Decimal", libraries[0].Templates[0].String())
+	require.Equal(t, "acme", libraries[1].Namespace)
+	require.Equal(t, "1.0.0", libraries[1].Version)
+	require.Equal(t, "double(Decimal)Decimal", libraries[1].Templates[0].String())
+
+	require.Nil(t, interp.TemplateLibrary("unknown", ""))
+	require.Nil(t, interp.TemplateLibrary("acme", "2.0.0"))
+
+	// an exported library can be loaded into another interpreter
+	other := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, other.LoadTemplateLibrary(libraries[1]))
+	require.Equal(t, "4", other.MustLexAndEvaluate("(! acme.double 2)").String)
+}
+
+func TestTemplateLibraryConflicts(t *testing.T) {
+	interp := helpers.MustNewInterpreterWithLogger()
+	library, err := interpreter.ReadTemplateLibrary(strings.NewReader(discountLibrary), interpreter.TemplateLibrarySource)
+	require.NoError(t, err)
+	require.NoError(t, interp.LoadTemplateLibrary(*library))
+
+	// the same version can not be loaded twice
+	require.Error(t, interp.LoadTemplateLibrary(*library))
+
+	// another version in a different namespace can coexist
+	library.Namespace = "acme2"
+	library.Version = "2.0.0"
+	require.NoError(t, interp.LoadTemplateLibrary(*library))
+	require.Equal(t, "4", interp.MustLexAndEvaluate("(! acme2.double 2)").String)
+
+	// conflicting templates are reported and nothing gets registered
+	interp.MustLexAndEvaluate(`(setTemplate "double(Decimal)Decimal" (* (# 0) 2))`)
+	err = interp.LoadTemplateLibrary(interpreter.TemplateLibrary{
+		Templates: []interpreter.TaTemplate{
+			{
+				CommonSignature: *interpreter.NewCommonSignature("quadruple(Decimal)Decimal"),
+				Template:        *lexer.MustLex("(* (# 0) 4)"),
+			},
+			{
+				CommonSignature: *interpreter.NewCommonSignature("double(Decimal)Decimal"),
+				Template:        *lexer.MustLex("(+ (# 0) (# 0))"),
+			},
+		},
+	})
+	require.IsType(t, interpreter.TemplateConflictError{}, err)
+	require.Len(t, err.(interpreter.TemplateConflictError).Templates, 1)
+	require.Error(t, getError(interp.LexAndEvaluate("(! quadruple 2)")))
+
+	// templates conflict if a call could match both of them, even if the signatures are not the same
+	for _, signatures := range [][]string{
+		{"fn(a Decimal)Decimal", "fn(a Decimal, b Decimal = 0)Decimal"},
+		{"fn(a Decimal, b Decimal?)Decimal", "fn(a Decimal)Decimal"},
+		{"fn(a Decimal)Decimal", "fn(a Atom)Decimal"},
+		{"fn(Decimal...)Decimal", "fn(a Decimal, b Decimal)Decimal"},
+		// double(Decimal)Decimal is registered
+		{"double(a Decimal, b Decimal = 2)Decimal"},
+	} {
+		library := interpreter.TemplateLibrary{}
+		for _, signature := range signatures {
+			library.Templates = append(library.Templates, interpreter.TaTemplate{
+				CommonSignature: *interpreter.NewCommonSignature(signature),
+				Template:        *lexer.MustLex("(# 0)"),
+			})
+		}
+		err := interp.LoadTemplateLibrary(library)
+		require.IsType(t, interpreter.TemplateConflictError{}, err, "%v", signatures)
+	}
+	// different kinds or argument counts do not conflict
+	require.NoError(t, interp.LoadTemplateLibrary(interpreter.TemplateLibrary{
+		Templates: []interpreter.TaTemplate{
+			{
+				CommonSignature: *interpreter.NewCommonSignature("fn(a Decimal)Decimal"),
+				Template:        *lexer.MustLex("(# 0)"),
+			},
+			{
+				CommonSignature: *interpreter.NewCommonSignature("fn(a String, b Decimal = 0)Decimal"),
+				Template:        *lexer.MustLex("(# b)"),
+			},
+			{
+				CommonSignature: *interpreter.NewCommonSignature("fn(a Decimal, b Decimal)Decimal"),
+				Template:        *lexer.MustLex("(# b)"),
+			},
+		},
+	}))
+	require.Equal(t, "1", interp.MustLexAndEvaluate("(! fn 1)").String)
+	require.Equal(t, "2", interp.MustLexAndEvaluate("(! fn 1 2)").String)
+
+	require.Error(t, interp.UnloadTemplateLibrary("acme", "2.0.0"))
+	require.NoError(t, interp.UnloadTemplateLibrary("acme", "1.2.0"))
+	require.Error(t, getError(interp.LexAndEvaluate("(! acme.double 2)")))
+	require.Equal(t, "4", interp.MustLexAndEvaluate("(! acme2.double 2)").String)
+	require.Error(t, interp.UnloadTemplateLibrary("acme", "1.2.0"))
+}
+
+func TestTemplateLibraryVersions(t *testing.T) {
+	version := func(version, factor string) interpreter.TemplateLibrary {
+		return interpreter.TemplateLibrary{
+			Namespace: "acme",
+			Version:   version,
+			Templates: []interpreter.TaTemplate{
+				{
+					CommonSignature: *interpreter.NewCommonSignature("double(Decimal)Decimal"),
+					Template:        *lexer.MustLex("(* (# 0) " + factor + ")"),
+				},
+				{
+					CommonSignature: *interpreter.NewCommonSignature("quadruple(Decimal)Decimal"),
+					Template:        *lexer.MustLex("(! acme.double (! acme.double (# 0)))"),
+				},
+			},
+		}
+	}
+
+	interp := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, interp.LoadTemplateLibrary(version("1.0.0", "2")))
+	// a broken double in the second version shows which version is called
+	require.NoError(t, interp.LoadTemplateLibrary(version("2.0.0", "3")))
+
+	helpers.RunTestsWithInterpreter(t, interp,
+		helpers.Test{
+			"(! acme.double 2)",
+			nil,
+			token.NewDecimalFromInt(4),
+		},
+		helpers.Test{
+			"(! acme@1.0.0.double 2)",
+			nil,
+			token.NewDecimalFromInt(4),
+		},
+		helpers.Test{
+			"(! acme@2.0.0.double 2)",
+			nil,
+			token.NewDecimalFromInt(6),
+		},
+		// templates of a version call the templates of their own version
+		helpers.Test{
+			"(! acme@2.0.0.quadruple 2)",
+			nil,
+			token.NewDecimalFromInt(18),
+		},
+		helpers.Test{
+			"(! acme.quadruple 2)",
+			nil,
+			token.NewDecimalFromInt(8),
+		},
+		helpers.Test{
+			"(! acme@3.0.0.double 2)",
+			nil,
+			helpers.Error{},
+		},
+	)
+
+	libraries := interp.TemplateLibraries()
+	require.Len(t, libraries, 2)
+	require.Equal(t, "1.0.0", libraries[0].Version)
+	require.Equal(t, "2.0.0", libraries[1].Version)
+	// the exported templates call the templates of the namespace without the version
+	require.Equal(t, lexer.MustLex("(! acme.double (! acme.double (# 0)))").Stringify(), libraries[1].Templates[1].Template.Stringify())
+
+	require.NoError(t, interp.UnloadTemplateLibrary("acme", "1.0.0"))
+	require.Equal(t, "6", interp.MustLexAndEvaluate("(! acme.double 2)").String)
+}
+
+func TestLoadTemplateLibraryDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "talang")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	library, err := interpreter.ReadTemplateLibrary(strings.NewReader(discountLibrary), interpreter.TemplateLibrarySource)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "acme.talang"), []byte(discountLibrary), 0644))
+
+	library.Namespace = "globex"
+	f, err := os.Create(filepath.Join(dir, "globex.json"))
+	require.NoError(t, err)
+	require.NoError(t, library.Write(f, interpreter.TemplateLibraryJSON))
+	require.NoError(t, f.Close())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a library"), 0644))
+
+	interp := helpers.MustNewInterpreterWithLogger()
+	require.NoError(t, interp.LoadTemplateLibraryDir(dir))
+	require.Equal(t, "4", interp.MustLexAndEvaluate("(! acme.double 2)").String)
+	require.Equal(t, "12", interp.MustLexAndEvaluate("(! globex.bonus 2)").String)
+}
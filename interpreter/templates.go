@@ -15,6 +15,12 @@ func (interp *Interpreter) RegisterTemplate(signatures ...TaTemplate) error {
 		if interp.GetTemplate(&signature) != nil {
 			return errors.Errorf("Template `%s' is already registered", signature.Name)
 		}
+		if err := interp.checkRecordTypes(&signature.CommonSignature); err != nil {
+			return err
+		}
+		if err := interp.checkTemplate(&signature); err != nil {
+			return err
+		}
 		interp.Templates = append(interp.Templates, signature)
 	}
 	return nil
@@ -29,6 +35,12 @@ func (interp *Interpreter) MustRegisterTemplate(signatures ...TaTemplate) {
 func (interp *Interpreter) UpdateTemplate(signature TaTemplate) error {
 	signature.sanitize()
 	if s := interp.GetTemplate(&signature); s != nil {
+		if err := interp.checkRecordTypes(&signature.CommonSignature); err != nil {
+			return err
+		}
+		if err := interp.checkTemplate(&signature); err != nil {
+			return err
+		}
 		*s = signature
 		return nil
 	}
@@ -58,6 +70,11 @@ func (interp *Interpreter) GetTemplate(signature *TaTemplate) *TaTemplate {
 }
 
 func (interp *Interpreter) registerCoreTemplates() error {
+	for i := 0; i < len(coreTemplateLibraries); i++ {
+		if err := interp.LoadTemplateLibrary(coreTemplateLibraries[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -83,14 +100,26 @@ var templateSignature = TaFunction{
 	},
 	Func: func(interp *Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
 		walker := newtemplateToRunWalker(interp, token.NewToken(args[0].String, args[1:]...), 0)
+		evaluated := make([]*token.TaToken, len(args)-1)
 		for tmpl := walker.Next(); tmpl != nil; tmpl = walker.Next() {
+			templateArgs, err := interp.evaluateRecordArguments(&tmpl.CommonSignature, args[1:], evaluated)
+			if err == nil {
+				err = interp.matchRecords(&tmpl.CommonSignature, templateArgs)
+			}
+			if err != nil {
+				if interp.Logger != nil {
+					interp.Logger.Printf("Not running template `%s': %s\n", tmpl.CommonSignature.String(), err)
+				}
+				continue
+			}
 			if interp.Logger != nil {
-				interp.Logger.Printf("Running template `%s' with `%v'\n", tmpl.CommonSignature.String(), token.TokenArguments(args[1:]).ToHumanReadable())
+				interp.Logger.Printf("Running template `%s' with `%v'\n", tmpl.CommonSignature.String(), token.TokenArguments(templateArgs).ToHumanReadable())
 			}
 
-			b := tmpl.Template
-			if len(args) > 1 {
-				if _, err := replaceVariables(&b, args[1:]...); err != nil {
+			var b token.TaToken
+			token.Copy(&b, &tmpl.Template)
+			if templateArgs := tmpl.CommonSignature.appendDefaults(templateArgs); len(templateArgs) > 0 {
+				if _, err := replaceVariables(&b, tmpl.ArgumentNames, templateArgs...); err != nil {
 					return nil, err
 				}
 			}
@@ -101,7 +130,7 @@ var templateSignature = TaFunction{
 	},
 }
 
-func replaceVariables(b *token.TaToken, args ...*token.TaToken) (int, error) {
+func replaceVariables(b *token.TaToken, names []string, args ...*token.TaToken) (int, error) {
 	total := 0
 
 	var replaced int
@@ -110,6 +139,9 @@ replace:
 	replaced = 0
 	for i := 0; i < len(args); i++ {
 		replaced += replaceVariable(b, strconv.Itoa(i), args[i])
+		if i < len(names) && len(names[i]) > 0 {
+			replaced += replaceVariable(b, names[i], args[i])
+		}
 	}
 
 	total += replaced
@@ -150,6 +182,7 @@ var setTemplateSignature = TaFunction{
 		Description: "Set a template",
 		Example: `
 (setTemplate "plus(Decimal, Decimal)Decimal" (+ (# 0) (# 1)))    ; creates a template with the signature plus(Decimal, Decimal)Decimal
+(setTemplate "plus(a Decimal, b Decimal = 1)Decimal" (+ (# a) (# b))) ; creates a template with named parameters, (! plus 2) returns 3
 `,
 	},
 	Func: func(interp *Interpreter, args ...*token.TaToken) (*token.TaToken, error) {
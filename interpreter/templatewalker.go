@@ -57,7 +57,8 @@ func (f *templateToRunWalker) getChild(index int) []token.Kind {
 		return child
 	}
 
-	walker := newtemplateToRunWalker(f.Interpreter, f.Token.Children[index], f.Level+1)
+	// the child is a function call, so lookup the functions it could resolve to
+	walker := newFuncToRunWalker(f.Interpreter, f.Token.Children[index], f.Level+1)
 
 	var fns []token.Kind
 
@@ -73,13 +74,13 @@ func (f *templateToRunWalker) getChild(index int) []token.Kind {
 func (f *templateToRunWalker) Next() *TaTemplate {
 outerloop:
 	for fn := f.templateWalker.Next(); fn != nil; fn = f.templateWalker.Next() {
-		if fn.lowerName != f.lowerFuncName {
+		if !fn.hasName(f.lowerFuncName) {
 			continue
 		}
 
 		fnArgc := len(fn.Arguments)
 		if !fn.IsVariadic {
-			if fnArgc != f.argumentCount {
+			if fnArgc < f.argumentCount || fn.RequiredArgumentCount() > f.argumentCount {
 				continue
 			}
 		} else {
@@ -90,7 +91,7 @@ outerloop:
 
 		for i, j := 0, 0; i < f.argumentCount; i++ {
 			child := f.Token.Children[i]
-			childKinds := []token.Kind{child.Kind}
+			childKinds := []token.Kind{token.KindFor(child, fn.Arguments[j])}
 			// if the child is a block
 			if child.IsBlock() {
 				// but the fn does not accept a block
@@ -102,7 +103,7 @@ outerloop:
 
 			gotMatchingFunc := false
 			for k := 0; k < len(childKinds); k++ {
-				if fn.Arguments[j]&childKinds[k] != 0 {
+				if fn.Arguments[j].Accepts(childKinds[k]) {
 					gotMatchingFunc = true
 				}
 			}
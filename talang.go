@@ -57,3 +57,15 @@ func (interp *Interpreter) Evaluate(b *token.TaToken) error {
 func (interp *Interpreter) MustEvaluate(b *token.TaToken) {
 	interp.Interpreter.MustEvaluate(b)
 }
+
+func (interp *Interpreter) LexAndExpand(str string) (*token.TaToken, error) {
+	return interp.Interpreter.LexAndExpand(str)
+}
+
+func (interp *Interpreter) MustLexAndExpand(str string) *token.TaToken {
+	return interp.Interpreter.MustLexAndExpand(str)
+}
+
+func (interp *Interpreter) Expand(b *token.TaToken) error {
+	return interp.Interpreter.Expand(b)
+}
@@ -0,0 +1,109 @@
+package token
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/talon-one/decimal"
+)
+
+// kindOrder is the order of values with different kinds
+var kindOrder = []Kind{Null, Boolean, Decimal, Money, Duration, Time, String, List, Map, Token}
+
+func kindRank(k Kind) int {
+	for i, kind := range kindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+// Compare returns -1 if a is less than b, 0 if they are equal and 1 if a is greater than b.
+// Values of different kinds are ordered by their kind (Null, Boolean, Decimal, Money, Duration, Time, String, List, Map, Token),
+// values of the same kind are compared by their value: decimals by value, times by instant (dates before times at the same instant),
+// money by currency and amount, lists lexicographically and maps by their sorted keys and the values of these keys
+func Compare(a, b *TaToken) int {
+	if a.Kind != b.Kind {
+		return compareInts(kindRank(a.Kind), kindRank(b.Kind))
+	}
+	switch a.Kind {
+	case Null:
+		return 0
+	case Boolean:
+		if a.Bool == b.Bool {
+			return 0
+		} else if a.Bool {
+			return 1
+		}
+		return -1
+	case Decimal:
+		return decimal.Cmp(a.Decimal, b.Decimal)
+	case Money:
+		if c := strings.Compare(a.Currency, b.Currency); c != 0 {
+			return c
+		}
+		return decimal.Cmp(a.Decimal, b.Decimal)
+	case Duration:
+		return compareInts(int(a.Duration), int(b.Duration))
+	case Time:
+		if a.Time.Equal(b.Time) {
+			// Equal tells dates and times apart, so a date is less than a time at the same instant
+			if a.DateOnly == b.DateOnly {
+				return 0
+			} else if a.DateOnly {
+				return -1
+			}
+			return 1
+		} else if a.Time.Before(b.Time) {
+			return -1
+		}
+		return 1
+	case String:
+		return strings.Compare(a.String, b.String)
+	case Map:
+		aKeys := sortedKeys(a)
+		bKeys := sortedKeys(b)
+		for i := 0; i < len(aKeys) && i < len(bKeys); i++ {
+			if c := strings.Compare(aKeys[i], bKeys[i]); c != 0 {
+				return c
+			}
+		}
+		if c := compareInts(len(aKeys), len(bKeys)); c != 0 {
+			return c
+		}
+		for _, key := range aKeys {
+			if c := Compare(a.MapItem(key), b.MapItem(key)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	case Token:
+		if c := strings.Compare(a.String, b.String); c != 0 {
+			return c
+		}
+	}
+	// lists and blocks
+	for i := 0; i < len(a.Children) && i < len(b.Children); i++ {
+		if c := Compare(a.Children[i], b.Children[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a.Children), len(b.Children))
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(b *TaToken) []string {
+	keys := make([]string, len(b.Keys))
+	copy(keys, b.Keys)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package token
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/talon-one/decimal"
+)
+
+func TestCompare(t *testing.T) {
+	instant := time.Date(2018, 1, 2, 19, 4, 5, 0, time.UTC)
+	tests := []struct {
+		a, b     *TaToken
+		expected int
+	}{
+		{NewNull(), NewNull(), 0},
+		{NewBool(false), NewBool(true), -1},
+		{NewDecimalFromInt(1), NewDecimalFromString("1.00"), 0},
+		{NewDecimalFromInt(9), NewDecimalFromInt(10), -1},
+		{NewString("9"), NewString("10"), 1},
+		{NewTime(instant), NewTime(instant.In(time.FixedZone("", 3600))), 0},
+		{NewTime(instant), NewTime(instant.Add(time.Second)), -1},
+		// dates and times are not equal, even at the same instant
+		{NewDate(instant.Truncate(24 * time.Hour)), NewTime(instant.Truncate(24 * time.Hour)), -1},
+		{NewDate(instant), NewDate(instant), 0},
+		{NewDuration(time.Hour), NewDuration(time.Minute), 1},
+		{NewMoney(decimal.NewFromInt64(5), "EUR"), NewMoney(decimal.NewFromInt64(5), "eur"), 0},
+		{NewMoney(decimal.NewFromInt64(9), "EUR"), NewMoney(decimal.NewFromInt64(5), "USD"), -1},
+		{NewList(NewDecimalFromInt(1), NewDecimalFromInt(2)), NewList(NewDecimalFromInt(1), NewDecimalFromInt(3)), -1},
+		{NewList(NewDecimalFromInt(1), NewDecimalFromInt(2)), NewList(NewDecimalFromInt(1)), 1},
+		{NewList(), NewList(), 0},
+		// maps are compared by their keys first, the order of the keys does not matter
+		{
+			NewOrderedMap([]string{"A", "B"}, []*TaToken{NewDecimalFromInt(1), NewDecimalFromInt(2)}),
+			NewOrderedMap([]string{"B", "A"}, []*TaToken{NewDecimalFromInt(2), NewDecimalFromInt(1)}),
+			0,
+		},
+		{
+			NewMap(map[string]*TaToken{"A": NewDecimalFromInt(9)}),
+			NewMap(map[string]*TaToken{"B": NewDecimalFromInt(1)}),
+			-1,
+		},
+		{
+			NewMap(map[string]*TaToken{"A": NewDecimalFromInt(1)}),
+			NewMap(map[string]*TaToken{"A": NewDecimalFromInt(1), "B": NewDecimalFromInt(1)}),
+			-1,
+		},
+		{
+			NewMap(map[string]*TaToken{"A": NewDecimalFromInt(2)}),
+			NewMap(map[string]*TaToken{"A": NewDecimalFromInt(1)}),
+			1,
+		},
+		// different kinds are ordered by their kind
+		{NewDecimalFromInt(1), NewString("1"), -1},
+		{NewNull(), NewBool(false), -1},
+		{NewMap(map[string]*TaToken{}), NewList(), 1},
+	}
+	for i, test := range tests {
+		require.Equal(t, test.expected, Compare(test.a, test.b), "Test #%d: %s %s", i, test.a.Stringify(), test.b.Stringify())
+		require.Equal(t, test.expected == 0, test.a.Equal(test.b), "Test #%d (Equal): %s %s", i, test.a.Stringify(), test.b.Stringify())
+		require.Equal(t, -test.expected, Compare(test.b, test.a), "Test #%d (swapped): %s %s", i, test.b.Stringify(), test.a.Stringify())
+	}
+}
+
+func TestCompareIsTotal(t *testing.T) {
+	values := []*TaToken{
+		NewString("b"),
+		NewList(NewDecimalFromInt(2)),
+		NewDecimalFromInt(10),
+		NewNull(),
+		NewBool(true),
+		NewDuration(time.Minute),
+		NewDecimalFromInt(9),
+		NewString("a"),
+		NewList(NewDecimalFromInt(1), NewDecimalFromInt(5)),
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return Compare(values[i], values[j]) < 0
+	})
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = v.Stringify()
+	}
+	require.Equal(t, []string{"", "true", "9", "10", "1m0s", `"a"`, `"b"`, "[1, 5]", "[2]"}, strs)
+}
@@ -0,0 +1,118 @@
+package token
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+func NewDuration(d time.Duration) *TaToken {
+	var b TaToken
+	b.Duration = d
+	b.Kind = Duration
+	b.String = d.String()
+	b.Children = []*TaToken{}
+	return &b
+}
+
+func (b *TaToken) IsDuration() bool {
+	return b.Kind == Duration
+}
+
+// ParseDuration parses a go duration (3h, 1h30m, 500ms) or an ISO 8601 duration (P1DT2H, PT30M, P2W).
+// ISO 8601 years and months are not supported because they do not have a fixed length.
+func ParseDuration(s string) (time.Duration, bool) {
+	if len(s) <= 0 {
+		return 0, false
+	}
+	if d, ok := parseISODuration(s); ok {
+		return d, true
+	}
+	// go accepts "0" as a duration, but that is a decimal for us
+	if !strings.ContainsAny(s, "hmsuµn") {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+func parseISODuration(s string) (time.Duration, bool) {
+	sign := time.Duration(1)
+	if s[0] == '-' || s[0] == '+' {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	if len(s) < 3 || (s[0] != 'P' && s[0] != 'p') {
+		return 0, false
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	hasTime := false
+	components := 0
+	for len(s) > 0 {
+		if s[0] == 'T' || s[0] == 't' {
+			if inTime {
+				return 0, false
+			}
+			inTime = true
+			s = s[1:]
+			continue
+		}
+
+		end := 0
+		for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' || s[end] == ',') {
+			end++
+		}
+		if end == 0 || end >= len(s) {
+			return 0, false
+		}
+		value, err := strconv.ParseFloat(strings.Replace(s[:end], ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+
+		var unit time.Duration
+		switch strings.ToUpper(s[end : end+1]) {
+		case "W":
+			if inTime {
+				return 0, false
+			}
+			unit = 7 * 24 * time.Hour
+		case "D":
+			if inTime {
+				return 0, false
+			}
+			unit = 24 * time.Hour
+		case "H":
+			unit = time.Hour
+		case "M":
+			if !inTime {
+				// months
+				return 0, false
+			}
+			unit = time.Minute
+		case "S":
+			unit = time.Second
+		default:
+			return 0, false
+		}
+		if (unit < time.Hour*24) != inTime {
+			return 0, false
+		}
+		d += time.Duration(value * float64(unit))
+		components++
+		hasTime = inTime
+		s = s[end+1:]
+	}
+	if components == 0 || (inTime && !hasTime) {
+		return 0, false
+	}
+	return sign * d, true
+}
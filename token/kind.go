@@ -5,7 +5,7 @@ import (
 	"strings"
 )
 
-type Kind int
+type Kind int64
 
 const (
 	Decimal    Kind = 1 << iota
@@ -16,11 +16,72 @@ const (
 	List       Kind = 1 << iota
 	Map        Kind = 1 << iota
 	Token      Kind = 1 << iota
-	Atom       Kind = Decimal | String | Boolean | Time | Null
+	Money      Kind = 1 << iota
+	Duration   Kind = 1 << iota
+	Atom       Kind = Decimal | String | Boolean | Time | Null | Money | Duration
 	Collection Kind = List | Map
 	Any        Kind = Atom | Token | Collection
 )
 
+// typed collections (List<Decimal>, Map<String, Boolean>) store the kinds of their elements
+// in the upper bits of the kind, element kinds are not nested (List<List<Decimal>> is a List<List>)
+const (
+	baseKindMask     Kind = 1<<listElementShift - 1
+	listElementShift      = 20
+	mapValueShift         = 40
+)
+
+// ListOf returns the kind of a list that only contains elements of the kind elements
+func ListOf(elements Kind) Kind {
+	return List | elements.Base()<<listElementShift
+}
+
+// MapOf returns the kind of a map that only contains values of the kind values, map keys are always strings
+func MapOf(values Kind) Kind {
+	return Map | values.Base()<<mapValueShift
+}
+
+// Optional returns the kind k or Null, optional kinds are written as `Kind?`
+func Optional(k Kind) Kind {
+	return k | Null
+}
+
+// Base returns the kind without the element kinds of typed collections
+func (k Kind) Base() Kind {
+	return k & baseKindMask
+}
+
+// Elements returns the element kinds of a typed list, 0 if the list is not typed
+func (k Kind) Elements() Kind {
+	return k >> listElementShift & baseKindMask
+}
+
+// Values returns the value kinds of a typed map, 0 if the map is not typed
+func (k Kind) Values() Kind {
+	return k >> mapValueShift & baseKindMask
+}
+
+// Accepts returns true if a value of kind v can be used where k is expected,
+// the kinds match if they have a kind in common. Typed collections only accept collections
+// whose element kinds are part of the expected element kinds, untyped (or empty) collections are always accepted
+func (k Kind) Accepts(v Kind) bool {
+	common := k.Base() & v.Base()
+	if common&^Collection != 0 {
+		return true
+	}
+	if common&List != 0 && elementsAccepted(k.Elements(), v.Elements()) {
+		return true
+	}
+	if common&Map != 0 && elementsAccepted(k.Values(), v.Values()) {
+		return true
+	}
+	return false
+}
+
+func elementsAccepted(expected, actual Kind) bool {
+	return expected == 0 || actual == 0 || actual&^expected == 0
+}
+
 // cached for faster lookup
 var kindStrings = map[Kind]string{
 	Decimal:    strings.ToLower(Decimal.String()),
@@ -31,6 +92,8 @@ var kindStrings = map[Kind]string{
 	List:       strings.ToLower(List.String()),
 	Map:        strings.ToLower(Map.String()),
 	Token:      strings.ToLower(Token.String()),
+	Money:      strings.ToLower(Money.String()),
+	Duration:   strings.ToLower(Duration.String()),
 	Atom:       strings.ToLower(Atom.String()),
 	Collection: strings.ToLower(Collection.String()),
 	Any:        strings.ToLower(Any.String()),
@@ -38,7 +101,16 @@ var kindStrings = map[Kind]string{
 
 func (k Kind) String() string {
 	var kinds []string
-	v := k
+	var typed []string
+	v := k.Base()
+	if elements := k.Elements(); elements != 0 && v&List == List {
+		typed = append(typed, "List<"+elements.String()+">")
+		v &= ^List
+	}
+	if values := k.Values(); values != 0 && v&Map == Map {
+		typed = append(typed, "Map<String, "+values.String()+">")
+		v &= ^Map
+	}
 	for v != 0 {
 		if v&Any == Any {
 			kinds = append(kinds, "Any")
@@ -70,6 +142,12 @@ func (k Kind) String() string {
 		} else if v&Map == Map {
 			kinds = append(kinds, "Map")
 			v &= ^Map
+		} else if v&Money == Money {
+			kinds = append(kinds, "Money")
+			v &= ^Money
+		} else if v&Duration == Duration {
+			kinds = append(kinds, "Duration")
+			v &= ^Duration
 		} else if v&Token == Token {
 			kinds = append(kinds, "Token")
 			v &= ^Token
@@ -78,14 +156,35 @@ func (k Kind) String() string {
 			break
 		}
 	}
+	kinds = append(kinds, typed...)
+	// a single kind or null is written as optional kind
+	if len(kinds) == 2 && kinds[0] == "Null" {
+		return kinds[1] + "?"
+	}
+	if len(kinds) == 2 && kinds[1] == "Null" {
+		return kinds[0] + "?"
+	}
 	return strings.Join(kinds, "|")
 }
 
+// KindFromString parses a kind in the form of `Kind|Kind`, collections can be typed using `List<Kind>` and `Map<String, Kind>`,
+// `Kind?` is the kind or Null
 func KindFromString(s string) Kind {
 	var v Kind
-	parts := strings.Split(s, "|")
+	parts := splitKinds(s, '|')
 	for _, part := range parts {
 		part = strings.ToLower(strings.TrimSpace(part))
+		if strings.HasSuffix(part, "?") {
+			part = strings.TrimSpace(part[:len(part)-1])
+			if kind := KindFromString(part); kind != 0 {
+				v |= Optional(kind)
+			}
+			continue
+		}
+		if open := strings.IndexRune(part, '<'); open > 0 && strings.HasSuffix(part, ">") {
+			v |= typedKindFromString(strings.TrimSpace(part[:open]), splitKinds(part[open+1:len(part)-1], ','))
+			continue
+		}
 		switch part {
 		case kindStrings[Decimal]:
 			v |= Decimal
@@ -103,6 +202,10 @@ func KindFromString(s string) Kind {
 			v |= Map
 		case kindStrings[Token]:
 			v |= Token
+		case kindStrings[Money]:
+			v |= Money
+		case kindStrings[Duration]:
+			v |= Duration
 		case kindStrings[Atom]:
 			v |= Atom
 		case kindStrings[Collection]:
@@ -113,3 +216,42 @@ func KindFromString(s string) Kind {
 	}
 	return v
 }
+
+func typedKindFromString(name string, parameters []string) Kind {
+	switch name {
+	case kindStrings[List]:
+		if len(parameters) == 1 {
+			if elements := KindFromString(parameters[0]); elements != 0 {
+				return ListOf(elements)
+			}
+		}
+	case kindStrings[Map]:
+		if len(parameters) == 2 && KindFromString(parameters[0]) == String {
+			if values := KindFromString(parameters[1]); values != 0 {
+				return MapOf(values)
+			}
+		}
+	}
+	return 0
+}
+
+// splitKinds splits s at every separator that is not inside angle brackets
+func splitKinds(s string, separator rune) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case separator:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -15,14 +15,23 @@ func TestKindString(t *testing.T) {
 	require.Equal(t, "List", List.String())
 	require.Equal(t, "Map", Map.String())
 	require.Equal(t, "Token", Token.String())
+	require.Equal(t, "Money", Money.String())
+	require.Equal(t, "Duration", Duration.String())
 	require.Equal(t, "Atom", Atom.String())
 	require.Equal(t, "Collection", Collection.String())
 	require.Equal(t, "Any", Any.String())
 
 	require.Equal(t, "Decimal|String", (Decimal | String).String())
 
-	unknown := Kind(300)
-	require.Equal(t, "Boolean|Time|List|Unknown(256)", unknown.String())
+	unknown := Kind(44 | 1<<16)
+	require.Equal(t, "Boolean|Time|List|Unknown(65536)", unknown.String())
+
+	require.Equal(t, "List<Decimal>", ListOf(Decimal).String())
+	require.Equal(t, "List<Decimal|String>", ListOf(Decimal|String).String())
+	require.Equal(t, "Map<String, Boolean>", MapOf(Boolean).String())
+	require.Equal(t, "List<Decimal>?", (ListOf(Decimal) | Null).String())
+	// element kinds are not nested
+	require.Equal(t, "List<List>", ListOf(ListOf(Decimal)).String())
 }
 
 func TestKindFromString(t *testing.T) {
@@ -34,9 +43,73 @@ func TestKindFromString(t *testing.T) {
 	require.Equal(t, List, KindFromString("List"))
 	require.Equal(t, Map, KindFromString("Map"))
 	require.Equal(t, Token, KindFromString("Token"))
+	require.Equal(t, Money, KindFromString("Money"))
+	require.Equal(t, Duration, KindFromString("Duration"))
 	require.Equal(t, Atom, KindFromString("Atom"))
 	require.Equal(t, Collection, KindFromString("Collection"))
 	require.Equal(t, Any, KindFromString("Any"))
 
-	require.Equal(t, Boolean|Time|List, KindFromString("Boolean|Time|List|Unknown(256)"))
+	require.Equal(t, Boolean|Time|List, KindFromString("Boolean|Time|List|Unknown(65536)"))
+
+	require.Equal(t, ListOf(Decimal), KindFromString("List<Decimal>"))
+	require.Equal(t, ListOf(Decimal|String)|Null, KindFromString("List<Decimal|String>|Null"))
+	require.Equal(t, MapOf(Boolean), KindFromString("Map<String, Boolean>"))
+	require.Equal(t, Kind(0), KindFromString("Map<Decimal, Boolean>"))
+	require.Equal(t, Kind(0), KindFromString("List<Unknown>"))
+}
+
+func TestOptionalKind(t *testing.T) {
+	require.Equal(t, Decimal|Null, Optional(Decimal))
+	require.Equal(t, "Decimal?", Optional(Decimal).String())
+	require.Equal(t, "Decimal|String|Null", Optional(Decimal|String).String())
+	require.Equal(t, "List<Decimal?>", ListOf(Optional(Decimal)).String())
+	require.Equal(t, "Atom", Optional(Atom).String())
+
+	require.Equal(t, Optional(Decimal), KindFromString("Decimal?"))
+	require.Equal(t, Optional(Decimal|String), KindFromString("Decimal? | String"))
+	require.Equal(t, Optional(ListOf(Optional(Decimal))), KindFromString("List<Decimal?>?"))
+	require.Equal(t, Kind(0), KindFromString("Unknown?"))
+
+	require.True(t, Optional(Decimal).Accepts(Null))
+	require.True(t, Optional(Decimal).Accepts(Decimal))
+	require.False(t, Decimal.Accepts(Null))
+}
+
+func TestKindAccepts(t *testing.T) {
+	require.True(t, Decimal.Accepts(Decimal))
+	require.True(t, (Decimal | String).Accepts(String))
+	require.False(t, Decimal.Accepts(String))
+
+	require.True(t, List.Accepts(ListOf(Decimal)))
+	require.True(t, ListOf(Decimal).Accepts(ListOf(Decimal)))
+	require.True(t, ListOf(Decimal|String).Accepts(ListOf(Decimal)))
+	require.False(t, ListOf(Decimal).Accepts(ListOf(Decimal|String)))
+	// untyped and empty lists can not be checked
+	require.True(t, ListOf(Decimal).Accepts(List))
+	require.False(t, ListOf(Decimal).Accepts(Map))
+
+	require.True(t, MapOf(Boolean).Accepts(MapOf(Boolean)))
+	require.False(t, MapOf(Boolean).Accepts(MapOf(String)))
+	require.False(t, MapOf(Boolean).Accepts(ListOf(Boolean)))
+	require.True(t, (MapOf(Boolean) | Null).Accepts(Null))
+}
+
+func TestKindOf(t *testing.T) {
+	require.Equal(t, Decimal, KindOf(NewDecimalFromInt(1)))
+	require.Equal(t, List, KindOf(NewList()))
+	require.Equal(t, ListOf(Decimal|String), KindOf(NewList(NewDecimalFromInt(1), NewString("a"))))
+	require.Equal(t, MapOf(Boolean), KindOf(NewMap(map[string]*TaToken{"a": NewBool(true)})))
+}
+
+func TestKindFor(t *testing.T) {
+	list := NewList(NewDecimalFromInt(1), NewString("a"))
+	// the elements are only collected if a typed collection is expected
+	require.Equal(t, List, KindFor(list, List))
+	require.Equal(t, List, KindFor(list, Any))
+	require.Equal(t, ListOf(Decimal|String), KindFor(list, ListOf(Decimal)))
+	require.Equal(t, MapOf(Boolean), KindFor(NewMap(map[string]*TaToken{"a": NewBool(true)}), MapOf(Boolean)|String))
+	require.Equal(t, Decimal, KindFor(NewDecimalFromInt(1), ListOf(Decimal)))
+
+	require.Equal(t, true, List.Accepts(KindFor(list, List)))
+	require.Equal(t, false, ListOf(Decimal).Accepts(KindFor(list, ListOf(Decimal))))
 }
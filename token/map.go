@@ -0,0 +1,158 @@
+package token
+
+import "sort"
+
+// NewMap creates an immutable map from a go map, the keys are sorted so the order of the map is deterministic
+func NewMap(m map[string]*TaToken) *TaToken {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	children := make([]*TaToken, len(keys))
+	for i, k := range keys {
+		children[i] = m[k]
+	}
+	return NewOrderedMap(keys, children)
+}
+
+// NewOrderedMap creates an immutable map that keeps the order of the keys, the value of a key is the child with the same index.
+// If a key is used multiple times the last value wins
+func NewOrderedMap(keys []string, children []*TaToken) *TaToken {
+	var b TaToken
+	b.Kind = Map
+	b.Keys = make([]string, 0, len(keys))
+	b.Children = make([]*TaToken, 0, len(keys))
+	b.keyIndex = make(map[string]int, len(keys))
+	for i, key := range keys {
+		b.SetMapItem(key, children[i])
+	}
+	return b.Freeze()
+}
+
+// Freeze makes a list or map immutable.
+// Copies of immutable values share their keys and children instead of copying the whole tree (structural sharing),
+// SetMapItem and RemoveMapItem copy the keys and children before they change them (copy-on-write).
+// Immutable values and the values they contain must not be changed in any other way
+func (b *TaToken) Freeze() *TaToken {
+	if b.Kind == List || b.Kind == Map {
+		b.immutable = true
+	}
+	return b
+}
+
+func (b *TaToken) IsImmutable() bool {
+	return b.immutable
+}
+
+// Mutable returns the token if it is not immutable, otherwise a copy whose keys and children can be changed,
+// the children of the copy are still shared and must not be changed
+func (b *TaToken) Mutable() *TaToken {
+	if !b.immutable {
+		return b
+	}
+	var m TaToken
+	Copy(&m, b)
+	m.thaw()
+	return &m
+}
+
+// thaw copies the keys and children of an immutable value so they can be changed,
+// the children themselves stay immutable
+func (b *TaToken) thaw() {
+	if !b.immutable {
+		return
+	}
+	keys := make([]string, len(b.Keys))
+	copy(keys, b.Keys)
+	children := make([]*TaToken, len(b.Children))
+	copy(children, b.Children)
+	b.Keys = keys
+	b.Children = children
+	b.immutable = false
+	if b.Kind == Map {
+		b.reindex()
+	}
+}
+
+// Get an item from the map
+func (b *TaToken) MapItem(key string) *TaToken {
+	if i := b.mapIndex(key); i >= 0 {
+		return b.Children[i]
+	}
+	return NewNull()
+}
+
+// HasMapItem returns true if the map contains the key
+func (b *TaToken) HasMapItem(key string) bool {
+	return b.mapIndex(key) >= 0
+}
+
+// Set an item in the map, new keys are appended to the end of the map
+func (b *TaToken) SetMapItem(key string, value *TaToken) {
+	b.thaw()
+	if i := b.mapIndex(key); i >= 0 {
+		b.Children[i] = value
+		return
+	}
+	if !b.indexValid() {
+		b.reindex()
+	}
+	b.keyIndex[key] = len(b.Keys)
+	b.Keys = append(b.Keys, key)
+	b.Children = append(b.Children, value)
+}
+
+// RemoveMapItem removes the key from the map, the order of the other keys is kept
+func (b *TaToken) RemoveMapItem(key string) {
+	i := b.mapIndex(key)
+	if i < 0 {
+		return
+	}
+	b.thaw()
+	b.Keys = append(b.Keys[:i], b.Keys[i+1:]...)
+	b.Children = append(b.Children[:i], b.Children[i+1:]...)
+	b.reindex()
+}
+
+// Create the map
+func (b *TaToken) Map() map[string]*TaToken {
+	m := make(map[string]*TaToken)
+
+	for i, key := range b.Keys {
+		m[key] = b.Children[i]
+	}
+	return m
+}
+
+// mapIndex returns the index of the key in Keys or -1.
+// Keys and Children are exported and can be appended to or replaced without updating the index,
+// if the index does not match the keys anymore the keys are searched instead, so reading a map never writes to it.
+// Keys must not be renamed in place
+func (b *TaToken) mapIndex(key string) int {
+	if b.indexValid() {
+		if i, ok := b.keyIndex[key]; ok && b.Keys[i] == key {
+			return i
+		} else if !ok {
+			return -1
+		}
+	}
+	for i, k := range b.Keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *TaToken) indexValid() bool {
+	return b.keyIndex != nil && len(b.keyIndex) == len(b.Keys)
+}
+
+func (b *TaToken) reindex() {
+	b.keyIndex = make(map[string]int, len(b.Keys))
+	for i, key := range b.Keys {
+		b.keyIndex[key] = i
+	}
+}
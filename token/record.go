@@ -0,0 +1,79 @@
+package token
+
+import (
+	"sort"
+	"strings"
+)
+
+// RecordType describes a map with named fields, e.g. a CartItem binding with a Name and a Price
+type RecordType struct {
+	Name   string
+	Fields map[string]Kind
+}
+
+// NewRecord creates a map that is attached to the record type with the name
+func NewRecord(name string, m map[string]*TaToken) *TaToken {
+	b := NewMap(m)
+	b.Record = name
+	return b
+}
+
+func (b *TaToken) IsRecord() bool {
+	return b.Kind == Map && len(b.Record) > 0
+}
+
+// Matches returns true if the token is a map that has all fields of the record type with matching kinds,
+// additional fields are allowed
+func (r *RecordType) Matches(t *TaToken) bool {
+	if t.Kind != Map {
+		return false
+	}
+	for name, kind := range r.Fields {
+		found := false
+		for i, key := range t.Keys {
+			if key == name {
+				if !kind.Accepts(KindFor(t.Children[i], kind)) {
+					return false
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal returns true if both record types have the same name (case insensitive) and the same fields with the same kinds
+func (r *RecordType) Equal(o *RecordType) bool {
+	if !strings.EqualFold(r.Name, o.Name) || len(r.Fields) != len(o.Fields) {
+		return false
+	}
+	for name, kind := range r.Fields {
+		if other, ok := o.Fields[name]; !ok || other != kind {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldNames returns the sorted names of the fields
+func (r *RecordType) FieldNames() []string {
+	names := make([]string, 0, len(r.Fields))
+	for name := range r.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *RecordType) String() string {
+	names := r.FieldNames()
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = name + " " + r.Fields[name].String()
+	}
+	return r.Name + "{" + strings.Join(fields, ", ") + "}"
+}
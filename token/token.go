@@ -11,15 +11,29 @@ import (
 	"github.com/talon-one/decimal"
 )
 
+// DateLayout is the layout of calendar dates
+const DateLayout = "2006-01-02"
+
 type TaToken struct {
 	// String contains the string value of an block
-	String   string
-	Decimal  decimal.Decimal
-	Bool     bool
-	Time     time.Time
+	String  string
+	Decimal decimal.Decimal
+	Bool    bool
+	Time    time.Time
+	// DateOnly marks a Time as a calendar date without a time component
+	DateOnly bool
+	Duration time.Duration
+	Currency string
 	Kind     Kind
 	Children []*TaToken
-	Keys     []string
+	// Keys contains the keys of a map in insertion order, the value of a key is the child with the same index
+	Keys []string
+	// keyIndex maps the keys to their index in Keys, see mapIndex
+	keyIndex map[string]int
+	// immutable lists and maps share their keys and children with their copies, see Freeze
+	immutable bool
+	// Record contains the name of the RecordType a map is attached to
+	Record string
 }
 
 func New(text string, children ...*TaToken) *TaToken {
@@ -98,6 +112,17 @@ func NewTime(t time.Time) *TaToken {
 	return &b
 }
 
+// NewDate creates a calendar date (without a time component) from the year, month and day of t
+func NewDate(t time.Time) *TaToken {
+	var b TaToken
+	b.Time = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	b.DateOnly = true
+	b.Kind = Time
+	b.String = b.Time.Format(DateLayout)
+	b.Children = []*TaToken{}
+	return &b
+}
+
 func NewString(str string) *TaToken {
 	var b TaToken
 	b.String = str
@@ -113,6 +138,7 @@ func NewNull() *TaToken {
 	return &b
 }
 
+// NewList creates an immutable list, the list uses the children slice
 func NewList(children ...*TaToken) *TaToken {
 	var b TaToken
 	if children == nil {
@@ -121,21 +147,7 @@ func NewList(children ...*TaToken) *TaToken {
 		b.Children = children
 	}
 	b.Kind = List
-	return &b
-}
-
-func NewMap(m map[string]*TaToken) *TaToken {
-	var b TaToken
-	b.Children = make([]*TaToken, len(m))
-	b.Keys = make([]string, len(m))
-	b.Kind = Map
-	i := 0
-	for k, v := range m {
-		b.Keys[i] = k
-		b.Children[i] = v
-		i++
-	}
-	return &b
+	return b.Freeze()
 }
 
 func NewToken(text string, children ...*TaToken) *TaToken {
@@ -170,6 +182,10 @@ func (b *TaToken) IsTime() bool {
 	return b.Kind == Time
 }
 
+func (b *TaToken) IsDate() bool {
+	return b.Kind == Time && b.DateOnly
+}
+
 func (b *TaToken) IsString() bool {
 	return b.Kind == String
 }
@@ -186,38 +202,6 @@ func (b *TaToken) IsMap() bool {
 	return b.Kind == Map
 }
 
-// Get an item from the map
-func (b *TaToken) MapItem(key string) *TaToken {
-	for i, k := range b.Keys {
-		if key == k {
-			return b.Children[i]
-		}
-	}
-	return NewNull()
-}
-
-// Set an item in the map
-func (b *TaToken) SetMapItem(key string, value *TaToken) {
-	for i, k := range b.Keys {
-		if key == k {
-			b.Children[i] = value
-			return
-		}
-	}
-	b.Keys = append(b.Keys, key)
-	b.Children = append(b.Children, value)
-}
-
-// Create the map
-func (b *TaToken) Map() map[string]*TaToken {
-	m := make(map[string]*TaToken)
-
-	for i, key := range b.Keys {
-		m[key] = b.Children[i]
-	}
-	return m
-}
-
 func (b *TaToken) initValue(text string) {
 	// only blocks could have children
 	if len(b.Children) > 0 {
@@ -248,6 +232,15 @@ func (b *TaToken) initValue(text string) {
 			}
 		}
 
+		// is it a duration?
+		if d, ok := ParseDuration(text); ok {
+			// the text is kept, so words like 1m or P1D can still be used as keys,
+			// durations are compared by their value (60m equals 1h)
+			b.Duration = d
+			b.Kind = Duration
+			return
+		}
+
 		// is it a time?
 		var err error
 		b.Time, err = time.Parse(time.RFC3339, text)
@@ -256,6 +249,14 @@ func (b *TaToken) initValue(text string) {
 			return
 		}
 
+		// is it a date?
+		b.Time, err = time.Parse(DateLayout, text)
+		if err == nil {
+			b.Kind = Time
+			b.DateOnly = true
+			return
+		}
+
 		b.Kind = String
 	} else {
 		b.Kind = Token
@@ -299,16 +300,36 @@ func Copy(dst *TaToken, src *TaToken) {
 		return
 	}
 	dst.Kind = src.Kind
+	dst.immutable = false
+	if src.immutable {
+		// immutable values never change, so the copy can share the keys and children
+		dst.String = src.String
+		dst.Keys = src.Keys
+		dst.keyIndex = src.keyIndex
+		dst.Record = src.Record
+		dst.Children = src.Children
+		dst.immutable = true
+		return
+	}
 	switch dst.Kind {
 	case Decimal:
-		dst.Decimal = src.Decimal
+		// decimals are pointer based, so operations on the copy would modify the source
+		dst.Decimal = decimal.NewFromDecimal(src.Decimal)
+	case Money:
+		dst.Decimal = decimal.NewFromDecimal(src.Decimal)
+		dst.Currency = src.Currency
 	case Boolean:
 		dst.Bool = src.Bool
 	case Time:
 		dst.Time = src.Time
+		dst.DateOnly = src.DateOnly
+	case Duration:
+		dst.Duration = src.Duration
 	case Map:
 		dst.Keys = make([]string, len(src.Keys))
 		copy(dst.Keys, src.Keys)
+		dst.Record = src.Record
+		dst.reindex()
 	}
 	dst.String = src.String
 	dst.Children = make([]*TaToken, len(src.Children))
@@ -374,12 +395,16 @@ func (b *TaToken) Equal(a *TaToken) bool {
 	switch a.Kind {
 	case Decimal:
 		return a.Decimal.Equals(b.Decimal)
+	case Money:
+		return a.Currency == b.Currency && a.Decimal.Equals(b.Decimal)
 	case String:
 		return a.String == b.String
 	case Boolean:
 		return a.Bool == b.Bool
 	case Time:
-		return a.Time.Equal(b.Time)
+		return a.DateOnly == b.DateOnly && a.Time.Equal(b.Time)
+	case Duration:
+		return a.Duration == b.Duration
 	case Null:
 		return true
 	case Map:
@@ -421,7 +446,23 @@ func (t *TaToken) MarshalJSON() ([]byte, error) {
 		keys["Boolean"] = t.Bool
 	}
 	if t.Kind&Time == Time {
-		keys["Time"] = t.Time
+		if t.DateOnly {
+			keys["Date"] = t.String
+		} else {
+			keys["Time"] = t.Time
+		}
+	}
+	if t.Kind&Duration == Duration {
+		keys["Duration"] = t.Duration.String()
+	}
+	if t.Kind&Money == Money {
+		keys["Money"] = struct {
+			Amount   string
+			Currency string
+		}{
+			Amount:   t.Decimal.String(),
+			Currency: t.Currency,
+		}
 	}
 	if t.Kind&Null == Null {
 		keys["Null"] = true
@@ -437,6 +478,9 @@ func (t *TaToken) MarshalJSON() ([]byte, error) {
 		}
 
 		keys["Map"] = mapKeys
+		if len(t.Record) > 0 {
+			keys["Record"] = t.Record
+		}
 	}
 	if t.Kind&Token == Token {
 		keys["Token"] = struct {
@@ -467,11 +511,35 @@ func (t *TaToken) UnmarshalJSON(b []byte) error {
 func Arguments(children []*TaToken) []Kind {
 	types := make([]Kind, len(children))
 	for i, child := range children {
-		types[i] = child.Kind
+		types[i] = KindOf(child)
 	}
 	return types
 }
 
+// KindOf returns the kind of the token, for lists and maps the kind contains the kinds of the elements
+func KindOf(t *TaToken) Kind {
+	if t.Kind != List && t.Kind != Map {
+		return t.Kind
+	}
+	var elements Kind
+	for _, child := range t.Children {
+		elements |= child.Kind
+	}
+	if t.Kind == List {
+		return ListOf(elements)
+	}
+	return MapOf(elements)
+}
+
+// KindFor returns the kind of the token that is needed to check it against the expected kind,
+// the element kinds of lists and maps (see KindOf) are only collected if a typed collection is expected
+func KindFor(t *TaToken, expected Kind) Kind {
+	if expected.Elements() == 0 && expected.Values() == 0 {
+		return t.Kind
+	}
+	return KindOf(t)
+}
+
 type TokenArguments []*TaToken
 
 func (b TokenArguments) ToHumanReadable() string {
@@ -483,7 +551,7 @@ func (b TokenArguments) ToHumanReadable() string {
 }
 func (b TokenArguments) Len() int           { return len(b) }
 func (b TokenArguments) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
-func (b TokenArguments) Less(i, j int) bool { return strings.Compare(b[i].String, b[j].String) < 0 }
+func (b TokenArguments) Less(i, j int) bool { return Compare(b[i], b[j]) < 0 }
 
 func (k *Kind) UnmarshalJSON(b []byte) (err error) {
 	*k = KindFromString(strings.Trim(string(b), `"`))
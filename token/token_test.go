@@ -3,6 +3,7 @@ package token
 import (
 	"encoding/json"
 	"sort"
+	"strconv"
 	"testing"
 	"time"
 
@@ -300,9 +301,21 @@ func TestCopy(t *testing.T) {
 	require.Equal(t, true, b.IsTime())
 	require.Equal(t, "2006-01-02T15:04:05Z", b.String)
 
-	Copy(&b, NewDecimalFromInt(1))
+	src := NewDecimalFromInt(1)
+	Copy(&b, src)
 	require.Equal(t, true, b.IsDecimal())
 	require.Equal(t, "1", b.String)
+	// modifying the copy must not modify the source
+	b.Decimal.Add(decimal.NewFromInt(1))
+	require.Equal(t, "1", src.Decimal.String())
+
+	money := NewMoneyFromString("1.5", "EUR")
+	Copy(&b, money)
+	require.Equal(t, true, b.IsMoney())
+	require.Equal(t, "EUR", b.Currency)
+	require.Equal(t, "1.50 EUR", b.String)
+	b.Decimal.Add(decimal.NewFromInt(1))
+	require.Equal(t, "1.5", money.Decimal.String())
 
 	Copy(&b, NewNull())
 	require.Equal(t, true, b.IsNull())
@@ -323,6 +336,67 @@ func TestCopy(t *testing.T) {
 	}, b.Map())
 }
 
+func TestMoney(t *testing.T) {
+	require.Equal(t, "12.50 EUR", NewMoneyFromString("12.5", "eur").Stringify())
+	require.Equal(t, "12.125 EUR", NewMoneyFromString("12.125", "EUR").Stringify())
+	require.Equal(t, "100 JPY", NewMoneyFromString("100", "JPY").Stringify())
+	require.Equal(t, true, NewMoneyFromString("invalid", "EUR").IsNull())
+
+	require.Equal(t, true, NewMoneyFromString("1.5", "EUR").Equal(NewMoneyFromString("1.50", "EUR")))
+	require.Equal(t, false, NewMoneyFromString("1.5", "EUR").Equal(NewMoneyFromString("1.5", "USD")))
+	require.Equal(t, false, NewMoneyFromString("1.5", "EUR").Equal(NewDecimalFromString("1.5")))
+
+	b, err := json.Marshal(NewMoneyFromString("1.5", "EUR"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Money":{"Amount":"1.5","Currency":"EUR"}}`, string(b))
+
+	units, ok := CurrencyMinorUnits("kwd")
+	require.Equal(t, true, ok)
+	require.Equal(t, 3, units)
+	_, ok = CurrencyMinorUnits("XYZ")
+	require.Equal(t, false, ok)
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"3h", 3 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+		{"-500ms", -500 * time.Millisecond},
+		{"P1DT2H", 26 * time.Hour},
+		{"PT1.5S", 1500 * time.Millisecond},
+		{"P2W", 14 * 24 * time.Hour},
+		{"-PT30M", -30 * time.Minute},
+	}
+	for _, test := range tests {
+		tkn := New(test.input)
+		require.Equal(t, true, tkn.IsDuration(), test.input)
+		require.Equal(t, test.expected, tkn.Duration, test.input)
+	}
+
+	for _, input := range []string{"P", "PT", "P1M", "P1Y", "PT1D", "P1H", "1", "hello", "P1DT"} {
+		require.Equal(t, false, New(input).IsDuration(), input)
+	}
+
+	// the text of a literal is kept, so it can still be used as a key
+	require.Equal(t, "P1DT2H", New("P1DT2H").Stringify())
+	require.Equal(t, "1m", New("1m").String)
+	require.Equal(t, true, New("P1DT2H").Equal(New("26h")))
+	require.Equal(t, 0, Compare(New("60m"), New("1h")))
+	require.Equal(t, "26h0m0s", NewDuration(26*time.Hour).String)
+
+	var b TaToken
+	Copy(&b, NewDuration(time.Hour))
+	require.Equal(t, true, b.IsDuration())
+	require.Equal(t, time.Hour, b.Duration)
+
+	buf, err := json.Marshal(NewDuration(90 * time.Minute))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Duration":"1h30m0s"}`, string(buf))
+}
+
 func TestMapItem(t *testing.T) {
 	tkn := NewMap(map[string]*TaToken{
 		"Key1": NewBool(true),
@@ -406,6 +480,59 @@ func TestStringify(t *testing.T) {
 	}
 
 	require.Equal(t, `{Key1:{SubKey1:14.5}, Key2:"Hello"}`, tkn.Stringify())
+
+	// NewMap sorts the keys
+	tkn = NewMap(map[string]*TaToken{
+		"Key2": NewString("Hello"),
+		"Key1": NewMap(map[string]*TaToken{
+			"SubKey1": NewDecimalFromString("14.5"),
+		}),
+	})
+	require.Equal(t, `{Key1:{SubKey1:14.5}, Key2:"Hello"}`, tkn.Stringify())
+}
+
+func TestOrderedMap(t *testing.T) {
+	m := NewOrderedMap([]string{"b", "a", "c", "a"}, []*TaToken{
+		NewDecimalFromInt(1),
+		NewDecimalFromInt(2),
+		NewDecimalFromInt(3),
+		NewDecimalFromInt(4),
+	})
+	require.Equal(t, []string{"b", "a", "c"}, m.Keys)
+	require.Equal(t, "4", m.MapItem("a").String)
+	require.True(t, m.HasMapItem("c"))
+	require.False(t, m.HasMapItem("d"))
+	require.True(t, m.MapItem("d").IsNull())
+
+	m.SetMapItem("d", NewDecimalFromInt(5))
+	m.SetMapItem("b", NewDecimalFromInt(6))
+	require.Equal(t, "{b:6, a:4, c:3, d:5}", m.Stringify())
+
+	m.RemoveMapItem("a")
+	m.RemoveMapItem("unknown")
+	require.Equal(t, "{b:6, c:3, d:5}", m.Stringify())
+	require.Equal(t, "5", m.MapItem("d").String)
+
+	// copies have their own index
+	var copied TaToken
+	Copy(&copied, m)
+	copied.SetMapItem("e", NewDecimalFromInt(7))
+	require.False(t, m.HasMapItem("e"))
+	require.True(t, copied.HasMapItem("e"))
+
+	// Keys and Children can still be changed directly
+	m.Keys = append(m.Keys, "f")
+	m.Children = append(m.Children, NewDecimalFromInt(8))
+	require.Equal(t, "8", m.MapItem("f").String)
+	m.SetMapItem("f", NewDecimalFromInt(9))
+	require.Equal(t, "9", m.MapItem("f").String)
+	require.Len(t, m.Keys, 4)
+
+	// maps without an index
+	m = &TaToken{Kind: Map, Keys: []string{"a"}, Children: []*TaToken{NewDecimalFromInt(1)}}
+	require.Equal(t, "1", m.MapItem("a").String)
+	m.SetMapItem("b", NewDecimalFromInt(2))
+	require.Equal(t, "{a:1, b:2}", m.Stringify())
 }
 
 func TestEqual(t *testing.T) {
@@ -563,6 +690,80 @@ func BenchmarkTimeParse(b *testing.B) {
 	}
 }
 
+func TestImmutableCollections(t *testing.T) {
+	inner := NewMap(map[string]*TaToken{"a": NewDecimalFromInt(1)})
+	list := NewList(NewDecimalFromInt(1), inner)
+	require.True(t, list.IsImmutable())
+	require.True(t, inner.IsImmutable())
+
+	// copies share the children
+	var copied TaToken
+	Copy(&copied, list)
+	require.True(t, copied.IsImmutable())
+	require.True(t, &list.Children[0] == &copied.Children[0])
+	require.True(t, copied.Equal(list))
+
+	// changing a map copies its keys and children first
+	var m TaToken
+	Copy(&m, inner)
+	m.SetMapItem("a", NewDecimalFromInt(2))
+	m.SetMapItem("b", NewDecimalFromInt(3))
+	require.False(t, m.IsImmutable())
+	require.Equal(t, "{a:2, b:3}", m.Stringify())
+	require.Equal(t, "{a:1}", inner.Stringify())
+
+	m.RemoveMapItem("a")
+	require.Equal(t, "{a:1}", inner.Stringify())
+
+	// mutable copies own their children
+	mutable := list.Mutable()
+	require.False(t, mutable.IsImmutable())
+	mutable.Children[0] = NewDecimalFromInt(5)
+	require.Equal(t, "1", list.Children[0].String)
+	require.True(t, mutable.Mutable() == mutable)
+
+	// tokens created without a constructor are copied completely
+	plain := &TaToken{Kind: List, Children: []*TaToken{NewDecimalFromInt(1)}}
+	require.False(t, plain.IsImmutable())
+	Copy(&copied, plain)
+	require.False(t, copied.IsImmutable())
+	require.False(t, &plain.Children[0] == &copied.Children[0])
+}
+
+func BenchmarkCopyList(b *testing.B) {
+	children := make([]*TaToken, 10000)
+	for i := range children {
+		children[i] = NewDecimalFromInt(int64(i))
+	}
+	b.Run("Immutable", func(b *testing.B) {
+		list := NewList(children...)
+		for i := 0; i < b.N; i++ {
+			var copied TaToken
+			Copy(&copied, list)
+		}
+	})
+	b.Run("Mutable", func(b *testing.B) {
+		list := &TaToken{Kind: List, Children: children}
+		for i := 0; i < b.N; i++ {
+			var copied TaToken
+			Copy(&copied, list)
+		}
+	})
+}
+
+func BenchmarkMapItem(b *testing.B) {
+	m := NewOrderedMap(nil, nil)
+	keys := make([]string, 5000)
+	for i := range keys {
+		keys[i] = "Attribute" + strconv.Itoa(i)
+		m.SetMapItem(keys[i], NewDecimalFromInt(int64(i)))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.MapItem(keys[i%len(keys)])
+	}
+}
+
 func BenchmarkMarshaling(ba *testing.B) {
 	block1 := NewMap(map[string]*TaToken{
 		"Key2": NewDecimalFromInt(1),
@@ -581,3 +782,50 @@ func BenchmarkMarshaling(ba *testing.B) {
 		require.Equal(ba, true, block1.Equal(&block2))
 	}
 }
+
+func TestRecordTypeMatches(t *testing.T) {
+	record := RecordType{
+		Name: "CartItem",
+		Fields: map[string]Kind{
+			"Name":  String,
+			"Price": Decimal | Null,
+			"Tags":  ListOf(String),
+		},
+	}
+	require.Equal(t, "CartItem{Name String, Price Decimal?, Tags List<String>}", record.String())
+
+	item := NewMap(map[string]*TaToken{
+		"Name":  NewString("Shoe"),
+		"Price": NewDecimalFromInt(20),
+		"Tags":  NewList(NewString("sale")),
+		"Color": NewString("red"),
+	})
+	require.True(t, record.Matches(item))
+
+	item.SetMapItem("Price", NewNull())
+	require.True(t, record.Matches(item))
+
+	item.SetMapItem("Tags", NewList(NewDecimalFromInt(1)))
+	require.False(t, record.Matches(item))
+
+	require.False(t, record.Matches(NewMap(map[string]*TaToken{"Name": NewString("Shoe")})))
+	require.False(t, record.Matches(NewList()))
+
+	attached := NewRecord("CartItem", map[string]*TaToken{"Name": NewString("Shoe")})
+	require.True(t, attached.IsRecord())
+	var copied TaToken
+	Copy(&copied, attached)
+	require.Equal(t, "CartItem", copied.Record)
+}
+
+func TestSortKinds(t *testing.T) {
+	list := NewList(NewDecimalFromInt(10), NewString("a"), NewDecimalFromInt(9), NewTime(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)), NewTime(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)))
+	sort.Sort(TokenArguments(list.Children))
+	require.Equal(t, true, list.Equal(NewList(
+		NewDecimalFromInt(9),
+		NewDecimalFromInt(10),
+		NewTime(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)),
+		NewTime(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)),
+		NewString("a"),
+	)), list.Stringify())
+}